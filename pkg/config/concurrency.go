@@ -0,0 +1,7 @@
+package config
+
+// Concurrency bounds the number of queries running at once against each datasource, shared by all API requests; zero means unbounded
+type Concurrency struct {
+	Loki       int `yaml:"loki,omitempty" json:"loki,omitempty"`
+	Prometheus int `yaml:"prometheus,omitempty" json:"prometheus,omitempty"`
+}
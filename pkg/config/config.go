@@ -143,18 +143,22 @@ type Frontend struct {
 	DataSources          []string                     `yaml:"dataSources" json:"dataSources"`
 	LokiMocks            bool                         `yaml:"lokiMocks,omitempty" json:"lokiMocks,omitempty"`
 	LokiLabels           []string                     `yaml:"lokiLabels" json:"lokiLabels"`
+	LokiTenants          []string                     `yaml:"lokiTenants,omitempty" json:"lokiTenants,omitempty"`
 	PromLabels           []string                     `yaml:"promLabels" json:"promLabels"`
 	MaxChunkAgeMs        int                          `yaml:"maxChunkAgeMs,omitempty" json:"maxChunkAgeMs,omitempty"` // populated at query time
 	RecordingAnnotations map[string]map[string]string `yaml:"recordingAnnotations,omitempty" json:"recordingAnnotations,omitempty"`
 }
 
 type Config struct {
-	Loki       Loki       `yaml:"loki" json:"loki"`
-	Prometheus Prometheus `yaml:"prometheus" json:"prometheus"`
-	Frontend   Frontend   `yaml:"frontend" json:"frontend"`
-	Server     Server     `yaml:"server,omitempty" json:"server,omitempty"`
-	Path       string     `yaml:"-" json:"-"`
-	Static     bool
+	Loki        Loki        `yaml:"loki" json:"loki"`
+	Prometheus  Prometheus  `yaml:"prometheus" json:"prometheus"`
+	Frontend    Frontend    `yaml:"frontend" json:"frontend"`
+	Server      Server      `yaml:"server,omitempty" json:"server,omitempty"`
+	Guardrails  Guardrails  `yaml:"guardrails,omitempty" json:"guardrails,omitempty"`
+	Concurrency Concurrency `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	Export      Export      `yaml:"export,omitempty" json:"export,omitempty"`
+	Path        string      `yaml:"-" json:"-"`
+	Static      bool
 }
 
 func ReadFile(version, date, filename string) (*Config, error) {
@@ -171,11 +175,25 @@ func ReadFile(version, date, filename string) (*Config, error) {
 			AuthCheck:   "auto",
 		},
 		Loki: Loki{
-			Timeout: Duration{Duration: 30 * time.Second},
+			Timeout:         Duration{Duration: 30 * time.Second},
+			MaxResponseSize: 100 << 20,
 		},
 		Prometheus: Prometheus{
 			Timeout: Duration{Duration: 30 * time.Second},
 		},
+		Concurrency: Concurrency{
+			Loki:       16,
+			Prometheus: 16,
+		},
+		Export: Export{
+			PageSize: 1000,
+			MaxRows:  1000000,
+			Jobs: ExportJobs{
+				Retention:    Duration{Duration: time.Hour},
+				MaxSize:      1 << 30,
+				MaxTotalSize: 10 << 30,
+			},
+		},
 		Frontend: Frontend{
 			BuildVersion: version,
 			BuildDate:    date,
@@ -224,6 +242,9 @@ func ReadFile(version, date, filename string) (*Config, error) {
 		cfg.Frontend.DataSources = append(cfg.Frontend.DataSources, string(constants.DataSourceLoki))
 		cfg.Frontend.LokiMocks = cfg.Loki.UseMocks
 		cfg.Frontend.LokiLabels = cfg.Loki.Labels
+		if tenants := cfg.Loki.GetTenantIDs(); len(tenants) > 1 {
+			cfg.Frontend.LokiTenants = tenants
+		}
 		cfg.Loki.FieldsType = make(map[string]string)
 		cfg.Loki.FieldsFormat = make(map[string]string)
 		for _, f := range cfg.Frontend.Fields {
@@ -250,6 +271,11 @@ func ReadFile(version, date, filename string) (*Config, error) {
 	return &cfg, err
 }
 
+// WriteTimeout is the write timeout of the server, which must let Loki queries complete
+func (c *Config) WriteTimeout() time.Duration {
+	return max(30*time.Second, c.Loki.Timeout.Duration)
+}
+
 func (c *Config) IsLokiEnabled() bool {
 	return c.Loki.URL != ""
 }
@@ -0,0 +1,22 @@
+package config
+
+// Export configures exports of flows
+type Export struct {
+	// PageSize is the number of flows fetched per Loki query by paged exports
+	PageSize int `yaml:"pageSize,omitempty" json:"pageSize,omitempty"`
+	// MaxRows is the maximum number of flows written by a paged export
+	MaxRows int        `yaml:"maxRows,omitempty" json:"maxRows,omitempty"`
+	Jobs    ExportJobs `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+}
+
+// ExportJobs configures asynchronous exports, whose results are spooled to a local directory
+type ExportJobs struct {
+	// Dir is where results are spooled; jobs are disabled when empty
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Retention is how long results are kept once a job completes
+	Retention Duration `yaml:"retention,omitempty" json:"retention,omitempty"`
+	// MaxSize is the maximum size of a job result, in bytes
+	MaxSize int64 `yaml:"maxSize,omitempty" json:"maxSize,omitempty"`
+	// MaxTotalSize is the maximum size of all results, in bytes; new jobs are rejected when reached
+	MaxTotalSize int64 `yaml:"maxTotalSize,omitempty" json:"maxTotalSize,omitempty"`
+}
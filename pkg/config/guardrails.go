@@ -0,0 +1,48 @@
+package config
+
+import "time"
+
+// Guardrails are limits enforced on flows and topology queries, that can differ for cluster admins and other users (dev)
+type Guardrails struct {
+	Admin GuardrailLimits `yaml:"admin,omitempty" json:"admin,omitempty"`
+	Dev   GuardrailLimits `yaml:"dev,omitempty" json:"dev,omitempty"`
+}
+
+// GuardrailLimits are the limits enforced for a role; zero values mean unlimited
+type GuardrailLimits struct {
+	// MaxRange is the maximum time range of a query
+	MaxRange Duration `yaml:"maxRange,omitempty" json:"maxRange,omitempty"`
+	// MinSteps defines the minimum step allowed above a given time range
+	MinSteps []MinStep `yaml:"minSteps,omitempty" json:"minSteps,omitempty"`
+	// MaxLimit is the maximum number of flows or topology series; when set, a limit is required
+	MaxLimit int `yaml:"maxLimit,omitempty" json:"maxLimit,omitempty"`
+	// MaxQueries is the maximum number of queries run for a request, after expanding OR filters
+	MaxQueries int `yaml:"maxQueries,omitempty" json:"maxQueries,omitempty"`
+	// MaxCost is the maximum estimated cost of Loki queries, in stream-hours (streams matched by label filters × range in hours)
+	MaxCost float64 `yaml:"maxCost,omitempty" json:"maxCost,omitempty"`
+}
+
+type MinStep struct {
+	Range Duration `yaml:"range" json:"range"`
+	Step  Duration `yaml:"step" json:"step"`
+}
+
+// For returns the limits applying to cluster admins or other users
+func (g *Guardrails) For(isAdmin bool) *GuardrailLimits {
+	if isAdmin {
+		return &g.Admin
+	}
+	return &g.Dev
+}
+
+// MinStepFor returns the minimum step allowed for a time range, ie. the one of the largest range threshold reached
+func (l *GuardrailLimits) MinStepFor(r time.Duration) time.Duration {
+	var threshold, step time.Duration
+	for _, ms := range l.MinSteps {
+		if r >= ms.Range.Duration && ms.Range.Duration >= threshold {
+			threshold = ms.Range.Duration
+			step = ms.Step.Duration
+		}
+	}
+	return step
+}
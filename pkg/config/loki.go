@@ -7,6 +7,13 @@ import (
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils"
 )
 
+const (
+	// MultiTenancyHeader queries all tenants at once, with the pipe-joined X-Scope-OrgID header
+	MultiTenancyHeader = "header"
+	// MultiTenancySplit runs one query per tenant, merging results
+	MultiTenancySplit = "split"
+)
+
 type Loki struct {
 	URL                string            `yaml:"url" json:"url"`
 	Labels             []string          `yaml:"labels" json:"labels"`
@@ -15,6 +22,8 @@ type Loki struct {
 	StatusURL          string            `yaml:"statusUrl,omitempty" json:"statusUrl,omitempty"`
 	Timeout            Duration          `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 	TenantID           string            `yaml:"tenantID,omitempty" json:"tenantID,omitempty"`
+	TenantIDs          []string          `yaml:"tenantIDs,omitempty" json:"tenantIDs,omitempty"`
+	MultiTenancy       string            `yaml:"multiTenancy,omitempty" json:"multiTenancy,omitempty"`
 	TokenPath          string            `yaml:"tokenPath,omitempty" json:"tokenPath,omitempty"`
 	SkipTLS            bool              `yaml:"skipTls,omitempty" json:"skipTls,omitempty"`
 	CAPath             string            `yaml:"caPath,omitempty" json:"caPath,omitempty"`
@@ -25,7 +34,27 @@ type Loki struct {
 	StatusUserKeyPath  string            `yaml:"statusUserKeyPath,omitempty" json:"statusUserKeyPath,omitempty"`
 	UseMocks           bool              `yaml:"useMocks,omitempty" json:"useMocks,omitempty"`
 	ForwardUserToken   bool              `yaml:"forwardUserToken,omitempty" json:"forwardUserToken,omitempty"`
+	StructuredMetadata []string          `yaml:"structuredMetadata,omitempty" json:"structuredMetadata,omitempty"`
+	NamespaceParam     bool              `yaml:"namespaceParam,omitempty" json:"namespaceParam,omitempty"`
+	MaxResponseSize    int64             `yaml:"maxResponseSize,omitempty" json:"maxResponseSize,omitempty"` // bytes, 100 MiB by default; zero or negative disables the limit
 	labelsMap          map[string]struct{}
+	metadataMap        map[string]struct{}
+}
+
+// GetTenantIDs returns the configured tenants, either from the list or the single tenant ID
+func (l *Loki) GetTenantIDs() []string {
+	if len(l.TenantIDs) > 0 {
+		return l.TenantIDs
+	}
+	if l.TenantID != "" {
+		return []string{l.TenantID}
+	}
+	return nil
+}
+
+// SplitTenants tells whether several tenants must be queried separately
+func (l *Loki) SplitTenants(tenants []string) bool {
+	return l.MultiTenancy == MultiTenancySplit && len(tenants) > 1
 }
 
 func (l *Loki) GetStatusURL() string {
@@ -35,6 +64,12 @@ func (l *Loki) GetStatusURL() string {
 	return l.URL
 }
 
+// UseNamespaceParam tells whether namespace-scoped queries are restricted by the Loki gateway, through the "namespace" query param,
+// rather than by expanding queries on source and destination namespaces
+func (l *Loki) UseNamespaceParam(namespace string) bool {
+	return namespace != "" && l.NamespaceParam
+}
+
 func (l *Loki) IsLabel(key string) bool {
 	if l.labelsMap == nil {
 		l.labelsMap = utils.GetMapInterface(l.Labels)
@@ -43,6 +78,20 @@ func (l *Loki) IsLabel(key string) bool {
 	return isLabel
 }
 
+// IsStructuredMetadata tells whether a field is stored as Loki structured metadata, which can be filtered and aggregated without parsing JSON
+func (l *Loki) IsStructuredMetadata(key string) bool {
+	if l.metadataMap == nil {
+		l.metadataMap = utils.GetMapInterface(l.StructuredMetadata)
+	}
+	_, isMetadata := l.metadataMap[key]
+	return isMetadata
+}
+
+// IsLabelOrMetadata tells whether a field is available without parsing the JSON payload
+func (l *Loki) IsLabelOrMetadata(key string) bool {
+	return l.IsLabel(key) || l.IsStructuredMetadata(key)
+}
+
 func (l *Loki) IsNumeric(v string) bool {
 	// check on Field / SrcField / DstField since we remove prefix in some cases for common filtering
 	types := fmt.Sprintf("%s|%s|%s", l.FieldsType[v], l.FieldsType["Src"+v], l.FieldsType["Dst"+v])
@@ -6,8 +6,10 @@ import (
 	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"github.com/sirupsen/logrus"
 )
@@ -222,8 +224,8 @@ func createNodePairAlert(probability float64, name string, threshold, upperBound
 		alertLabels := labels.Clone()
 		alertState := randomState()
 		alertLabels["alertname"] = model.LabelValue(name)
-		alertLabels["SrcK8S_Hostname"] = model.LabelValue(nodePair.src)
-		alertLabels["DstK8S_Hostname"] = model.LabelValue(nodePair.dst)
+		alertLabels["SrcK8S_HostName"] = model.LabelValue(nodePair.src)
+		alertLabels["DstK8S_HostName"] = model.LabelValue(nodePair.dst)
 		val := float64(threshold) + rand.Float64()*float64(upperBound-threshold)
 		return &Alert{
 			Annotations: annotations,
@@ -302,7 +304,7 @@ func createWorkloadAlerts(probability float64, name string, threshold, upperBoun
 	return alerts, ruleState
 }
 
-func createWorkloadRule(probability float64, name, severity, extraFilter string, threshold, upperBound int, bynetobs bool, workloadLabels []string) AlertingRule {
+func createWorkloadRule(probability float64, name, severity, extraFilter string, threshold, upperBound int, bynetobs bool) AlertingRule {
 	labels := model.LabelSet{
 		"severity": model.LabelValue(severity),
 	}
@@ -314,14 +316,8 @@ func createWorkloadRule(probability float64, name, severity, extraFilter string,
 		labels["app"] = "netobserv"
 	}
 	labels["netobserv"] = "true"
-	var jsonWorkloadLbl string
-	if len(workloadLabels) > 0 {
-		var quotedLbl []string
-		for _, lbl := range workloadLabels {
-			quotedLbl = append(quotedLbl, `"`+lbl+`"`)
-		}
-		jsonWorkloadLbl = fmt.Sprintf(`"workloadLabels":[%s],`, strings.Join(quotedLbl, ","))
-	}
+	// Workload alerts are labelled with the source owner, see createWorkloadAlert
+	jsonWorkloadLbl := `"namespaceLabels":["SrcK8S_Namespace"],"workloadLabels":["SrcK8S_OwnerName"],"kindLabels":["SrcK8S_Type"],`
 	searchURL := "https://duckduckgo.com/?q=" + url.PathEscape(name)
 	var extraFilterJSON string
 	if extraFilter != "" {
@@ -427,6 +423,114 @@ func createRule(probability float64, name, severity, extraFilter string, thresho
 	}
 }
 
+// Recording rules based on real operator templates
+func recordingRules() []RecordingRule {
+	return []RecordingRule{
+		{
+			Name:  "netobserv_health_packet_drops_kernel_total",
+			Query: "100 * (sum by (SrcK8S_Namespace) (rate(netobserv_workload_ingress_drop_packets_total{PktDropLatestDropCause=\"SKB_DROP_REASON_SOCKET_FILTER\"}[2m])) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "PacketDropsByKernel",
+			},
+		},
+		{
+			Name:  "netobserv_health_packet_drops_device_total",
+			Query: "100 * (sum by (SrcK8S_HostName) (rate(netobserv_workload_ingress_drop_packets_total{PktDropLatestDropCause!=\"SKB_DROP_REASON_SOCKET_FILTER\"}[2m])) / (sum by (SrcK8S_HostName) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "PacketDropsByDevice",
+			},
+		},
+		{
+			Name:  "netobserv:network:dns_latency:src:p99",
+			Query: "histogram_quantile(0.99, sum by (SrcK8S_Namespace, le) (rate(netobserv_workload_dns_latency_seconds_bucket[2m])))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "DNSErrors",
+			},
+		},
+		{
+			Name:  "netobserv_health_dns_errors_total",
+			Query: "100 * (sum(rate(netobserv_workload_dns_latency_seconds_count{DnsFlagsResponseCode!~\"NoError|NXDomain\"}[2m])) / (sum(rate(netobserv_workload_dns_latency_seconds_count[2m])) > 0))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "DNSErrors",
+			},
+		},
+		{
+			Name:  "netobserv_health_dns_nxdomain_total",
+			Query: "100 * (sum by (SrcK8S_Namespace) (rate(netobserv_workload_dns_latency_seconds_count{DnsFlagsResponseCode=\"NXDomain\"}[2m])) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_dns_latency_seconds_count[2m])) > 0))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "DNSNxDomain",
+			},
+		},
+		{
+			Name:  "netobserv:network:packet_drop_rate:dst:avg",
+			Query: "100 * (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_drop_packets_total[2m])) / (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "PacketDropsByKernel",
+			},
+		},
+		{
+			Name:  "netobserv_health_netpol_denied_total",
+			Query: "100 * (sum by (SrcK8S_Namespace) (rate(netobserv_workload_network_events_total{NetworkEventsAction=\"NetworkPolicyDrop\"}[2m])) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "NetpolDenied",
+			},
+		},
+		{
+			Name:  "netobserv_health_latency_high_trend",
+			Query: "100 * ((avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_sum[1h])) / avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_count[1h]))) - (avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_sum[1h] offset 1d)) / avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_count[1h] offset 1d)))) / (avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_sum[1h] offset 1d)) / avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_count[1h] offset 1d)))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "LatencyHighTrend",
+			},
+		},
+		{
+			Name:  "netobserv_health_external_egress_high_trend",
+			Query: "100 * ((sum by (SrcK8S_Namespace) (rate(netobserv_workload_egress_bytes_total{DstK8S_Type=\"\"}[1h])) - (sum by (SrcK8S_Namespace) (rate(netobserv_workload_egress_bytes_total{DstK8S_Type=\"\"} offset 1d [1h])))) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_egress_bytes_total{DstK8S_Type=\"\"} offset 1d [1h]))))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "ExternalEgressHighTrend",
+			},
+		},
+		{
+			Name:  "netobserv_health_external_ingress_high_trend",
+			Query: "100 * ((sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_bytes_total{SrcK8S_Type=\"\"}[1h])) - (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_bytes_total{SrcK8S_Type=\"\"} offset 1d [1h])))) / (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_bytes_total{SrcK8S_Type=\"\"} offset 1d [1h]))))",
+			Labels: model.LabelSet{
+				"netobserv": "true",
+				"template":  "ExternalIngressHighTrend",
+			},
+		},
+	}
+}
+
+func alertingRules() []AlertingRule {
+	return []AlertingRule{
+		createRule(0.4, "Packet delivery failed", "info", "", 5, 100, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
+		createRule(0.3, "You have reached your hourly rate limit", "info", "", 5, 100, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
+		createRule(0.1, "It's always DNS", "warning", `dns_flag_response_code!=\"\"`, 15, 100, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
+		createRule(0.1, "We're under attack", "warning", "", 20, 100, true, []string{}, []string{}),
+		createRule(0.1, "Sh*t - Famous last words", "critical", "", 5, 100, true, []string{}, []string{"SrcK8S_HostName", "DstK8S_HostName"}),
+		createRule(0.3, "FromIngress", "info", "", 10, 100, false, []string{"exported_namespace"}, []string{}),
+		createRule(0.3, "Degraded latency", "info", "", 100, 1000, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
+		// Additional global alerts
+		createRule(0.8, "High overall traffic volume", "warning", "", 1000, 5000, true, []string{}, []string{}),
+		createRule(0.6, "Cluster-wide packet loss detected", "critical", "", 10, 50, true, []string{}, []string{}),
+		createRule(0.5, "Global DNS resolution issues", "info", "", 100, 500, true, []string{}, []string{}),
+		// Workload-specific alerts
+		createWorkloadRule(0.2, "High workload packet drops", "warning", "", 10, 50, true),
+		createWorkloadRule(0.15, "Workload connection errors", "info", "", 5, 30, true),
+		createWorkloadRule(0.1, "Workload DNS issues", "warning", `dns_flag_response_code!=\"\"`, 15, 60, true),
+		createWorkloadRule(0.12, "Workload high latency", "info", "", 100, 500, true),
+		createWorkloadRule(0.08, "Workload network policy denied", "warning", "", 5, 25, true),
+	}
+}
+
 func GetRules() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ruleType := r.URL.Query().Get("type")
@@ -434,110 +538,10 @@ func GetRules() func(w http.ResponseWriter, r *http.Request) {
 
 		var rules any
 		if ruleType == "record" {
-			// Recording rules based on real operator templates
-			rules = []RecordingRule{
-				{
-					Name:  "netobserv_health_packet_drops_kernel_total",
-					Query: "100 * (sum by (SrcK8S_Namespace) (rate(netobserv_workload_ingress_drop_packets_total{PktDropLatestDropCause=\"SKB_DROP_REASON_SOCKET_FILTER\"}[2m])) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "PacketDropsByKernel",
-					},
-				},
-				{
-					Name:  "netobserv_health_packet_drops_device_total",
-					Query: "100 * (sum by (SrcK8S_HostName) (rate(netobserv_workload_ingress_drop_packets_total{PktDropLatestDropCause!=\"SKB_DROP_REASON_SOCKET_FILTER\"}[2m])) / (sum by (SrcK8S_HostName) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "PacketDropsByDevice",
-					},
-				},
-				{
-					Name:  "netobserv:network:dns_latency:src:p99",
-					Query: "histogram_quantile(0.99, sum by (SrcK8S_Namespace, le) (rate(netobserv_workload_dns_latency_seconds_bucket[2m])))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "DNSErrors",
-					},
-				},
-				{
-					Name:  "netobserv_health_dns_errors_total",
-					Query: "100 * (sum(rate(netobserv_workload_dns_latency_seconds_count{DnsFlagsResponseCode!~\"NoError|NXDomain\"}[2m])) / (sum(rate(netobserv_workload_dns_latency_seconds_count[2m])) > 0))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "DNSErrors",
-					},
-				},
-				{
-					Name:  "netobserv_health_dns_nxdomain_total",
-					Query: "100 * (sum by (SrcK8S_Namespace) (rate(netobserv_workload_dns_latency_seconds_count{DnsFlagsResponseCode=\"NXDomain\"}[2m])) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_dns_latency_seconds_count[2m])) > 0))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "DNSNxDomain",
-					},
-				},
-				{
-					Name:  "netobserv:network:packet_drop_rate:dst:avg",
-					Query: "100 * (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_drop_packets_total[2m])) / (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "PacketDropsByKernel",
-					},
-				},
-				{
-					Name:  "netobserv_health_netpol_denied_total",
-					Query: "100 * (sum by (SrcK8S_Namespace) (rate(netobserv_workload_network_events_total{NetworkEventsAction=\"NetworkPolicyDrop\"}[2m])) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_ingress_packets_total[2m])) > 0))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "NetpolDenied",
-					},
-				},
-				{
-					Name:  "netobserv_health_latency_high_trend",
-					Query: "100 * ((avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_sum[1h])) / avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_count[1h]))) - (avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_sum[1h] offset 1d)) / avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_count[1h] offset 1d)))) / (avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_sum[1h] offset 1d)) / avg by (SrcK8S_Namespace) (rate(netobserv_workload_flow_rtt_seconds_count[1h] offset 1d)))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "LatencyHighTrend",
-					},
-				},
-				{
-					Name:  "netobserv_health_external_egress_high_trend",
-					Query: "100 * ((sum by (SrcK8S_Namespace) (rate(netobserv_workload_egress_bytes_total{DstK8S_Type=\"\"}[1h])) - (sum by (SrcK8S_Namespace) (rate(netobserv_workload_egress_bytes_total{DstK8S_Type=\"\"} offset 1d [1h])))) / (sum by (SrcK8S_Namespace) (rate(netobserv_workload_egress_bytes_total{DstK8S_Type=\"\"} offset 1d [1h]))))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "ExternalEgressHighTrend",
-					},
-				},
-				{
-					Name:  "netobserv_health_external_ingress_high_trend",
-					Query: "100 * ((sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_bytes_total{SrcK8S_Type=\"\"}[1h])) - (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_bytes_total{SrcK8S_Type=\"\"} offset 1d [1h])))) / (sum by (DstK8S_Namespace) (rate(netobserv_workload_ingress_bytes_total{SrcK8S_Type=\"\"} offset 1d [1h]))))",
-					Labels: model.LabelSet{
-						"netobserv": "true",
-						"template":  "ExternalIngressHighTrend",
-					},
-				},
-			}
+			rules = recordingRules()
 		} else {
 			// Alerting rules (default or type=alert)
-			rules = []AlertingRule{
-				createRule(0.4, "Packet delivery failed", "info", "", 5, 100, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
-				createRule(0.3, "You have reached your hourly rate limit", "info", "", 5, 100, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
-				createRule(0.1, "It's always DNS", "warning", `dns_flag_response_code!=\"\"`, 15, 100, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
-				createRule(0.1, "We're under attack", "warning", "", 20, 100, true, []string{}, []string{}),
-				createRule(0.1, "Sh*t - Famous last words", "critical", "", 5, 100, true, []string{}, []string{"SrcK8S_Hostname", "DstK8S_Hostname"}),
-				createRule(0.3, "FromIngress", "info", "", 10, 100, false, []string{"exported_namespace"}, []string{}),
-				createRule(0.3, "Degraded latency", "info", "", 100, 1000, true, []string{"SrcK8S_Namespace", "DstK8S_Namespace"}, []string{}),
-				// Additional global alerts
-				createRule(0.8, "High overall traffic volume", "warning", "", 1000, 5000, true, []string{}, []string{}),
-				createRule(0.6, "Cluster-wide packet loss detected", "critical", "", 10, 50, true, []string{}, []string{}),
-				createRule(0.5, "Global DNS resolution issues", "info", "", 100, 500, true, []string{}, []string{}),
-				// Workload-specific alerts
-				createWorkloadRule(0.2, "High workload packet drops", "warning", "", 10, 50, true, []string{"SrcK8S_Namespace", "SrcK8S_OwnerName", "SrcK8S_Type"}),
-				createWorkloadRule(0.15, "Workload connection errors", "info", "", 5, 30, true, []string{"SrcK8S_Namespace", "SrcK8S_OwnerName", "SrcK8S_Type"}),
-				createWorkloadRule(0.1, "Workload DNS issues", "warning", `dns_flag_response_code!=\"\"`, 15, 60, true, []string{"SrcK8S_Namespace", "SrcK8S_OwnerName", "SrcK8S_Type"}),
-				createWorkloadRule(0.12, "Workload high latency", "info", "", 100, 500, true, []string{"SrcK8S_Namespace", "SrcK8S_OwnerName", "SrcK8S_Type"}),
-				createWorkloadRule(0.08, "Workload network policy denied", "warning", "", 5, 25, true, []string{"SrcK8S_Namespace", "SrcK8S_OwnerName", "SrcK8S_Type"}),
-			}
+			rules = alertingRules()
 		}
 
 		res := map[string]any{
@@ -574,9 +578,9 @@ func GetRules() func(w http.ResponseWriter, r *http.Request) {
 
 func GetSilences() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, _ *http.Request) {
-		// Return empty array for silences in mock mode
+		// Return silences created through the silences API, or an empty array
 		// This matches the error handling behavior in the frontend (fetcher.tsx)
-		response, err := json.Marshal([]any{})
+		response, err := json.Marshal(ListSilences())
 		if err != nil {
 			mlog.Errorf("Marshalling error while responding JSON: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -599,30 +603,7 @@ func GetQuery() func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query().Get("query")
 		mlog.Infof("GetQuery called with query=%s", query)
 
-		// Determine template from query (metric name)
-		var template string
-		switch query {
-		case "netobserv_health_packet_drops_kernel_total":
-			template = "PacketDropsByKernel"
-		case "netobserv_health_packet_drops_device_total":
-			template = "PacketDropsByDevice"
-		case "netobserv:network:dns_latency:src:p99":
-			template = "DNSErrors"
-		case "netobserv_health_dns_errors_total":
-			template = "DNSErrors"
-		case "netobserv_health_dns_nxdomain_total":
-			template = "DNSNxDomain"
-		case "netobserv:network:packet_drop_rate:dst:avg":
-			template = "PacketDropsByKernel"
-		case "netobserv_health_netpol_denied_total":
-			template = "NetpolDenied"
-		case "netobserv_health_latency_high_trend":
-			template = "LatencyHighTrend"
-		case "netobserv_health_external_egress_high_trend":
-			template = "ExternalEgressHighTrend"
-		case "netobserv_health_external_ingress_high_trend":
-			template = "ExternalIngressHighTrend"
-		}
+		template := queryTemplate(query)
 
 		// Get fixed mock data for this template
 		mockData := getMockData(template)
@@ -674,3 +655,111 @@ func GetQuery() func(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// Determine template from query (metric name)
+func queryTemplate(query string) string {
+	switch query {
+	case "netobserv_health_packet_drops_kernel_total":
+		return "PacketDropsByKernel"
+	case "netobserv_health_packet_drops_device_total":
+		return "PacketDropsByDevice"
+	case "netobserv:network:dns_latency:src:p99":
+		return "DNSErrors"
+	case "netobserv_health_dns_errors_total":
+		return "DNSErrors"
+	case "netobserv_health_dns_nxdomain_total":
+		return "DNSNxDomain"
+	case "netobserv:network:packet_drop_rate:dst:avg":
+		return "PacketDropsByKernel"
+	case "netobserv_health_netpol_denied_total":
+		return "NetpolDenied"
+	case "netobserv_health_latency_high_trend":
+		return "LatencyHighTrend"
+	case "netobserv_health_external_egress_high_trend":
+		return "ExternalEgressHighTrend"
+	case "netobserv_health_external_ingress_high_trend":
+		return "ExternalIngressHighTrend"
+	}
+	return ""
+}
+
+// Rules returns the mocked alerting and recording rules, as they would be returned by the Prometheus API
+func Rules() v1.RulesResult {
+	var rules v1.Rules
+	for _, r := range alertingRules() {
+		rule := v1.AlertingRule{
+			Name:        r.Name,
+			Labels:      r.Labels,
+			Annotations: r.Annotations,
+			State:       r.State,
+		}
+		for _, a := range r.Alerts {
+			rule.Alerts = append(rule.Alerts, &v1.Alert{
+				Annotations: a.Annotations,
+				Labels:      a.Labels,
+				State:       v1.AlertState(a.State),
+				Value:       a.Value,
+			})
+		}
+		rules = append(rules, rule)
+	}
+	for _, r := range recordingRules() {
+		rules = append(rules, v1.RecordingRule{
+			Name:   r.Name,
+			Query:  r.Query,
+			Labels: r.Labels,
+		})
+	}
+	return v1.RulesResult{Groups: []v1.RuleGroup{{Name: "netobserv-rules", Rules: rules}}}
+}
+
+// groupingLabels returns the labels that the query of a mocked recording rule aggregates by, except histogram buckets
+func groupingLabels(name string) []model.LabelName {
+	for _, r := range recordingRules() {
+		if r.Name != name {
+			continue
+		}
+		match := groupingRegexp.FindStringSubmatch(r.Query)
+		if match == nil {
+			return nil
+		}
+		var labels []model.LabelName
+		for _, lbl := range strings.Split(match[1], ",") {
+			if lbl = strings.TrimSpace(lbl); lbl != "le" {
+				labels = append(labels, model.LabelName(lbl))
+			}
+		}
+		return labels
+	}
+	return nil
+}
+
+var groupingRegexp = regexp.MustCompile(`by \(([^)]*)\)`)
+
+// Vector returns the fixed mock data for a recording rule query, labelled as its query would be. Data that the
+// query can't produce, such as nodes for a query by namespace, is left out.
+func Vector(query string) model.Vector {
+	labels := groupingLabels(query)
+	var vector model.Vector
+	for _, data := range getMockData(queryTemplate(query)) {
+		metric := model.Metric{}
+		for _, lbl := range labels {
+			switch {
+			case strings.HasSuffix(string(lbl), "_Namespace") && data.namespace != "":
+				metric[lbl] = model.LabelValue(data.namespace)
+			case strings.HasSuffix(string(lbl), "_HostName") && data.node != "":
+				metric[lbl] = model.LabelValue(data.node)
+			}
+		}
+		global := data.namespace == "" && data.node == ""
+		if len(metric) != len(labels) || (len(labels) == 0) != global {
+			continue
+		}
+		vector = append(vector, &model.Sample{
+			Metric:    metric,
+			Value:     model.SampleValue(data.value),
+			Timestamp: model.TimeFromUnix(1234567890),
+		})
+	}
+	return vector
+}
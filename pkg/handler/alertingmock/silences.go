@@ -0,0 +1,85 @@
+package alertingmock
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	netobsmodel "github.com/netobserv/network-observability-console-plugin/pkg/model"
+)
+
+// In-memory silences, for the standalone dev setup
+type silenceStore struct {
+	mu       sync.Mutex
+	lastID   int
+	silences map[string]netobsmodel.Silence
+}
+
+var silences = silenceStore{silences: map[string]netobsmodel.Silence{}}
+
+func silenceState(s *netobsmodel.Silence, now time.Time) string {
+	if !now.Before(s.EndsAt) {
+		return "expired"
+	}
+	if now.Before(s.StartsAt) {
+		return "pending"
+	}
+	return "active"
+}
+
+func withStatus(s netobsmodel.Silence) netobsmodel.Silence {
+	s.Status = &netobsmodel.SilenceStatus{State: silenceState(&s, time.Now())}
+	return s
+}
+
+// ListSilences returns all mocked silences, including expired ones, ordered by ID
+func ListSilences() []netobsmodel.Silence {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+	res := []netobsmodel.Silence{}
+	for _, s := range silences.silences {
+		res = append(res, withStatus(s))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// GetSilence returns a mocked silence by ID
+func GetSilence(id string) (netobsmodel.Silence, bool) {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+	s, ok := silences.silences[id]
+	if !ok {
+		return s, false
+	}
+	return withStatus(s), true
+}
+
+// CreateSilence stores a new mocked silence and returns its ID
+func CreateSilence(s netobsmodel.Silence) string {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+	silences.lastID++
+	s.ID = fmt.Sprintf("mock-silence-%04d", silences.lastID)
+	now := time.Now()
+	s.UpdatedAt = &now
+	s.Status = nil
+	silences.silences[s.ID] = s
+	return s.ID
+}
+
+// ExpireSilence ends a mocked silence now; it returns false if the silence doesn't exist
+func ExpireSilence(id string) bool {
+	silences.mu.Lock()
+	defer silences.mu.Unlock()
+	s, ok := silences.silences[id]
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	s.EndsAt = now
+	s.UpdatedAt = &now
+	silences.silences[id] = s
+	return true
+}
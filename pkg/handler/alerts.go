@@ -0,0 +1,472 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/alertingmock"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/fields"
+	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+const (
+	healthAnnotation = "netobserv_io_network_health"
+	netobsLabel      = "netobserv"
+)
+
+// healthMetadata is the JSON content of the netobserv_io_network_health annotation
+type healthMetadata struct {
+	AlertThreshold      string               `json:"alertThreshold,omitempty"`
+	RecordingThresholds *recordingThresholds `json:"recordingThresholds,omitempty"`
+	NamespaceLabels     []string             `json:"namespaceLabels,omitempty"`
+	NodeLabels          []string             `json:"nodeLabels,omitempty"`
+	WorkloadLabels      []string             `json:"workloadLabels,omitempty"`
+	KindLabels          []string             `json:"kindLabels,omitempty"`
+}
+
+type recordingThresholds struct {
+	Info     string `json:"info,omitempty"`
+	Warning  string `json:"warning,omitempty"`
+	Critical string `json:"critical,omitempty"`
+}
+
+// keyField binds a topology field (without Src/Dst prefix) to the alert labels that can provide its value
+type keyField struct {
+	field  string
+	labels []string
+}
+
+type healthItem struct {
+	alert model.Alert
+	md    healthMetadata
+}
+
+// alertsSource abstracts Prometheus, so that alertingmock can be used instead
+type alertsSource interface {
+	rules(ctx context.Context) (v1.RulesResult, int, error)
+	vector(ctx context.Context, query string) (model.Vector, int, error)
+}
+
+type promAlertsSource struct {
+	client api.Client
+}
+
+func (s *promAlertsSource) rules(ctx context.Context) (v1.RulesResult, int, error) {
+	return prometheus.GetRules(ctx, s.client)
+}
+
+func (s *promAlertsSource) vector(ctx context.Context, query string) (model.Vector, int, error) {
+	qr, code, err := prometheus.QueryVector(ctx, s.client, &prometheus.Query{
+		PromQL: query,
+		Range:  v1.Range{End: time.Now()},
+	})
+	if err != nil {
+		return nil, code, err
+	}
+	vector, ok := qr.Data.Result.(model.Vector)
+	if !ok {
+		return nil, http.StatusInternalServerError, fmt.Errorf("unexpected result type: %T", qr.Data.Result)
+	}
+	return vector, code, nil
+}
+
+type mockAlertsSource struct{}
+
+func (s *mockAlertsSource) rules(_ context.Context) (v1.RulesResult, int, error) {
+	return alertingmock.Rules(), http.StatusOK, nil
+}
+
+func (s *mockAlertsSource) vector(_ context.Context, query string) (model.Vector, int, error) {
+	vector := model.Vector{}
+	for _, sample := range alertingmock.Vector(query) {
+		vector = append(vector, *sample)
+	}
+	return vector, http.StatusOK, nil
+}
+
+func (h *Handlers) useAlertingMocks() bool {
+	return h.Cfg.Prometheus.AlertManager.URL == "" && h.Cfg.Loki.UseMocks
+}
+
+func (h *Handlers) GetTopologyAlerts(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("GetTopologyAlerts", code, startTime)
+		}()
+
+		namespace, err := getNamespace(params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+
+		scopes := h.Cfg.Frontend.Scopes
+		if aggregateBy := params.Get(aggregateByKey); aggregateBy != "" {
+			scopes = nil
+			for i := range h.Cfg.Frontend.Scopes {
+				if h.Cfg.Frontend.Scopes[i].ID == aggregateBy {
+					scopes = append(scopes, h.Cfg.Frontend.Scopes[i])
+				}
+			}
+			if len(scopes) == 0 {
+				code = http.StatusBadRequest
+				apierrors.Write(w, code, fmt.Errorf("unknown scope: %s", aggregateBy))
+				return
+			}
+		}
+
+		var src alertsSource
+		if h.useAlertingMocks() {
+			src = &mockAlertsSource{}
+		} else {
+			if !h.Cfg.IsPromEnabled() {
+				code = http.StatusBadRequest
+				apierrors.Write(w, code, errors.New("alerts require Prometheus to be configured"))
+				return
+			}
+			clients, sterr := newPromClients(h.Cfg, r.Header, namespace)
+			if sterr != nil {
+				code = http.StatusInternalServerError
+				sterr.Write(w, code)
+				return
+			}
+			src = &promAlertsSource{client: clients.getPromClient(namespace != "")}
+		}
+
+		items, code, err := collectHealthItems(ctx, src, h.Cfg.Frontend.RecordingAnnotations)
+		if err != nil {
+			apierrors.Write(w, code, apierrors.NewPromClientError(err))
+			return
+		}
+
+		res := correlateAlerts(items, scopes)
+		res.UnixTimestamp = time.Now().Unix()
+		code = http.StatusOK
+		writeJSON(w, code, res)
+	}
+}
+
+// collectHealthItems fetches netobserv firing alerts, and recording rules metrics that are above their thresholds
+func collectHealthItems(ctx context.Context, src alertsSource, recordingAnnotations map[string]map[string]string) ([]healthItem, int, error) {
+	rules, code, err := src.rules(ctx)
+	if err != nil {
+		return nil, code, err
+	}
+	var items []healthItem
+	seenRecording := map[string]bool{}
+	for _, group := range rules.Groups {
+		for _, rule := range group.Rules {
+			switch r := rule.(type) {
+			case v1.AlertingRule:
+				if r.Labels[netobsLabel] != "true" {
+					continue
+				}
+				md := parseHealthMetadata(string(r.Annotations[healthAnnotation]))
+				for _, a := range r.Alerts {
+					if a.State == v1.AlertStateFiring {
+						items = append(items, healthItem{alert: alertFromRule(r.Name, a), md: md})
+					}
+				}
+			case v1.RecordingRule:
+				annotations, ok := recordingAnnotations[r.Name]
+				if !ok || seenRecording[r.Name] {
+					continue
+				}
+				seenRecording[r.Name] = true
+				vector, code, err := src.vector(ctx, r.Name)
+				if err != nil {
+					return nil, code, err
+				}
+				items = append(items, recordingToHealthItems(r.Name, vector, annotations)...)
+			}
+		}
+	}
+	return items, http.StatusOK, nil
+}
+
+func parseHealthMetadata(annotation string) healthMetadata {
+	md := healthMetadata{}
+	if annotation == "" {
+		return md
+	}
+	if err := json.Unmarshal([]byte(annotation), &md); err != nil {
+		hlog.Debugf("Could not parse %s annotation: %v", healthAnnotation, err)
+	}
+	return md
+}
+
+func alertFromRule(ruleName string, a *v1.Alert) model.Alert {
+	labels := make(map[string]string, len(a.Labels))
+	for k, v := range a.Labels {
+		labels[string(k)] = string(v)
+	}
+	value, err := strconv.ParseFloat(a.Value, 64)
+	if err != nil {
+		value = 0
+	}
+	alert := model.Alert{
+		Name:     ruleName,
+		Severity: labels["severity"],
+		State:    model.AlertStateFiring,
+		Value:    value,
+		Summary:  string(a.Annotations["summary"]),
+		Labels:   labels,
+	}
+	if alert.Severity == "" {
+		alert.Severity = model.SeverityInfo
+	}
+	if alert.Summary == "" {
+		alert.Summary = labels["template"]
+	}
+	if !a.ActiveAt.IsZero() {
+		activeAt := a.ActiveAt
+		alert.ActiveAt = &activeAt
+	}
+	return alert
+}
+
+func recordingToHealthItems(ruleName string, vector model.Vector, annotations map[string]string) []healthItem {
+	md := parseHealthMetadata(annotations[healthAnnotation])
+	if md.RecordingThresholds == nil {
+		return nil
+	}
+	var items []healthItem
+	for i := range vector {
+		value := float64(vector[i].Value)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		severity := md.RecordingThresholds.severity(value)
+		if severity == "" {
+			continue
+		}
+		labels := make(map[string]string, len(vector[i].Metric))
+		for k, v := range vector[i].Metric {
+			labels[string(k)] = string(v)
+		}
+		summary := annotations["summary"]
+		if summary == "" {
+			summary = labels["template"]
+		}
+		items = append(items, healthItem{
+			alert: model.Alert{
+				Name:     ruleName,
+				Severity: severity,
+				State:    model.AlertStateRecording,
+				Value:    value,
+				Summary:  summary,
+				Labels:   labels,
+			},
+			md: md,
+		})
+	}
+	return items
+}
+
+// severity returns the highest severity reached by the value, or an empty string if no threshold is reached
+func (t *recordingThresholds) severity(value float64) string {
+	reached := func(threshold string) bool {
+		f, err := strconv.ParseFloat(threshold, 64)
+		return err == nil && value >= f
+	}
+	if reached(t.Critical) {
+		return model.SeverityCritical
+	}
+	if reached(t.Warning) {
+		return model.SeverityWarning
+	}
+	if reached(t.Info) {
+		return model.SeverityInfo
+	}
+	return ""
+}
+
+// keyFields returns the topology fields identifying the alert target, following the same precedence as the frontend:
+// owner, namespace, node, or none when the alert is global
+func (md *healthMetadata) keyFields() []keyField {
+	if len(md.WorkloadLabels) > 0 && len(md.NamespaceLabels) > 0 && len(md.KindLabels) > 0 {
+		return []keyField{
+			{field: fields.Namespace, labels: md.NamespaceLabels},
+			{field: fields.OwnerName, labels: md.WorkloadLabels},
+			{field: fields.OwnerType, labels: md.KindLabels},
+		}
+	}
+	if len(md.NamespaceLabels) > 0 {
+		return []keyField{{field: fields.Namespace, labels: md.NamespaceLabels}}
+	}
+	if len(md.NodeLabels) > 0 {
+		return []keyField{{field: fields.HostName, labels: md.NodeLabels}}
+	}
+	return nil
+}
+
+func labelSide(label string) string {
+	if strings.HasPrefix(label, fields.Src) {
+		return fields.Src
+	}
+	if strings.HasPrefix(label, fields.Dst) {
+		return fields.Dst
+	}
+	return ""
+}
+
+// resolvePeer reads the key fields values for one side (Src, Dst or unprefixed); it returns nil if any is missing
+func resolvePeer(labels map[string]string, keys []keyField, side string) map[string]string {
+	peer := map[string]string{}
+	for _, k := range keys {
+		for _, lbl := range k.labels {
+			if v := labels[lbl]; v != "" && labelSide(lbl) == side {
+				peer[k.field] = v
+				break
+			}
+		}
+		if _, ok := peer[k.field]; !ok {
+			return nil
+		}
+	}
+	return peer
+}
+
+// findScope returns the scope whose labels, without Src/Dst prefix, are exactly the key fields
+func findScope(scopes []config.Scope, keys []keyField) string {
+	for i := range scopes {
+		scopeFields := map[string]bool{}
+		for _, lbl := range scopes[i].Labels {
+			scopeFields[strings.TrimPrefix(strings.TrimPrefix(lbl, fields.Src), fields.Dst)] = true
+		}
+		if len(scopeFields) != len(keys) {
+			continue
+		}
+		match := true
+		for _, k := range keys {
+			if !scopeFields[k.field] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return scopes[i].ID
+		}
+	}
+	return ""
+}
+
+func peerKey(peer map[string]string) string {
+	keys := make([]string, 0, len(peer))
+	for k, v := range peer {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func highestSeverity(current, other string) string {
+	if model.SeverityRank(other) > model.SeverityRank(current) {
+		return other
+	}
+	return current
+}
+
+func sortAlerts(alerts []model.Alert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		ri, rj := model.SeverityRank(alerts[i].Severity), model.SeverityRank(alerts[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		return alerts[i].Name < alerts[j].Name
+	})
+}
+
+// correlateAlerts maps alerts onto topology nodes and edges of the given scopes
+// Alerts providing both a source and a destination peer go to edges, alerts with a single peer go to nodes.
+// Alerts whose key fields don't match any scope are ignored, and alerts without key fields are global.
+func correlateAlerts(items []healthItem, scopes []config.Scope) model.TopologyAlerts {
+	res := model.TopologyAlerts{Global: []model.Alert{}, Nodes: []model.NodeAlerts{}, Edges: []model.EdgeAlerts{}}
+	nodes := map[string]*model.NodeAlerts{}
+	edges := map[string]*model.EdgeAlerts{}
+	addNode := func(scope string, peer map[string]string, alert *model.Alert) {
+		key := scope + "/" + peerKey(peer)
+		n, ok := nodes[key]
+		if !ok {
+			n = &model.NodeAlerts{Scope: scope, Peer: peer}
+			nodes[key] = n
+		}
+		n.Severity = highestSeverity(n.Severity, alert.Severity)
+		n.Alerts = append(n.Alerts, *alert)
+	}
+
+	for i := range items {
+		alert := &items[i].alert
+		keys := items[i].md.keyFields()
+		if len(keys) == 0 {
+			res.Global = append(res.Global, *alert)
+			continue
+		}
+		scope := findScope(scopes, keys)
+		if scope == "" {
+			hlog.Debugf("No scope found for alert %s", alert.Name)
+			continue
+		}
+		src := resolvePeer(alert.Labels, keys, fields.Src)
+		dst := resolvePeer(alert.Labels, keys, fields.Dst)
+		switch {
+		case src != nil && dst != nil:
+			key := scope + "/" + peerKey(src) + "/" + peerKey(dst)
+			e, ok := edges[key]
+			if !ok {
+				e = &model.EdgeAlerts{Scope: scope, Source: src, Target: dst}
+				edges[key] = e
+			}
+			e.Severity = highestSeverity(e.Severity, alert.Severity)
+			e.Alerts = append(e.Alerts, *alert)
+		case src != nil:
+			addNode(scope, src, alert)
+		case dst != nil:
+			addNode(scope, dst, alert)
+		default:
+			if peer := resolvePeer(alert.Labels, keys, ""); peer != nil {
+				addNode(scope, peer, alert)
+			} else {
+				hlog.Debugf("Could not resolve topology peer for alert %s", alert.Name)
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		sortAlerts(n.Alerts)
+		res.Nodes = append(res.Nodes, *n)
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool {
+		return res.Nodes[i].Scope+"/"+peerKey(res.Nodes[i].Peer) < res.Nodes[j].Scope+"/"+peerKey(res.Nodes[j].Peer)
+	})
+	for _, e := range edges {
+		sortAlerts(e.Alerts)
+		res.Edges = append(res.Edges, *e)
+	}
+	sort.Slice(res.Edges, func(i, j int) bool {
+		ki := res.Edges[i].Scope + "/" + peerKey(res.Edges[i].Source) + "/" + peerKey(res.Edges[i].Target)
+		kj := res.Edges[j].Scope + "/" + peerKey(res.Edges[j].Source) + "/" + peerKey(res.Edges[j].Target)
+		return ki < kj
+	})
+	sortAlerts(res.Global)
+	return res
+}
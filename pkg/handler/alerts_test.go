@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	pmod "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var alertScopes = []config.Scope{
+	{ID: "host", Labels: []string{"SrcK8S_HostName", "DstK8S_HostName"}},
+	{ID: "namespace", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace"}},
+	{ID: "owner", Labels: []string{"SrcK8S_OwnerName", "SrcK8S_OwnerType", "DstK8S_OwnerName", "DstK8S_OwnerType", "SrcK8S_Namespace", "DstK8S_Namespace"}},
+}
+
+type fakeAlertsSource struct {
+	result  v1.RulesResult
+	vectors map[string]model.Vector
+}
+
+func (s *fakeAlertsSource) rules(_ context.Context) (v1.RulesResult, int, error) {
+	return s.result, http.StatusOK, nil
+}
+
+func (s *fakeAlertsSource) vector(_ context.Context, query string) (model.Vector, int, error) {
+	return s.vectors[query], http.StatusOK, nil
+}
+
+func alertingRule(name, severity, metadata string, alerts ...pmod.LabelSet) v1.AlertingRule {
+	rule := v1.AlertingRule{
+		Name:        name,
+		Labels:      pmod.LabelSet{"netobserv": "true", "severity": pmod.LabelValue(severity)},
+		Annotations: pmod.LabelSet{healthAnnotation: pmod.LabelValue(metadata)},
+	}
+	for _, lbls := range alerts {
+		lbls["severity"] = pmod.LabelValue(severity)
+		rule.Alerts = append(rule.Alerts, &v1.Alert{Labels: lbls, State: v1.AlertStateFiring, Value: "42"})
+	}
+	return rule
+}
+
+func TestCorrelateAlerts(t *testing.T) {
+	src := fakeAlertsSource{
+		result: v1.RulesResult{Groups: []v1.RuleGroup{{Rules: v1.Rules{
+			alertingRule("NsPair", "warning", `{"namespaceLabels":["SrcK8S_Namespace","DstK8S_Namespace"]}`,
+				pmod.LabelSet{"SrcK8S_Namespace": "a", "DstK8S_Namespace": "b"},
+				pmod.LabelSet{"SrcK8S_Namespace": "a"},
+			),
+			alertingRule("NsIngress", "critical", `{"namespaceLabels":["exported_namespace"]}`,
+				pmod.LabelSet{"exported_namespace": "a"},
+			),
+			alertingRule("Workload", "info", `{"namespaceLabels":["SrcK8S_Namespace"],"workloadLabels":["SrcK8S_OwnerName"],"kindLabels":["SrcK8S_Type"]}`,
+				pmod.LabelSet{"SrcK8S_Namespace": "a", "SrcK8S_OwnerName": "w", "SrcK8S_Type": "Deployment"},
+			),
+			alertingRule("Node", "info", `{"nodeLabels":["DstK8S_HostName"]}`,
+				pmod.LabelSet{"DstK8S_HostName": "n1"},
+				// missing label: dropped
+				pmod.LabelSet{"SrcK8S_Namespace": "a"},
+			),
+			alertingRule("Global", "info", `{}`, pmod.LabelSet{}),
+			v1.AlertingRule{Name: "NotNetobserv", Alerts: []*v1.Alert{{State: v1.AlertStateFiring}}},
+			v1.RecordingRule{Name: "recording"},
+		}}}},
+		vectors: map[string]model.Vector{
+			"recording": {
+				{Metric: pmod.Metric{"namespace": "b"}, Value: 80},
+				{Metric: pmod.Metric{"namespace": "c"}, Value: 5},
+			},
+		},
+	}
+	recordingAnnotations := map[string]map[string]string{
+		"recording": {healthAnnotation: `{"namespaceLabels":["namespace"],"recordingThresholds":{"info":"10","critical":"50"}}`},
+	}
+
+	items, code, err := collectHealthItems(context.Background(), &src, recordingAnnotations)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, items, 8)
+
+	res := correlateAlerts(items, alertScopes)
+
+	require.Len(t, res.Global, 1)
+	assert.Equal(t, "Global", res.Global[0].Name)
+
+	require.Len(t, res.Edges, 1)
+	assert.Equal(t, "namespace", res.Edges[0].Scope)
+	assert.Equal(t, map[string]string{"K8S_Namespace": "a"}, res.Edges[0].Source)
+	assert.Equal(t, map[string]string{"K8S_Namespace": "b"}, res.Edges[0].Target)
+	assert.Equal(t, "warning", res.Edges[0].Severity)
+
+	require.Len(t, res.Nodes, 4)
+	assert.Equal(t, "host", res.Nodes[0].Scope)
+	assert.Equal(t, map[string]string{"K8S_HostName": "n1"}, res.Nodes[0].Peer)
+
+	assert.Equal(t, "namespace", res.Nodes[1].Scope)
+	assert.Equal(t, map[string]string{"K8S_Namespace": "a"}, res.Nodes[1].Peer)
+	assert.Equal(t, "critical", res.Nodes[1].Severity)
+	require.Len(t, res.Nodes[1].Alerts, 2)
+	assert.Equal(t, "NsIngress", res.Nodes[1].Alerts[0].Name)
+	assert.Equal(t, "NsPair", res.Nodes[1].Alerts[1].Name)
+	assert.Equal(t, 42.0, res.Nodes[1].Alerts[1].Value)
+
+	assert.Equal(t, "namespace", res.Nodes[2].Scope)
+	assert.Equal(t, map[string]string{"K8S_Namespace": "b"}, res.Nodes[2].Peer)
+	assert.Equal(t, "critical", res.Nodes[2].Severity)
+	assert.Equal(t, "recording", res.Nodes[2].Alerts[0].State)
+
+	assert.Equal(t, "owner", res.Nodes[3].Scope)
+	assert.Equal(t, map[string]string{"K8S_Namespace": "a", "K8S_OwnerName": "w", "K8S_OwnerType": "Deployment"}, res.Nodes[3].Peer)
+}
+
+func TestCorrelateAlerts_NoMatchingScope(t *testing.T) {
+	items := []healthItem{{
+		alert: model.Alert{Name: "Node", Severity: "info", Labels: map[string]string{"SrcK8S_HostName": "n1"}},
+		md:    healthMetadata{NodeLabels: []string{"SrcK8S_HostName"}},
+	}}
+	res := correlateAlerts(items, alertScopes[1:])
+	assert.Empty(t, res.Nodes)
+	assert.Empty(t, res.Edges)
+	assert.Empty(t, res.Global)
+}
+
+func TestRecordingThresholds(t *testing.T) {
+	th := recordingThresholds{Info: "10", Warning: "20", Critical: "50"}
+	assert.Equal(t, "", th.severity(5))
+	assert.Equal(t, "info", th.severity(10))
+	assert.Equal(t, "warning", th.severity(30))
+	assert.Equal(t, "critical", th.severity(50))
+}
+
+func TestMockAlertsSource_RecordingLabels(t *testing.T) {
+	src := mockAlertsSource{}
+
+	// mocked vectors are labelled as the queries of their recording rules
+	vector, _, err := src.vector(context.Background(), "netobserv_health_packet_drops_kernel_total")
+	require.NoError(t, err)
+	require.Len(t, vector, 4)
+	for i := range vector {
+		assert.Contains(t, vector[i].Metric, pmod.LabelName("SrcK8S_Namespace"))
+		assert.Len(t, vector[i].Metric, 1)
+	}
+	vector, _, err = src.vector(context.Background(), "netobserv_health_packet_drops_device_total")
+	require.NoError(t, err)
+	require.Len(t, vector, 3)
+	assert.Equal(t, pmod.Metric{"SrcK8S_HostName": "vulcan"}, vector[0].Metric)
+	vector, _, err = src.vector(context.Background(), "netobserv_health_dns_errors_total")
+	require.NoError(t, err)
+	require.Len(t, vector, 1)
+	assert.Empty(t, vector[0].Metric)
+
+	// so that they are correlated with topology nodes, along with the randomly firing alerts
+	items, _, err := collectHealthItems(context.Background(), &src, map[string]map[string]string{
+		"netobserv_health_packet_drops_kernel_total": {healthAnnotation: `{"namespaceLabels":["SrcK8S_Namespace"],"recordingThresholds":{"info":"10"}}`},
+	})
+	require.NoError(t, err)
+	var peers []string
+	for _, node := range correlateAlerts(items, alertScopes).Nodes {
+		for _, a := range node.Alerts {
+			if a.State == model.AlertStateRecording {
+				peers = append(peers, node.Peer["K8S_Namespace"])
+			}
+		}
+	}
+	assert.Equal(t, []string{"sh-raan", "uss-enterprise"}, peers)
+}
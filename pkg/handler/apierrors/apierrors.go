@@ -38,6 +38,10 @@ func Write(w http.ResponseWriter, code int, err error) {
 }
 
 func WriteStructured(w http.ResponseWriter, code int, httpErr StructuredError) {
+	if pw, ok := w.(PayloadWriter); ok {
+		pw.WritePayload(code, httpErr)
+		return
+	}
 	r, err := json.Marshal(httpErr)
 	if err != nil {
 		hlog.Errorf("Marshalling error while responding an error: %v (message was: %s)", err, httpErr.Error())
@@ -0,0 +1,94 @@
+package apierrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code identifies the type of errors, in responses of the v2 API
+type Code string
+
+const (
+	CodeBadRequest          Code = "BadRequest"
+	CodeValidation          Code = "Validation"
+	CodeUnauthorized        Code = "Unauthorized"
+	CodeForbidden           Code = "Forbidden"
+	CodeNotFound            Code = "NotFound"
+	CodeNotAcceptable       Code = "NotAcceptable"
+	CodeConflict            Code = "Conflict"
+	CodeUnavailable         Code = "Unavailable"
+	CodeInternal            Code = "Internal"
+	CodeGuardrail           Code = "Guardrail"
+	CodeLokiDisabled        Code = "LokiDisabled"
+	CodeLokiResponse        Code = "LokiResponse"
+	CodeLokiClient          Code = "LokiClient"
+	CodeLokiLimits          Code = "LokiLimits"
+	CodePromDisabled        Code = "PromDisabled"
+	CodePromClient          Code = "PromClient"
+	CodePromUnsupported     Code = "PromUnsupported"
+	CodePromDisabledMetrics Code = "PromDisabledMetrics"
+	CodePromMissingLabels   Code = "PromMissingLabels"
+)
+
+// PayloadWriter is implemented by response writers that encode payloads themselves, such as the one of the v2 API
+type PayloadWriter interface {
+	WritePayload(code int, payload any)
+}
+
+// CodeOf returns the code of an error from its type or, for generic errors, from the HTTP status of the response
+func CodeOf(err error, status int) Code {
+	var (
+		validationErr      *ValidationError
+		guardrailErr       *GuardrailError
+		lokiDisabledErr    *LokiDisabledError
+		lokiResponseErr    *LokiResponseError
+		lokiClientErr      *LokiClientError
+		promDisabledErr    *PromDisabledError
+		promClientErr      *PromClientError
+		promUnsupportedErr *PromUnsupportedError
+		promMetricsErr     *PromDisabledMetricsError
+		promLabelsErr      *PromMissingLabelsError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		return CodeValidation
+	case errors.As(err, &guardrailErr):
+		return CodeGuardrail
+	case errors.As(err, &lokiDisabledErr):
+		return CodeLokiDisabled
+	case errors.As(err, &lokiResponseErr):
+		return CodeLokiResponse
+	case errors.As(err, &lokiClientErr):
+		return CodeLokiClient
+	case errors.As(err, &promDisabledErr):
+		return CodePromDisabled
+	case errors.As(err, &promClientErr):
+		return CodePromClient
+	case errors.As(err, &promUnsupportedErr):
+		return CodePromUnsupported
+	case errors.As(err, &promMetricsErr):
+		return CodePromDisabledMetrics
+	case errors.As(err, &promLabelsErr):
+		return CodePromMissingLabels
+	}
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusNotAcceptable:
+		return CodeNotAcceptable
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status < http.StatusInternalServerError {
+		return CodeBadRequest
+	}
+	return CodeInternal
+}
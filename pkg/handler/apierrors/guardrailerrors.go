@@ -0,0 +1,24 @@
+package apierrors
+
+import "net/http"
+
+// GuardrailError is used when a query is rejected because it exceeds a configured guardrail
+type GuardrailError struct {
+	StructuredError `json:"-"`
+	Guardrail       string `json:"guardrail,omitempty"`
+	Message         string `json:"message,omitempty"`
+	Hint            string `json:"hint,omitempty"`
+}
+
+// NewGuardrailError creates an error for the given guardrail, with a hint explaining how to narrow the query
+func NewGuardrailError(guardrail, message, hint string) *GuardrailError {
+	return &GuardrailError{Guardrail: guardrail, Message: message, Hint: hint}
+}
+
+func (e *GuardrailError) Error() string {
+	return e.Message
+}
+
+func (e *GuardrailError) Write(w http.ResponseWriter, code int) {
+	WriteStructured(w, code, e)
+}
@@ -0,0 +1,24 @@
+package apierrors
+
+import "net/http"
+
+// ValidationError is used when a request doesn't match the OpenAPI document of the API
+type ValidationError struct {
+	StructuredError `json:"-"`
+	Validation      bool   `json:"validation,omitempty"`
+	Parameter       string `json:"parameter,omitempty"`
+	Message         string `json:"message,omitempty"`
+}
+
+// NewValidationError creates an error for the given invalid parameter
+func NewValidationError(parameter, message string) *ValidationError {
+	return &ValidationError{Validation: true, Parameter: parameter, Message: message}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Write(w http.ResponseWriter, code int) {
+	WriteStructured(w, code, e)
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/httpclient"
+	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	pmodel "github.com/prometheus/common/model"
+)
+
+const (
+	thresholdKey = "threshold"
+
+	defaultCardinalityThreshold = 1000
+	defaultCardinalityRange     = time.Hour
+	tsdbStatusLimit             = 20
+)
+
+func (h *Handlers) GetCardinality(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("GetCardinality", code, startTime)
+		}()
+
+		params := r.URL.Query()
+		_, sTime, err := getStartTime(params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+		_, eTime, err := getEndTime(params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+		if sTime.IsZero() {
+			sTime = eTime.Add(-defaultCardinalityRange)
+		}
+		threshold := defaultCardinalityThreshold
+		if t := params.Get(thresholdKey); t != "" {
+			if threshold, err = strconv.Atoi(t); err != nil || threshold <= 0 {
+				code = http.StatusBadRequest
+				apierrors.Write(w, code, fmt.Errorf("invalid threshold: '%s'", t))
+				return
+			}
+		}
+
+		cl, sterr := newClients(h.Cfg, r.Header, false, "", nil)
+		if sterr != nil {
+			code = http.StatusInternalServerError
+			sterr.Write(w, code)
+			return
+		}
+
+		report := model.CardinalityReport{
+			Start:     sTime.Unix(),
+			End:       eTime.Unix(),
+			Threshold: threshold,
+		}
+		if cl.loki != nil {
+			lc, err := h.lokiCardinality(cl.loki, sTime, eTime)
+			if err != nil {
+				report.Errors = append(report.Errors, "Loki: "+err.Error())
+			} else {
+				report.Loki = lc
+			}
+		}
+		if cl.promAdmin != nil {
+			pc, errs := h.promCardinality(ctx, cl.promAdmin, sTime, eTime)
+			report.Prometheus = pc
+			for _, err := range errs {
+				report.Errors = append(report.Errors, "Prometheus: "+err.Error())
+			}
+		}
+		report.Scopes = h.scopeCardinalities(&report)
+
+		code = http.StatusOK
+		writeJSON(w, code, report)
+	}
+}
+
+func (h *Handlers) lokiCardinality(cl httpclient.Caller, start, end time.Time) (*model.LokiCardinality, error) {
+	s, e := strconv.FormatInt(start.Unix(), 10), strconv.FormatInt(end.Unix(), 10)
+	selector := fmt.Sprintf(`{%s="%s"}`, constants.AppLabel, constants.AppLabelValue)
+	series, _, err := getLokiSeries(h.Cfg.Loki.URL, cl, selector, s, e)
+	if err != nil {
+		return nil, err
+	}
+	names, _, err := getLokiLabelNames(h.Cfg.Loki.URL, cl, s, e)
+	if err != nil {
+		return nil, err
+	}
+
+	lc := model.LokiCardinality{
+		Streams: len(series),
+		Labels:  labelCardinalities(series),
+	}
+	for _, label := range h.Cfg.Loki.Labels {
+		if !slices.Contains(names, label) || cardinalityOf(lc.Labels, label) == 0 {
+			lc.MissingLabels = append(lc.MissingLabels, label)
+		}
+	}
+	return &lc, nil
+}
+
+// promCardinality counts series and label values of enabled netobserv metrics; errors are collected so that a partial report can still be returned
+func (h *Handlers) promCardinality(ctx context.Context, cl api.Client, start, end time.Time) (*model.PromCardinality, []error) {
+	var errs []error
+	pc := model.PromCardinality{
+		Metrics:   []model.MetricCardinality{},
+		TopLabels: []model.LabelCardinality{},
+	}
+
+	// TSDB status is not available through Thanos querier: don't fail on this one
+	if tsdb, _, err := prometheus.GetTSDBStatus(ctx, cl, tsdbStatusLimit); err != nil {
+		errs = append(errs, err)
+	} else {
+		pc.HeadSeries = tsdb.HeadStats.NumSeries
+		for _, stat := range tsdb.LabelValueCountByLabelName {
+			pc.TopLabels = append(pc.TopLabels, model.LabelCardinality{Name: stat.Name, Values: int(stat.Value)})
+		}
+	}
+
+	// Series and label values are counted by Prometheus, rather than fetching every series of high cardinality metrics
+	window := pmodel.Duration(end.Sub(start)).String()
+	for i := range h.Cfg.Prometheus.Metrics {
+		m := &h.Cfg.Prometheus.Metrics[i]
+		if !m.Enabled {
+			continue
+		}
+		// Include histogram series
+		name := fmt.Sprintf(`__name__=~"%s(_bucket|_sum|_count)?"`, regexp.QuoteMeta(m.Name))
+		series, err := promCount(ctx, cl, fmt.Sprintf(`count(last_over_time({%s}[%s]))`, name, window), end)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		mc := model.MetricCardinality{Name: m.Name, Series: series, Labels: []model.LabelCardinality{}}
+		for _, label := range m.Labels {
+			values, err := promCount(ctx, cl, fmt.Sprintf(`count(count by (%s) (last_over_time({%s,%s!=""}[%s])))`, label, name, label, window), end)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if values > 0 {
+				mc.Labels = append(mc.Labels, model.LabelCardinality{Name: label, Values: values})
+			}
+		}
+		sortLabelCardinalities(mc.Labels)
+		pc.Metrics = append(pc.Metrics, mc)
+	}
+	if len(pc.Metrics) == 0 && len(errs) > 0 && pc.HeadSeries == 0 {
+		return nil, append(errs, errors.New("no cardinality data could be fetched"))
+	}
+	return &pc, errs
+}
+
+// scopeCardinalities checks every label used in scopes: it is unavailable when no datasource can provide it,
+// and has a high cardinality when its number of values is above the threshold in any datasource
+func (h *Handlers) scopeCardinalities(report *model.CardinalityReport) []model.ScopeCardinality {
+	res := []model.ScopeCardinality{}
+	for i := range h.Cfg.Frontend.Scopes {
+		scope := &h.Cfg.Frontend.Scopes[i]
+		for _, label := range scope.Labels {
+			sc := model.ScopeCardinality{
+				Scope:     scope.ID,
+				Label:     label,
+				LokiLabel: h.Cfg.Loki.IsLabel(label),
+			}
+			if report.Loki != nil && sc.LokiLabel {
+				sc.LokiValues = cardinalityOf(report.Loki.Labels, label)
+			}
+			for j := range h.Cfg.Prometheus.Metrics {
+				m := &h.Cfg.Prometheus.Metrics[j]
+				if m.Enabled && slices.Contains(m.Labels, label) {
+					sc.PromMetrics = append(sc.PromMetrics, m.Name)
+				}
+			}
+			if report.Prometheus != nil {
+				for j := range report.Prometheus.Metrics {
+					sc.PromValues = max(sc.PromValues, cardinalityOf(report.Prometheus.Metrics[j].Labels, label))
+				}
+			}
+
+			// Labels that are not indexed in Loki are read from the JSON payload, so they can't be checked here
+			lokiAvailable := h.Cfg.IsLokiEnabled() && (!sc.LokiLabel || report.Loki == nil || sc.LokiValues > 0)
+			promAvailable := len(sc.PromMetrics) > 0 && (report.Prometheus == nil || len(report.Prometheus.Metrics) == 0 || sc.PromValues > 0)
+			sc.Unavailable = !lokiAvailable && !promAvailable
+			sc.HighCardinality = sc.LokiValues > report.Threshold || sc.PromValues > report.Threshold
+			res = append(res, sc)
+		}
+	}
+	return res
+}
+
+// labelCardinalities counts distinct values per label, sorted by decreasing cardinality
+func labelCardinalities(series []map[string]string) []model.LabelCardinality {
+	values := map[string]map[string]struct{}{}
+	for _, s := range series {
+		for k, v := range s {
+			if values[k] == nil {
+				values[k] = map[string]struct{}{}
+			}
+			values[k][v] = struct{}{}
+		}
+	}
+	res := make([]model.LabelCardinality, 0, len(values))
+	for k, v := range values {
+		res = append(res, model.LabelCardinality{Name: k, Values: len(v)})
+	}
+	sortLabelCardinalities(res)
+	return res
+}
+
+// sortLabelCardinalities sorts labels by decreasing cardinality, then by name
+func sortLabelCardinalities(labels []model.LabelCardinality) {
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Values == labels[j].Values {
+			return labels[i].Name < labels[j].Name
+		}
+		return labels[i].Values > labels[j].Values
+	})
+}
+
+// promCount runs an instant query counting series, returning 0 when none is found
+func promCount(ctx context.Context, cl api.Client, promQL string, at time.Time) (int, error) {
+	qr, _, err := prometheus.QueryVector(ctx, cl, &prometheus.Query{PromQL: promQL, Range: v1.Range{End: at}})
+	if err != nil {
+		return 0, err
+	}
+	vector, ok := qr.Data.Result.(model.Vector)
+	if !ok || len(vector) == 0 {
+		return 0, nil
+	}
+	return int(vector[0].Value), nil
+}
+
+func cardinalityOf(labels []model.LabelCardinality, name string) int {
+	for _, l := range labels {
+		if l.Name == name {
+			return l.Values
+		}
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/prometheus/client_golang/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelCardinalities(t *testing.T) {
+	res := labelCardinalities([]map[string]string{
+		{"SrcK8S_Namespace": "a", "DstK8S_Namespace": "a"},
+		{"SrcK8S_Namespace": "b", "DstK8S_Namespace": "a"},
+		{"SrcK8S_Namespace": "c", "DstK8S_Namespace": "b"},
+	})
+	assert.Equal(t, []model.LabelCardinality{
+		{Name: "SrcK8S_Namespace", Values: 3},
+		{Name: "DstK8S_Namespace", Values: 2},
+	}, res)
+}
+
+func TestScopeCardinalities(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{
+		Loki: config.Loki{URL: "http://loki", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace", "K8S_ClusterName"}},
+		Prometheus: config.Prometheus{Metrics: []config.MetricInfo{
+			{Enabled: true, Name: "netobserv_workload_ingress_bytes_total", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace", "SrcK8S_OwnerName"}},
+			{Enabled: false, Name: "netobserv_node_ingress_bytes_total", Labels: []string{"SrcK8S_HostName"}},
+		}},
+		Frontend: config.Frontend{Scopes: []config.Scope{
+			{ID: "cluster", Labels: []string{"K8S_ClusterName"}},
+			{ID: "namespace", Labels: []string{"SrcK8S_Namespace"}},
+			{ID: "host", Labels: []string{"SrcK8S_HostName"}},
+		}},
+	}}
+	report := model.CardinalityReport{
+		Threshold: 2,
+		Loki: &model.LokiCardinality{
+			Streams: 10,
+			Labels:  []model.LabelCardinality{{Name: "SrcK8S_Namespace", Values: 5}, {Name: "DstK8S_Namespace", Values: 2}},
+		},
+		Prometheus: &model.PromCardinality{Metrics: []model.MetricCardinality{
+			{Name: "netobserv_workload_ingress_bytes_total", Series: 4, Labels: []model.LabelCardinality{{Name: "SrcK8S_Namespace", Values: 1}}},
+		}},
+	}
+
+	res := h.scopeCardinalities(&report)
+	assert.Equal(t, []model.ScopeCardinality{
+		// Indexed in Loki, but no value found
+		{Scope: "cluster", Label: "K8S_ClusterName", LokiLabel: true, Unavailable: true},
+		{Scope: "namespace", Label: "SrcK8S_Namespace", LokiLabel: true, LokiValues: 5, PromMetrics: []string{"netobserv_workload_ingress_bytes_total"}, PromValues: 1, HighCardinality: true},
+		// Read from Loki JSON payload
+		{Scope: "host", Label: "SrcK8S_HostName"},
+	}, res)
+
+	// Without Loki, host is unavailable as its metric is disabled
+	h.Cfg.Loki.URL = ""
+	report.Loki = nil
+	res = h.scopeCardinalities(&report)
+	assert.True(t, res[0].Unavailable)
+	assert.False(t, res[1].Unavailable)
+	assert.False(t, res[1].HighCardinality)
+	assert.True(t, res[2].Unavailable)
+}
+
+func TestPromCardinality(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{Prometheus: config.Prometheus{Metrics: []config.MetricInfo{
+		{Enabled: true, Name: "netobserv_workload_ingress_bytes_total", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace", "SrcK8S_Zone"}},
+		{Enabled: false, Name: "netobserv_node_ingress_bytes_total", Labels: []string{"SrcK8S_HostName"}},
+	}}}}
+	counts := map[string]string{
+		`count(last_over_time({__name__=~"netobserv_workload_ingress_bytes_total(_bucket|_sum|_count)?"}[1h]))`:                                                    "40",
+		`count(count by (SrcK8S_Namespace) (last_over_time({__name__=~"netobserv_workload_ingress_bytes_total(_bucket|_sum|_count)?",SrcK8S_Namespace!=""}[1h])))`: "5",
+		`count(count by (DstK8S_Namespace) (last_over_time({__name__=~"netobserv_workload_ingress_bytes_total(_bucket|_sum|_count)?",DstK8S_Namespace!=""}[1h])))`: "8",
+	}
+	var queries []string
+	promSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.FormValue("query")
+		queries = append(queries, q)
+		result := "[]"
+		if count, ok := counts[q]; ok {
+			result = `[{"metric":{},"value":[3600,"` + count + `"]}]`
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":` + result + `}}`))
+	}))
+	defer promSrv.Close()
+	cl, err := api.NewClient(api.Config{Address: promSrv.URL})
+	require.NoError(t, err)
+
+	// series are counted by Prometheus, not fetched
+	pc, errs := h.promCardinality(context.Background(), cl, time.Unix(0, 0), time.Unix(3600, 0))
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "TSDB status")
+	assert.Len(t, queries, 4)
+	assert.Equal(t, []model.MetricCardinality{{
+		Name:   "netobserv_workload_ingress_bytes_total",
+		Series: 40,
+		// labels without values are left out
+		Labels: []model.LabelCardinality{{Name: "DstK8S_Namespace", Values: 8}, {Name: "SrcK8S_Namespace", Values: 5}},
+	}}, pc.Metrics)
+}
@@ -12,19 +12,66 @@ import (
 	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
 	"github.com/netobserv/network-observability-console-plugin/pkg/model"
 	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 	"github.com/prometheus/client_golang/api"
 )
 
 type clients struct {
-	loki      httpclient.Caller
-	promAdmin api.Client
-	promDev   api.Client
+	loki httpclient.Caller
+	// lokiTenants are set when tenants are queried separately
+	lokiTenants []tenantClient
+	promAdmin   api.Client
+	promDev     api.Client
+	// lokiPool and promPool bound concurrent queries to each datasource; user identifies the request for fair scheduling
+	lokiPool *workerPool
+	promPool *workerPool
+	user     string
 }
 
-func newClients(cfg *config.Config, requestHeader http.Header, useLokiStatus bool, namespace string) (clients, apierrors.StructuredError) {
-	lokiClients := newLokiClients(cfg, requestHeader, useLokiStatus)
+// tenantClient is a Loki client querying a single tenant
+type tenantClient struct {
+	tenant string
+	client httpclient.Caller
+}
+
+// newClients creates clients for all datasources; Loki clients query the given tenants, or all the configured ones when nil
+func newClients(cfg *config.Config, requestHeader http.Header, useLokiStatus bool, namespace string, tenants []string) (clients, apierrors.StructuredError) {
+	var cl clients
+	if cfg.IsLokiEnabled() {
+		if tenants == nil {
+			tenants = cfg.Loki.GetTenantIDs()
+		}
+		cl = newLokiQueryClients(&cfg.Loki, requestHeader, useLokiStatus, tenants)
+		cl.withLokiNamespace(&cfg.Loki, namespace)
+	}
 	promClients, err := newPromClients(cfg, requestHeader, namespace)
-	return clients{loki: lokiClients.loki, promAdmin: promClients.promAdmin, promDev: promClients.promDev}, err
+	cl.promAdmin, cl.promDev = promClients.promAdmin, promClients.promDev
+	cl.withPools(&cfg.Concurrency, requestHeader)
+	return cl, err
+}
+
+// withPools bounds concurrent queries with the pools shared by all requests
+func (c *clients) withPools(cfg *config.Concurrency, requestHeader http.Header) {
+	c.lokiPool, c.promPool = datasourcePools(cfg)
+	c.user = userKey(requestHeader)
+}
+
+// newLokiQueryClients creates a Loki client querying all tenants at once and, when configured so, one client per tenant to query them separately
+func newLokiQueryClients(cfg *config.Loki, requestHeader http.Header, useLokiStatus bool, tenants []string) clients {
+	cl := clients{loki: newLokiTenantClient(cfg, requestHeader, useLokiStatus, tenants)}
+	if cfg.SplitTenants(tenants) {
+		for _, tenant := range tenants {
+			cl.lokiTenants = append(cl.lokiTenants, tenantClient{tenant: tenant, client: newLokiTenantClient(cfg, requestHeader, useLokiStatus, []string{tenant})})
+		}
+	}
+	return cl
+}
+
+func (c *clients) withLokiNamespace(cfg *config.Loki, namespace string) {
+	c.loki = withLokiNamespace(cfg, c.loki, namespace)
+	for i := range c.lokiTenants {
+		c.lokiTenants[i].client = withLokiNamespace(cfg, c.lokiTenants[i].client, namespace)
+	}
 }
 
 func newPromClients(cfg *config.Config, requestHeader http.Header, namespace string) (clients, apierrors.StructuredError) {
@@ -53,8 +100,24 @@ func newLokiClients(cfg *config.Config, requestHeader http.Header, useLokiStatus
 	return clients{loki: lokiClient}
 }
 
-func (c *clients) fetchLokiSingle(logQL string, merger loki.Merger) (int, apierrors.StructuredError) {
-	qr, code, err := fetchLogQL(logQL, c.loki)
+// fetchLokiSingle runs a query once a slot of the Loki pool is available. Tenants queried separately are queried in
+// parallel, each taking a slot.
+func (c *clients) fetchLokiSingle(ctx context.Context, logQL string, merger loki.Merger) (int, apierrors.StructuredError) {
+	if len(c.lokiTenants) > 0 {
+		return c.fetchParallel(ctx, []string{logQL}, nil, merger, false)
+	}
+	if sm, ok := merger.(loki.StreamingMerger); ok {
+		return c.runPooled(ctx, c.lokiPool, func() (int, apierrors.StructuredError) {
+			return mergeLokiStreams(logQL, c.loki, "", sm, &sync.Mutex{})
+		})
+	}
+	var qr model.QueryResponse
+	code, err := c.runPooled(ctx, c.lokiPool, func() (int, apierrors.StructuredError) {
+		var code int
+		var err apierrors.StructuredError
+		qr, code, err = fetchLogQL(logQL, c.loki)
+		return code, err
+	})
 	if err != nil {
 		return code, err
 	}
@@ -64,6 +127,80 @@ func (c *clients) fetchLokiSingle(logQL string, merger loki.Merger) (int, apierr
 	return code, nil
 }
 
+// lokiTenantClients returns a client querying all tenants at once, or one client per tenant when queried separately
+func (c *clients) lokiTenantClients() []tenantClient {
+	if len(c.lokiTenants) == 0 {
+		return []tenantClient{{client: c.loki}}
+	}
+	return c.lokiTenants
+}
+
+// mergeLokiStreams runs a query and merges its streams as they are decoded, annotated with their tenant if any.
+// Clients that can't stream have their response merged at once.
+func mergeLokiStreams(logQL string, client httpclient.Caller, tenant string, merger loki.StreamingMerger, mu *sync.Mutex) (int, apierrors.StructuredError) {
+	sc, ok := client.(httpclient.StreamCaller)
+	if !ok {
+		qr, code, err := fetchLogQL(logQL, client)
+		if err != nil {
+			return code, err
+		}
+		if tenant != "" {
+			qr.Data.AddLabel(constants.TenantLabel, tenant)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := merger.Add(qr.Data); err != nil {
+			return http.StatusInternalServerError, apierrors.NewLokiClientError(err)
+		}
+		return code, nil
+	}
+	entries := 0
+	qr, code, err := streamLogQL(logQL, sc, func(s model.Stream) error {
+		if tenant != "" {
+			if s.Labels == nil {
+				s.Labels = map[string]string{}
+			}
+			s.Labels[constants.TenantLabel] = tenant
+		}
+		entries += len(s.Entries)
+		mu.Lock()
+		defer mu.Unlock()
+		merger.AddStream(s)
+		return nil
+	})
+	if err != nil {
+		return code, err
+	}
+	if qr.Data.ResultType != model.ResultTypeStream {
+		return http.StatusInternalServerError, apierrors.NewLokiClientError(fmt.Errorf("loki returned an unexpected type for streams: %s", qr.Data.ResultType))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	merger.EndQuery(qr.Data.Stats, entries)
+	return code, nil
+}
+
+// runPooled runs a query once a slot of the pool is available, unless the context is done first
+func (c *clients) runPooled(ctx context.Context, pool *workerPool, f func() (int, apierrors.StructuredError)) (int, apierrors.StructuredError) {
+	var code int
+	var err apierrors.StructuredError
+	if perr := pool.run(ctx, c.user, func() { code, err = f() }); perr != nil {
+		return http.StatusServiceUnavailable, &apierrors.GenericError{Message: perr.Error()}
+	}
+	return code, err
+}
+
+// runLoki runs a Loki lookup once a slot of the Loki pool is available
+func (c *clients) runLoki(ctx context.Context, f func() ([]string, int, apierrors.StructuredError)) ([]string, int, apierrors.StructuredError) {
+	var values []string
+	var code int
+	var err apierrors.StructuredError
+	if perr := c.lokiPool.run(ctx, c.user, func() { values, code, err = f() }); perr != nil {
+		return nil, http.StatusServiceUnavailable, apierrors.NewLokiClientError(perr)
+	}
+	return values, code, err
+}
+
 func (c *clients) getPromClient(isDev bool) api.Client {
 	if isDev {
 		return c.promDev
@@ -72,7 +209,7 @@ func (c *clients) getPromClient(isDev bool) api.Client {
 }
 
 func (c *clients) fetchPrometheusSingle(ctx context.Context, promQL *prometheus.Query, merger loki.Merger, client api.Client) (int, apierrors.StructuredError) {
-	qr, code, err := prometheus.QueryMatrix(ctx, client, promQL)
+	qr, code, err := prometheus.RunQuery(ctx, client, promQL)
 	if err != nil {
 		return code, apierrors.NewPromClientError(err)
 	}
@@ -88,12 +225,14 @@ func (c *clients) fetchSingle(ctx context.Context, logQL string, promQL *prometh
 		if client == nil {
 			return http.StatusBadRequest, apierrors.NewPromDisabledError(fmt.Sprintf("cannot execute the following Prometheus query: Prometheus is disabled: %v", promQL.PromQL))
 		}
-		return c.fetchPrometheusSingle(ctx, promQL, merger, client)
+		return c.runPooled(ctx, c.promPool, func() (int, apierrors.StructuredError) {
+			return c.fetchPrometheusSingle(ctx, promQL, merger, client)
+		})
 	}
 	if c.loki == nil {
 		return http.StatusBadRequest, apierrors.NewLokiDisabledError(fmt.Sprintf("cannot execute the following Loki query: Loki is disabled: %v", logQL))
 	}
-	return c.fetchLokiSingle(logQL, merger)
+	return c.fetchLokiSingle(ctx, logQL, merger)
 }
 
 func (c *clients) fetchParallel(ctx context.Context, logQL []string, promQL []*prometheus.Query, merger loki.Merger, isDev bool) (int, apierrors.StructuredError) {
@@ -114,7 +253,8 @@ func (c *clients) fetchParallel(ctx context.Context, logQL []string, promQL []*p
 	}
 
 	// Run queries in parallel, then aggregate them
-	size := len(logQL) + len(promQL)
+	tenantClients := c.lokiTenantClients()
+	size := len(logQL)*len(tenantClients) + len(promQL)
 	if size == 0 {
 		return http.StatusBadRequest, &apierrors.GenericError{Message: "no queries could be executed"}
 	}
@@ -124,28 +264,53 @@ func (c *clients) fetchParallel(ctx context.Context, logQL []string, promQL []*p
 	var wg sync.WaitGroup
 	wg.Add(size)
 
-	for _, q := range logQL {
-		go func(query string) {
+	// Streams are merged as they are decoded, when supported by the merger
+	streamingMerger, streaming := merger.(loki.StreamingMerger)
+	var mergerMu sync.Mutex
+
+	// Goroutines wait for a slot of their datasource pool before querying
+	schedule := func(pool *workerPool, f func()) {
+		go func() {
 			defer wg.Done()
-			qr, code, err := fetchLogQL(query, c.loki)
-			if err != nil {
-				errChan <- errorWithCode{err: apierrors.NewLokiClientError(err), code: code}
-			} else {
-				resChan <- qr
+			if err := pool.run(ctx, c.user, f); err != nil {
+				errChan <- errorWithCode{err: &apierrors.GenericError{Message: err.Error()}, code: http.StatusServiceUnavailable}
 			}
-		}(q)
+		}()
+	}
+
+	// Tenants queried separately have their results annotated with their tenant, the same way Loki does for
+	// multi-tenant queries
+	for _, q := range logQL {
+		for _, tc := range tenantClients {
+			schedule(c.lokiPool, func() {
+				if streaming {
+					if code, err := mergeLokiStreams(q, tc.client, tc.tenant, streamingMerger, &mergerMu); err != nil {
+						errChan <- errorWithCode{err: apierrors.NewLokiClientError(err), code: code}
+					}
+					return
+				}
+				qr, code, err := fetchLogQL(q, tc.client)
+				if err != nil {
+					errChan <- errorWithCode{err: apierrors.NewLokiClientError(err), code: code}
+					return
+				}
+				if tc.tenant != "" {
+					qr.Data.AddLabel(constants.TenantLabel, tc.tenant)
+				}
+				resChan <- qr
+			})
+		}
 	}
 
 	for _, q := range promQL {
-		go func(query *prometheus.Query) {
-			defer wg.Done()
-			qr, code, err := prometheus.QueryMatrix(ctx, promClient, query)
+		schedule(c.promPool, func() {
+			qr, code, err := prometheus.RunQuery(ctx, promClient, q)
 			if err != nil {
 				errChan <- errorWithCode{err: apierrors.NewPromClientError(err), code: code}
 			} else {
 				resChan <- qr
 			}
-		}(q)
+		})
 	}
 
 	wg.Wait()
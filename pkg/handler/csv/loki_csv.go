@@ -3,9 +3,16 @@ package csv
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/records"
 	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/fields"
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils"
 )
 
@@ -14,81 +21,251 @@ const (
 	startTimeCol    = timePrefix + "FlowStartMs"
 	endTimeCol      = timePrefix + "FlowEndMs"
 	receivedTimeCol = timePrefix + "Received"
+
+	prettyTimeFormat = "2006-01-02T15:04:05.000Z07:00"
 )
 
-func GetCSVData(qr *model.AggregatedQueryResponse, columns []string) ([][]string, error) {
-	if streams, ok := qr.Result.(model.Streams); ok { // make csv datas containing header as first line + rows
-		data := make([][]string, 1)
-
-		// set time columns first data
-		data[0] = append(data[0], startTimeCol, endTimeCol, receivedTimeCol)
-
-		// prepare columns for faster lookup
-		columnsMap := utils.GetMapInterface(columns)
-		// keep ordered labels / field names between each lines
-		// filtered by columns parameter if specified
-		var labels []string
-		var fields []string
-		for _, stream := range streams {
-			// get labels from first stream
-			if labels == nil {
-				labels = make([]string, 0, len(stream.Labels))
-				for name := range stream.Labels {
-					if _, exists := columnsMap[name]; exists || len(columns) == 0 {
-						labels = append(labels, name)
-					}
-				}
-				data[0] = append(data[0], labels...)
-			}
+// protocolNames are the names of common IANA protocol numbers
+var protocolNames = map[int64]string{
+	1:   "ICMP",
+	2:   "IGMP",
+	6:   "TCP",
+	17:  "UDP",
+	41:  "IPv6",
+	47:  "GRE",
+	50:  "ESP",
+	51:  "AH",
+	58:  "IPv6-ICMP",
+	89:  "OSPF",
+	112: "VRRP",
+	132: "SCTP",
+}
 
-			// apply timestamp & labels for each entries and add json line fields
-			for _, entry := range stream.Entries {
-				// get json line
-				var line map[string]interface{}
-				err := json.Unmarshal([]byte(entry.Line), &line)
-				if err != nil {
-					return nil, fmt.Errorf("cannot unmarshal line %s", entry.Line)
-				}
-
-				// get fields from first line
-				if fields == nil {
-					fields = make([]string, 0, len(line))
-					for name := range line {
-						if !strings.HasPrefix(name, timePrefix) {
-							if _, exists := columnsMap[name]; exists || len(columns) == 0 {
-								fields = append(fields, name)
-							}
-						}
-					}
-					data[0] = append(data[0], fields...)
-				}
-
-				data = append(data, getRowDatas(stream, labels, fields, line, len(data[0])))
-			}
+// Options define which columns are exported, and how values are rendered
+type Options struct {
+	// Columns are the columns displayed in the UI, defining CSV columns order and headers
+	Columns []config.Column
+	// Fields are used for columns when none is configured
+	Fields     []config.FieldConfig
+	FieldsType map[string]string
+	PortNaming config.PortNaming
+	// Pretty renders values as in the UI: timestamps as RFC3339, named ports and protocols, byte units
+	Pretty bool
+}
+
+type csvColumn struct {
+	header string
+	field  string
+}
+
+// GetCSVData makes CSV rows from flows, the first one being the header. Columns follow the configured columns order,
+// restricted to the selected fields when set, so that they are the same for every export and every row.
+func GetCSVData(qr *model.AggregatedQueryResponse, selected []string, opts *Options) ([][]string, error) {
+	var data [][]string
+	table := NewTable(selected, opts)
+	add := func(row []string) error {
+		data = append(data, row)
+		return nil
+	}
+	if err := table.Append(qr, add); err != nil {
+		return nil, err
+	}
+	if err := table.Close(add); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Table writes flows as CSV rows, possibly from several query responses, with the header first
+type Table struct {
+	opts          *Options
+	cols          []csvColumn
+	headerWritten bool
+}
+
+// NewTable creates a table for the selected fields, or all fields when none is selected
+func NewTable(selected []string, opts *Options) *Table {
+	return &Table{opts: opts, cols: getColumns(opts, selected)}
+}
+
+// Append passes the rows of flows to f, preceded by the header on first call.
+// Without configured columns, columns are every field found in the first flows.
+func (t *Table) Append(qr *model.AggregatedQueryResponse, f func(row []string) error) error {
+	if _, ok := qr.Result.(model.Streams); !ok {
+		return fmt.Errorf("loki returned an unexpected type: %T", qr.Result)
+	}
+	if len(t.cols) == 0 && !t.headerWritten {
+		// no configuration: use every field found in flows
+		cols, err := getFlowsColumns(qr, t.opts)
+		if err != nil {
+			return err
 		}
-		return data, nil
+		t.cols = cols
+	}
+	if err := t.writeHeader(f); err != nil {
+		return err
 	}
-	return nil, fmt.Errorf("loki returned an unexpected type: %T", qr.Result)
+	return records.ForEach(qr, t.opts.FieldsType, nil, func(r records.Record) error {
+		row := make([]string, 0, len(t.cols))
+		for _, c := range t.cols {
+			row = append(row, formatValue(t.opts, c.field, r[c.field]))
+		}
+		return f(row)
+	})
+}
+
+// Close passes the header to f when no flows were appended
+func (t *Table) Close(f func(row []string) error) error {
+	return t.writeHeader(f)
 }
 
-func getRowDatas(stream model.Stream, labels, fields []string,
-	line map[string]interface{}, size int) []string {
-	rowDatas := make([]string, 0, size)
+func (t *Table) writeHeader(f func(row []string) error) error {
+	if t.headerWritten {
+		return nil
+	}
+	t.headerWritten = true
+	header := make([]string, 0, len(t.cols))
+	for _, c := range t.cols {
+		header = append(header, c.header)
+	}
+	return f(header)
+}
+
+// getColumns returns the fields of the configured columns, in order, followed by selected fields not shown in columns.
+// Headers are column names, prefixed by their group; additional fields of a column are suffixed by their field name.
+func getColumns(opts *Options, selected []string) []csvColumn {
+	selectedMap := utils.GetMapInterface(selected)
+	keep := func(field string) bool {
+		_, exists := selectedMap[field]
+		return exists || len(selected) == 0
+	}
+	var cols []csvColumn
+	seen := map[string]struct{}{}
+	add := func(header, field string) {
+		if _, ok := seen[field]; ok || field == "" || !keep(field) {
+			return
+		}
+		seen[field] = struct{}{}
+		cols = append(cols, csvColumn{header: header, field: field})
+	}
+	for i := range opts.Columns {
+		c := &opts.Columns[i]
+		name := c.Name
+		if c.Group != "" {
+			name = c.Group + " " + c.Name
+		}
+		main := c.Field
+		if main == "" && len(c.Fields) > 0 {
+			main = c.Fields[0]
+		}
+		add(name, main)
+		for _, f := range c.Fields {
+			if f != main {
+				add(fmt.Sprintf("%s (%s)", name, f), f)
+			}
+		}
+	}
+	if len(opts.Columns) == 0 && len(selected) == 0 {
+		for _, f := range opts.Fields {
+			add(f.Name, f.Name)
+		}
+	}
+	for _, f := range selected {
+		add(f, f)
+	}
+	return cols
+}
 
-	// set time columns
-	rowDatas = append(rowDatas, fmt.Sprint(line[startTimeCol]))
-	rowDatas = append(rowDatas, fmt.Sprint(line[endTimeCol]))
-	rowDatas = append(rowDatas, fmt.Sprint(line[receivedTimeCol]))
+// getFlowsColumns returns time fields first, then every label and field found in flows, sorted by name
+func getFlowsColumns(qr *model.AggregatedQueryResponse, opts *Options) ([]csvColumn, error) {
+	found := map[string]struct{}{}
+	err := records.ForEach(qr, opts.FieldsType, nil, func(r records.Record) error {
+		for name := range r {
+			found[name] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cols := []csvColumn{{header: startTimeCol, field: startTimeCol}, {header: endTimeCol, field: endTimeCol}, {header: receivedTimeCol, field: receivedTimeCol}}
+	names := make([]string, 0, len(found))
+	for name := range found {
+		if !strings.HasPrefix(name, timePrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cols = append(cols, csvColumn{header: name, field: name})
+	}
+	return cols, nil
+}
 
-	// set labels values
-	for _, label := range labels {
-		rowDatas = append(rowDatas, stream.Labels[label])
+func formatValue(opts *Options, field string, v any) string {
+	if v == nil {
+		return ""
+	}
+	if items, ok := v.([]any); ok && opts.Pretty {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, formatValue(opts, field, item))
+		}
+		return strings.Join(values, ", ")
+	}
+	if !opts.Pretty {
+		return fmt.Sprint(v)
+	}
+	n, isNumber := v.(json.Number)
+	if !isNumber {
+		return fmt.Sprint(v)
+	}
+	if factor, ok := records.TimestampFactor(field); ok {
+		if f, err := n.Float64(); err == nil {
+			return time.UnixMilli(int64(f * float64(factor))).UTC().Format(prettyTimeFormat)
+		}
 	}
+	switch {
+	case field == fields.SrcPort || field == fields.DstPort || field == fields.XlatSrcPort || field == fields.XlatDstPort:
+		if name, ok := opts.portName(n.String()); ok {
+			return fmt.Sprintf("%s (%s)", name, n)
+		}
+	case field == fields.Proto:
+		if p, err := n.Int64(); err == nil {
+			if name, ok := protocolNames[p]; ok {
+				return name
+			}
+		}
+	case strings.HasSuffix(field, "Bytes"):
+		if f, err := n.Float64(); err == nil {
+			return formatBytes(f)
+		}
+	}
+	return n.String()
+}
 
-	// set field values
-	for _, field := range fields {
-		rowDatas = append(rowDatas, fmt.Sprint(line[field]))
+func (o *Options) portName(port string) (string, bool) {
+	if !o.PortNaming.Enable {
+		return "", false
 	}
+	name, ok := o.PortNaming.PortNames[port]
+	return name, ok
+}
 
-	return rowDatas
+// formatBytes renders a number of bytes with SI units and one decimal, as in the UI
+func formatBytes(b float64) string {
+	const thresh = 1000
+	if math.Abs(b) < thresh {
+		return strconv.FormatFloat(b, 'f', -1, 64) + " B"
+	}
+	units := []string{"k", "M", "G", "T", "P", "E", "Z", "Y"}
+	u := -1
+	for {
+		b /= thresh
+		u++
+		if math.Round(math.Abs(b)*10)/10 < thresh || u == len(units)-1 {
+			break
+		}
+	}
+	return strconv.FormatFloat(math.Round(b*10)/10, 'f', -1, 64) + " " + units[u] + "B"
 }
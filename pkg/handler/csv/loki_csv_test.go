@@ -0,0 +1,85 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func flows() *model.AggregatedQueryResponse {
+	return &model.AggregatedQueryResponse{
+		Result: model.Streams{{
+			Labels: map[string]string{"SrcK8S_Namespace": "ns1"},
+			Entries: []model.Entry{
+				{Timestamp: time.Now(), Line: `{"TimeFlowEndMs":1700000000123,"Bytes":1234,"Proto":6,"DstPort":443,"SrcAddr":"10.0.0.1","Interfaces":["eth0","br-ex"]}`},
+				{Timestamp: time.Now(), Line: `{"TimeFlowEndMs":1700000001000,"Bytes":10,"Proto":99,"DstPort":8080}`},
+			},
+		}},
+	}
+}
+
+var columns = []config.Column{
+	{ID: "EndTime", Name: "End Time", Field: "TimeFlowEndMs"},
+	{ID: "SrcNamespace", Group: "Source", Name: "Namespace", Field: "SrcK8S_Namespace"},
+	{ID: "SrcAddr", Group: "Source", Name: "IP", Field: "SrcAddr"},
+	{ID: "DstPort", Group: "Destination", Name: "Port", Field: "DstPort"},
+	{ID: "Proto", Name: "Protocol", Field: "Proto"},
+	{ID: "Bytes", Name: "Bytes", Field: "Bytes"},
+	{ID: "Interfaces", Name: "Interfaces", Fields: []string{"Interfaces", "IfDirections"}},
+	{ID: "K8S_FlowLayer", Name: "Flow layer", Calculated: "flowLayer"},
+}
+
+func TestGetCSVData_Columns(t *testing.T) {
+	data, err := GetCSVData(flows(), nil, &Options{Columns: columns})
+	require.NoError(t, err)
+	require.Len(t, data, 3)
+	assert.Equal(t, []string{"End Time", "Source Namespace", "Source IP", "Destination Port", "Protocol", "Bytes", "Interfaces", "Interfaces (IfDirections)"}, data[0])
+	assert.Equal(t, []string{"1700000000123", "ns1", "10.0.0.1", "443", "6", "1234", "[eth0 br-ex]", ""}, data[1])
+	// missing fields are kept as empty values
+	assert.Equal(t, []string{"1700000001000", "ns1", "", "8080", "99", "10", "", ""}, data[2])
+}
+
+func TestGetCSVData_Selected(t *testing.T) {
+	// selected fields keep the columns order, with fields not shown as columns last
+	data, err := GetCSVData(flows(), []string{"Bytes", "Custom", "SrcAddr"}, &Options{Columns: columns})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Source IP", "Bytes", "Custom"}, data[0])
+	assert.Equal(t, []string{"10.0.0.1", "1234", ""}, data[1])
+}
+
+func TestGetCSVData_Fallbacks(t *testing.T) {
+	data, err := GetCSVData(flows(), nil, &Options{Fields: []config.FieldConfig{{Name: "SrcAddr"}, {Name: "Bytes"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SrcAddr", "Bytes"}, data[0])
+
+	data, err = GetCSVData(flows(), nil, &Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TimeFlowStartMs", "TimeFlowEndMs", "TimeReceived", "Bytes", "DstPort", "Interfaces", "Proto", "SrcAddr", "SrcK8S_Namespace"}, data[0])
+	assert.Equal(t, []string{"", "1700000001000", "", "10", "8080", "", "99", "", "ns1"}, data[2])
+
+	_, err = GetCSVData(&model.AggregatedQueryResponse{Result: model.Matrix{}}, nil, &Options{})
+	assert.EqualError(t, err, "loki returned an unexpected type: model.Matrix")
+}
+
+func TestGetCSVData_Pretty(t *testing.T) {
+	data, err := GetCSVData(flows(), nil, &Options{
+		Columns:    columns,
+		PortNaming: config.PortNaming{Enable: true, PortNames: map[string]string{"443": "https"}},
+		Pretty:     true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2023-11-14T22:13:20.123Z", "ns1", "10.0.0.1", "https (443)", "TCP", "1.2 kB", "eth0, br-ex", ""}, data[1])
+	assert.Equal(t, []string{"2023-11-14T22:13:21.000Z", "ns1", "", "8080", "99", "10 B", "", ""}, data[2])
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "0 B", formatBytes(0))
+	assert.Equal(t, "999 B", formatBytes(999))
+	assert.Equal(t, "1 kB", formatBytes(1000))
+	assert.Equal(t, "1 MB", formatBytes(999999))
+	assert.Equal(t, "2.5 GB", formatBytes(2.5e9))
+}
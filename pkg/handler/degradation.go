@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
+	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	pmodel "github.com/prometheus/common/model"
+)
+
+const (
+	degradeCoarserStep  = "coarserStep"
+	degradeNarrowerTopk = "narrowerTopk"
+	degradeTimeSharding = "timeSharding"
+	degradeShorterRange = "shorterRange"
+
+	degradationStepFactor   = 4
+	degradationMinTopk      = 5
+	degradationShards       = 4
+	degradationRangeDivisor = 4
+)
+
+// degradationLadders lists, per reason, the degradations to try in order; each one is applied on top of the previous ones
+var degradationLadders = map[string][]string{
+	model.DegradationSeriesLimit: {degradeNarrowerTopk, degradeTimeSharding, degradeShorterRange},
+	model.DegradationTimeout:     {degradeCoarserStep, degradeTimeSharding, degradeShorterRange},
+}
+
+// lokiLimitReason tells whether an error comes from Loki rejecting a query for its limits, which a cheaper query could avoid.
+// Only Loki messages are matched: timeouts of the plugin clients or canceled requests aren't limits.
+// Responses of parallel queries are wrapped in client errors, keeping their message.
+func lokiLimitReason(err error) string {
+	var respErr *apierrors.LokiResponseError
+	var clientErr *apierrors.LokiClientError
+	if !errors.As(err, &respErr) && !errors.As(err, &clientErr) {
+		return ""
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "maximum of series"):
+		return model.DegradationSeriesLimit
+	case strings.Contains(msg, "query timed out"):
+		return model.DegradationTimeout
+	}
+	return ""
+}
+
+// degrade applies a degradation on the topology input and the range of its Prometheus queries, returning false if it's not applicable
+func degrade(name string, in *loki.TopologyInput, qr *v1.Range, d *model.Degradation) (bool, error) {
+	switch name {
+	case degradeCoarserStep:
+		if in.Snapshot {
+			return false, nil
+		}
+		step, err := time.ParseDuration(in.Step)
+		if err != nil {
+			return false, fmt.Errorf("invalid step %s: %w", in.Step, err)
+		}
+		qr.Step = step * degradationStepFactor
+		in.Step = pmodel.Duration(qr.Step).String()
+		d.Step = in.Step
+	case degradeNarrowerTopk:
+		top, err := strconv.Atoi(in.Top)
+		if err != nil || top <= degradationMinTopk {
+			return false, nil
+		}
+		in.Top = strconv.Itoa(max(top/2, degradationMinTopk))
+		d.Top = in.Top
+	case degradeTimeSharding:
+		// Sharded vectors cannot be merged for functions such as avg or max
+		if in.Snapshot || in.Start == "" {
+			return false, nil
+		}
+		d.Shards = degradationShards
+	case degradeShorterRange:
+		if in.Start == "" {
+			return false, nil
+		}
+		start, end, err := topologyTimeRange(in)
+		if err != nil {
+			return false, err
+		}
+		start = end - (end-start)/degradationRangeDivisor
+		qr.Start = time.Unix(start, 0)
+		in.Start = strconv.FormatInt(start, 10)
+		d.Start = in.Start
+	default:
+		return false, fmt.Errorf("unknown degradation: %s", name)
+	}
+	return true, nil
+}
+
+func topologyTimeRange(in *loki.TopologyInput) (int64, int64, error) {
+	start, err := strconv.ParseInt(in.Start, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not parse start time: %w", err)
+	}
+	end := time.Now().Unix()
+	if in.End != "" {
+		if end, err = strconv.ParseInt(in.End, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("could not parse end time: %w", err)
+		}
+	}
+	return start, end, nil
+}
+
+// shardTimeRange splits the time range into contiguous shards, aligned on the step so that merged results keep the same timestamps
+func shardTimeRange(in *loki.TopologyInput, qr *v1.Range, shards int) ([]loki.TopologyInput, []v1.Range, error) {
+	start, end, err := topologyTimeRange(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	step, err := time.ParseDuration(in.Step)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid step %s: %w", in.Step, err)
+	}
+	stepSecs := max(int64(step.Seconds()), 1)
+	shardLen := (end - start) / int64(shards)
+	shardLen = max((shardLen+stepSecs-1)/stepSecs*stepSecs, stepSecs)
+
+	var inputs []loki.TopologyInput
+	var ranges []v1.Range
+	for s := start; s <= end; s += shardLen {
+		// Shards don't overlap: Loki includes both ends of the range
+		e := min(s+shardLen-1, end)
+		shardIn := *in
+		shardIn.Start = strconv.FormatInt(s, 10)
+		shardIn.End = strconv.FormatInt(e, 10)
+		inputs = append(inputs, shardIn)
+		ranges = append(ranges, v1.Range{Start: time.Unix(s, 0), End: time.Unix(e, 0), Step: qr.Step})
+	}
+	return inputs, ranges, nil
+}
+
+func newTopologyMerger(in *loki.TopologyInput) loki.Merger {
+	reqLimit, _ := strconv.Atoi(in.Top)
+	if in.Snapshot {
+		return loki.NewVectorMerger(reqLimit)
+	}
+	return loki.NewMatrixMerger(reqLimit)
+}
+
+// fetchDegradedTopology retries topology queries with cumulative degradations, until one succeeds or none is left.
+// It returns the last error when all of them failed, or when the context is done: retries share the deadline of the request.
+func (h *Handlers) fetchDegradedTopology(
+	ctx context.Context,
+	cl clients,
+	filterGroups filters.MultiQueries,
+	in *loki.TopologyInput,
+	qr *v1.Range,
+	isDev bool,
+	reason string,
+	dataSources map[constants.DataSource]bool,
+) (loki.Merger, *model.Degradation, error) {
+	// Loki and Prometheus queries are degraded alike
+	current, currentRange := *in, *qr
+	d := model.Degradation{Reason: reason, Applied: []string{}}
+	var err error
+	for _, name := range degradationLadders[reason] {
+		applied, derr := degrade(name, &current, &currentRange, &d)
+		if derr != nil {
+			return nil, nil, derr
+		}
+		if !applied {
+			continue
+		}
+		if cerr := ctx.Err(); cerr != nil {
+			return nil, nil, fmt.Errorf("no time left to retry: %w", cerr)
+		}
+		d.Applied = append(d.Applied, name)
+		hlog.Infof("Retrying topology query after %s error, with degradations: %v", reason, d.Applied)
+
+		merger := newTopologyMerger(&current)
+		if d.Shards > 1 {
+			_, err = h.fetchTopologyShards(ctx, cl, filterGroups, &current, &currentRange, isDev, merger, dataSources, d.Shards)
+		} else {
+			_, err = h.fetchTopology(ctx, cl, filterGroups, &current, &currentRange, isDev, merger, dataSources)
+		}
+		if err == nil {
+			return merger, &d, nil
+		}
+		if lokiLimitReason(err) == "" {
+			// Not related to limits anymore, degrading further wouldn't help
+			return nil, nil, err
+		}
+	}
+	if err == nil {
+		err = errors.New("no degradation applicable")
+	}
+	return nil, nil, err
+}
+
+// fetchTopologyShards runs queries of every time shard in parallel, through the pools, merging all results
+func (h *Handlers) fetchTopologyShards(
+	ctx context.Context,
+	cl clients,
+	filterGroups filters.MultiQueries,
+	in *loki.TopologyInput,
+	qr *v1.Range,
+	isDev bool,
+	merger loki.Merger,
+	dataSources map[constants.DataSource]bool,
+	shards int,
+) (int, error) {
+	inputs, ranges, err := shardTimeRange(in, qr, shards)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	var lokiQ []string
+	var promQ []*prometheus.Query
+	for i := range inputs {
+		lq, pq, code, err := h.buildTopologyQueries(filterGroups, &inputs[i], &ranges[i], isDev, dataSources)
+		if err != nil {
+			return code, err
+		}
+		lokiQ = append(lokiQ, lq...)
+		promQ = append(promQ, pq...)
+	}
+	code, serr := cl.fetchParallel(ctx, lokiQ, promQ, merger, isDev)
+	if serr != nil {
+		return code, serr
+	}
+	return http.StatusOK, nil
+}
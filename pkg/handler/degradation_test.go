@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
+	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limitedLoki rejects queries with a series limit error, unless the predicate accepts them
+type limitedLoki struct {
+	accept func(url string) bool
+	mu     sync.Mutex
+	urls   []string
+}
+
+func (l *limitedLoki) Get(url string) ([]byte, int, error) {
+	l.mu.Lock()
+	l.urls = append(l.urls, url)
+	l.mu.Unlock()
+	if !l.accept(url) {
+		return []byte(`{"status":"error","errorType":"internal","error":"maximum of series (500) reached for a single query"}`), 500, nil
+	}
+	return []byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"SrcK8S_Namespace":"a","DstK8S_Namespace":"b"},"values":[[1000,"1"]]}]}}`), 200, nil
+}
+
+func degradationHandlers() *Handlers {
+	return &Handlers{Cfg: &config.Config{
+		Loki: config.Loki{URL: "http://loki", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace", "FlowDirection"}},
+		Frontend: config.Frontend{Scopes: []config.Scope{
+			{ID: "namespace", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace"}},
+		}},
+	}}
+}
+
+func TestLokiLimitReason(t *testing.T) {
+	assert.Equal(t, model.DegradationSeriesLimit, lokiLimitReason(apierrors.NewLokiResponseError(500, "Loki message: maximum of series (50000) reached for a single query")))
+	assert.Equal(t, model.DegradationTimeout, lokiLimitReason(apierrors.NewLokiClientError(errors.New("Error from Loki: [500] Loki message: context deadline exceeded: query timed out"))))
+	assert.Empty(t, lokiLimitReason(apierrors.NewLokiResponseError(400, "Loki message: parse error")))
+	// Timeouts of the plugin clients and canceled requests are not Loki limits
+	assert.Empty(t, lokiLimitReason(apierrors.NewLokiClientError(errors.New(`Get "http://loki": context deadline exceeded (Client.Timeout exceeded while awaiting headers)`))))
+	assert.Empty(t, lokiLimitReason(apierrors.NewLokiClientError(errors.New(`Get "http://loki": net/http: timeout awaiting response headers`))))
+	assert.Empty(t, lokiLimitReason(apierrors.NewLokiClientError(context.Canceled)))
+	// Prometheus errors are not degraded
+	assert.Empty(t, lokiLimitReason(apierrors.NewPromClientError(errors.New("query timed out"))))
+}
+
+func TestDegrade(t *testing.T) {
+	in := loki.TopologyInput{Start: "1000", End: "5000", Top: "8", Step: "30s"}
+	qr := v1.Range{Start: time.Unix(1000, 0), End: time.Unix(5000, 0), Step: 30 * time.Second}
+	d := model.Degradation{}
+
+	ok, err := degrade(degradeCoarserStep, &in, &qr, &d)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2m", in.Step)
+	assert.Equal(t, 2*time.Minute, qr.Step)
+
+	ok, err = degrade(degradeNarrowerTopk, &in, &qr, &d)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "5", in.Top)
+	ok, err = degrade(degradeNarrowerTopk, &in, &qr, &d)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = degrade(degradeShorterRange, &in, &qr, &d)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "4000", in.Start)
+	assert.Equal(t, int64(4000), qr.Start.Unix())
+
+	assert.Equal(t, model.Degradation{Step: "2m", Top: "5", Start: "4000"}, d)
+
+	// Sharding is not applicable to snapshots
+	in.Snapshot = true
+	ok, err = degrade(degradeTimeSharding, &in, &qr, &d)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestShardTimeRange(t *testing.T) {
+	in := loki.TopologyInput{Start: "1000", End: "2000", Step: "1m"}
+	inputs, ranges, err := shardTimeRange(&in, &v1.Range{}, 4)
+	require.NoError(t, err)
+	// 1000s / 4 = 250s, aligned on 60s step => 300s shards
+	require.Len(t, inputs, 4)
+	require.Len(t, ranges, 4)
+	var bounds [][2]string
+	for i := range inputs {
+		bounds = append(bounds, [2]string{inputs[i].Start, inputs[i].End})
+	}
+	assert.Equal(t, [][2]string{{"1000", "1299"}, {"1300", "1599"}, {"1600", "1899"}, {"1900", "2000"}}, bounds)
+	assert.Equal(t, int64(1300), ranges[1].Start.Unix())
+}
+
+func TestGetTopology_DegradedOnSeriesLimit(t *testing.T) {
+	h := degradationHandlers()
+	// Only accept time-sharded queries with narrower topk
+	fake := &limitedLoki{accept: func(u string) bool {
+		return strings.Contains(u, "topk(25,") && !strings.Contains(u, "start=1000&end=2001")
+	}}
+	params := url.Values{
+		"startTime":   {"1000"},
+		"endTime":     {"2000"},
+		"limit":       {"50"},
+		"step":        {"1m"},
+		"aggregateBy": {"namespace"},
+		"type":        {"Flows"},
+		"function":    {"count"},
+	}
+	resp, code, err := h.getTopologyFlows(context.TODO(), clients{loki: fake}, params, constants.DataSourceLoki, true)
+	require.NoError(t, err)
+	assert.Equal(t, 200, code)
+	require.NotNil(t, resp.Stats.Degradation)
+	assert.Equal(t, model.Degradation{
+		Reason:  model.DegradationSeriesLimit,
+		Applied: []string{degradeNarrowerTopk, degradeTimeSharding},
+		Top:     "25",
+		Shards:  4,
+	}, *resp.Stats.Degradation)
+	// 1 initial query, 1 with narrower topk, 4 shards
+	assert.Len(t, fake.urls, 6)
+	assert.Len(t, resp.Result, 1)
+}
+
+func TestGetTopology_DegradationExhausted(t *testing.T) {
+	h := degradationHandlers()
+	fake := &limitedLoki{accept: func(string) bool { return false }}
+	params := url.Values{
+		"startTime":   {"1000"},
+		"endTime":     {"2000"},
+		"limit":       {"50"},
+		"aggregateBy": {"namespace"},
+		"type":        {"Flows"},
+		"function":    {"count"},
+	}
+	_, _, err := h.getTopologyFlows(context.TODO(), clients{loki: fake}, params, constants.DataSourceLoki, true)
+	require.ErrorContains(t, err, "maximum of series")
+}
+
+func TestGetTopology_DegradationDeadline(t *testing.T) {
+	h := degradationHandlers()
+	fake := &limitedLoki{accept: func(string) bool { return false }}
+	params := url.Values{
+		"startTime":   {"1000"},
+		"endTime":     {"2000"},
+		"limit":       {"50"},
+		"aggregateBy": {"namespace"},
+		"type":        {"Flows"},
+		"function":    {"count"},
+	}
+	// no retry once the deadline of the request is exceeded
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, _, err := h.getTopologyFlows(ctx, clients{loki: fake}, params, constants.DataSourceLoki, true)
+	require.ErrorContains(t, err, "maximum of series")
+	assert.Len(t, fake.urls, 1)
+}
+
+func TestFetchDegradedTopology_BothDatasources(t *testing.T) {
+	h := degradationHandlers()
+	h.Cfg.Prometheus.Metrics = []config.MetricInfo{
+		{Enabled: true, Name: "netobserv_namespace_ingress_bytes_total", Type: "counter", ValueField: "Bytes", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace"}},
+	}
+	h.PromInventory = prometheus.NewInventory(&h.Cfg.Prometheus)
+	var mu sync.Mutex
+	var promSteps []string
+	promSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		promSteps = append(promSteps, r.FormValue("step"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer promSrv.Close()
+	promClient, err := api.NewClient(api.Config{Address: promSrv.URL})
+	require.NoError(t, err)
+	fake := &limitedLoki{accept: func(u string) bool { return strings.Contains(u, "step=2m") }}
+
+	in := loki.TopologyInput{
+		Start:          "1000",
+		End:            "2000",
+		Top:            "50",
+		Step:           "30s",
+		DataField:      constants.MetricTypeBytes,
+		MetricFunction: constants.MetricFunctionRate,
+		DataSource:     constants.DataSourceAuto,
+		PacketLoss:     constants.PacketLossAll,
+		Aggregate:      "namespace",
+	}
+	qr := v1.Range{Start: time.Unix(1000, 0), End: time.Unix(2000, 0), Step: 30 * time.Second}
+	// the first group is served by Prometheus, the second one by Loki
+	mq := filters.MultiQueries{
+		{filters.NewEqualMatch("SrcK8S_Namespace", `"a"`)},
+		{filters.NewEqualMatch("DstK8S_Type", `"Pod"`)},
+	}
+	dataSources := map[constants.DataSource]bool{}
+	_, d, err := h.fetchDegradedTopology(context.TODO(), clients{loki: fake, promAdmin: promClient}, mq, &in, &qr, false, model.DegradationTimeout, dataSources)
+	require.NoError(t, err)
+	assert.Equal(t, "2m", d.Step)
+	assert.True(t, dataSources[constants.DataSourceLoki])
+	assert.True(t, dataSources[constants.DataSourceProm])
+	// Prometheus queries have the same coarser step
+	assert.Equal(t, []string{"120"}, promSteps)
+	// the original range is left untouched
+	assert.Equal(t, 30*time.Second, qr.Step)
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+)
+
+const (
+	jsonMediaType = "application/json"
+	textMediaType = "text/plain"
+)
+
+// flowsExportMediaTypes are the flows export formats negotiated from the Accept header, when not set as a parameter
+var flowsExportMediaTypes = map[string]string{
+	"text/csv":                       exportCSVFormat,
+	"application/json":               exportJSONFormat,
+	"application/x-ndjson":           exportNDJSONFormat,
+	"application/vnd.apache.parquet": exportParquetFormat,
+	"text/plain":                     exportZeekFormat,
+}
+
+// v2ExportRoutes are the routes of the v2 API responding exports rather than envelopes, with their negotiated formats
+var v2ExportRoutes = map[string]map[string]string{
+	"/loki/export":                    flowsExportMediaTypes,
+	"/loki/export/jobs/{id}/download": flowsExportMediaTypes,
+	"/flow/metrics/export":            topologyExportMediaTypes(),
+}
+
+// v2TextRoutes are the routes of the v2 API that can respond plain text, rather than an envelope
+var v2TextRoutes = []string{"/loki/ready", "/loki/metrics", "/loki/buildinfo"}
+
+func topologyExportMediaTypes() map[string]string {
+	mediaTypes := map[string]string{}
+	for format, types := range topologyExportTypes {
+		mediaType, _, _ := mime.ParseMediaType(types[1])
+		mediaTypes[mediaType] = format
+	}
+	return mediaTypes
+}
+
+// Envelope is the middleware of the v2 API, whose routes are registered below the given prefix. It negotiates the
+// content type of responses from the Accept header: JSON responses and errors are wrapped in a model.Envelope, while
+// exports are written as is, in the format of the format parameter or else the negotiated one.
+func Envelope(prefix string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ew := &envelopeWriter{ResponseWriter: w}
+			defer ew.close()
+
+			var path string
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					path = strings.TrimPrefix(tpl, prefix)
+				}
+			}
+			accept := parseAccept(r.Header.Get("Accept"))
+			if formats, ok := v2ExportRoutes[path]; ok {
+				params := r.URL.Query()
+				if params.Get(exportFormatKey) == "" {
+					format, err := accept.format(formats)
+					if err != nil {
+						apierrors.Write(ew, http.StatusNotAcceptable, err)
+						return
+					}
+					if format != "" {
+						params.Set(exportFormatKey, format)
+						r.URL.RawQuery = params.Encode()
+					}
+				}
+			} else {
+				offers := []string{jsonMediaType}
+				if slices.Contains(v2TextRoutes, path) {
+					offers = append(offers, textMediaType)
+				}
+				mediaType := accept.negotiate(offers)
+				if mediaType == "" {
+					apierrors.Write(ew, http.StatusNotAcceptable, fmt.Errorf("none of the accepted media types is offered: %s", strings.Join(offers, ", ")))
+					return
+				}
+				ew.text = mediaType == textMediaType
+			}
+			next.ServeHTTP(ew, r)
+		})
+	}
+}
+
+// envelopeWriter writes responses of the v2 API. Payloads are wrapped in an envelope, as well as errors written as
+// raw bytes, such as authorization failures. Other raw bytes, such as exports, are written as is.
+type envelopeWriter struct {
+	http.ResponseWriter
+	// text is set when the client prefers plain text for text payloads
+	text bool
+	// errCode and errBody hold raw errors, to be enveloped once the handler is done
+	errCode int
+	errBody bytes.Buffer
+}
+
+func (w *envelopeWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest {
+		w.errCode = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *envelopeWriter) Write(b []byte) (int, error) {
+	if w.errCode != 0 {
+		return w.errBody.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *envelopeWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok && w.errCode == 0 {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives access to the underlying response writer, for http.ResponseController
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *envelopeWriter) close() {
+	if w.errCode != 0 {
+		code := w.errCode
+		w.errCode = 0
+		w.WritePayload(code, &apierrors.GenericError{Message: strings.TrimSpace(w.errBody.String())})
+	}
+}
+
+func (w *envelopeWriter) WritePayload(code int, payload any) {
+	// raw errors are replaced
+	w.errCode = 0
+	w.errBody.Reset()
+	if code == http.StatusNoContent {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if text, ok := payload.(string); ok && w.text {
+		w.Header().Set("Content-Type", textMediaType)
+		w.ResponseWriter.WriteHeader(code)
+		if _, err := w.ResponseWriter.Write([]byte(text)); err != nil {
+			hlog.Errorf("Error while responding Text: %v", err)
+		}
+		return
+	}
+
+	response, err := json.Marshal(newEnvelope(code, payload))
+	if err != nil {
+		hlog.Errorf("Marshalling error while responding envelope: %v", err)
+		code = http.StatusInternalServerError
+		response, _ = json.Marshal(newEnvelope(code, &apierrors.GenericError{Message: err.Error()}))
+	}
+	w.Header().Del("Content-Disposition")
+	w.Header().Set("Content-Type", jsonMediaType)
+	w.ResponseWriter.WriteHeader(code)
+	if _, err = w.ResponseWriter.Write(response); err != nil {
+		hlog.Errorf("Error while responding envelope: %v", err)
+	}
+}
+
+func newEnvelope(code int, payload any) model.Envelope {
+	switch p := payload.(type) {
+	case error:
+		e := model.EnvelopeError{Code: string(apierrors.CodeOf(p, code)), Message: p.Error()}
+		if lokiLimitReason(p) != "" {
+			e.Code = string(apierrors.CodeLokiLimits)
+		}
+		var generic *apierrors.GenericError
+		if !errors.As(p, &generic) {
+			e.Details = p
+		}
+		return model.Envelope{Errors: []model.EnvelopeError{e}}
+	case *model.AggregatedQueryResponse:
+		return model.Envelope{
+			Data:     model.QueryResult{ResultType: p.ResultType, Result: p.Result, UnixTimestamp: p.UnixTimestamp},
+			Stats:    p.Stats,
+			Warnings: queryWarnings(&p.Stats),
+		}
+	case model.ExportJob:
+		var warnings []string
+		if p.Truncated {
+			warnings = append(warnings, "export was truncated after "+strconv.Itoa(p.MaxRows)+" rows")
+		}
+		return model.Envelope{Data: p, Warnings: warnings}
+	default:
+		return model.Envelope{Data: payload}
+	}
+}
+
+// queryWarnings tells clients about results that are incomplete or approximated
+func queryWarnings(stats *model.AggregatedStats) []string {
+	var warnings []string
+	if stats.LimitReached {
+		warnings = append(warnings, "limit reached: results are truncated")
+	}
+	if stats.Estimate {
+		warnings = append(warnings, "results are estimated from ingested log volumes")
+		warnings = append(warnings, stats.Approximations...)
+	}
+	if d := stats.Degradation; d != nil {
+		warnings = append(warnings, fmt.Sprintf("queries were degraded (%s): %s", d.Reason, strings.Join(d.Applied, ", ")))
+	}
+	return warnings
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+type acceptHeader []mediaRange
+
+// parseAccept parses the media ranges of an Accept header, by decreasing quality
+func parseAccept(header string) acceptHeader {
+	var accept acceptHeader
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if str, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(str, 64); err != nil {
+				continue
+			}
+		}
+		accept = append(accept, mediaRange{mediaType: mediaType, q: q})
+	}
+	slices.SortStableFunc(accept, func(a, b mediaRange) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+	return accept
+}
+
+// quality returns the quality of a media type, from its most specific media range
+func (a acceptHeader) quality(mediaType string) float64 {
+	q, specificity := 0.0, -1
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	for _, r := range a {
+		s := -1
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// negotiate returns the offered media type of highest quality, the first one without Accept header, or an empty
+// string when none is acceptable
+func (a acceptHeader) negotiate(offers []string) string {
+	if len(a) == 0 {
+		return offers[0]
+	}
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := a.quality(offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// format returns the export format of the first media range naming one, or an empty string when the Accept header
+// is missing or has wildcards, leaving the format to the format parameter
+func (a acceptHeader) format(formats map[string]string) (string, error) {
+	wildcard := len(a) == 0
+	for _, r := range a {
+		if r.q <= 0 {
+			continue
+		}
+		if format, ok := formats[r.mediaType]; ok {
+			return format, nil
+		}
+		wildcard = wildcard || strings.HasSuffix(r.mediaType, "/*")
+	}
+	if wildcard {
+		return "", nil
+	}
+	return "", fmt.Errorf("none of the accepted media types is offered: %s", strings.Join(slices.Sorted(maps.Keys(formats)), ", "))
+}
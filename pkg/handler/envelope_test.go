@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+)
+
+func serveV2(path string, handle http.HandlerFunc, target, accept string) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	v2 := r.PathPrefix("/api/v2").Subrouter()
+	v2.Use(Envelope("/api/v2"))
+	v2.HandleFunc(path, handle)
+	req := httptest.NewRequest(http.MethodGet, "/api/v2"+target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestEnvelope_Payloads(t *testing.T) {
+	// queries, with stats and warnings
+	rec := serveV2("/flow/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, &model.AggregatedQueryResponse{
+			ResultType:    model.ResultTypeStream,
+			Result:        model.Streams{},
+			Stats:         model.AggregatedStats{NumQueries: 1, LimitReached: true},
+			UnixTimestamp: 1700000000,
+		})
+	}, "/flow/metrics", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"data": {"resultType": "streams", "result": [], "unixTimestamp": 1700000000},
+		"stats": {"numQueries": 1, "totalEntries": 0, "duplicates": 0, "limitReached": true, "queriesStats": null, "dataSources": null},
+		"warnings": ["limit reached: results are truncated"]
+	}`, rec.Body.String())
+
+	// other payloads
+	rec = serveV2("/resources/namespaces", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, []string{"a", "b"})
+	}, "/resources/namespaces", "application/*")
+	assert.JSONEq(t, `{"data": ["a", "b"]}`, rec.Body.String())
+
+	// text, as JSON or plain text
+	ready := func(w http.ResponseWriter, _ *http.Request) { writeText(w, http.StatusOK, []byte("ready")) }
+	rec = serveV2("/loki/ready", ready, "/loki/ready", "")
+	assert.JSONEq(t, `{"data": "ready"}`, rec.Body.String())
+	rec = serveV2("/loki/ready", ready, "/loki/ready", "text/plain, application/json;q=0.5")
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "ready", rec.Body.String())
+}
+
+func TestEnvelope_Errors(t *testing.T) {
+	// structured errors
+	rec := serveV2("/flow/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		apierrors.Write(w, http.StatusBadRequest, apierrors.NewPromMissingLabels([]string{"SrcK8S_Name"}))
+	}, "/flow/metrics", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"errors": [{
+		"code": "PromMissingLabels",
+		"message": "some requested labels are missing in Prometheus metrics: SrcK8S_Name",
+		"details": {"promMissingLabels": true, "missing": ["SrcK8S_Name"]}
+	}]}`, rec.Body.String())
+
+	// Loki rejecting queries for its limits
+	rec = serveV2("/flow/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		apierrors.Write(w, http.StatusBadRequest, apierrors.NewLokiResponseError(400, "maximum of series (500) reached for a single query"))
+	}, "/flow/metrics", "")
+	assert.Contains(t, rec.Body.String(), `"code":"LokiLimits"`)
+
+	// generic and raw errors, coded from their status
+	rec = serveV2("/flow/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "not allowed", http.StatusUnauthorized)
+	}, "/flow/metrics", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"errors": [{"code": "Unauthorized", "message": "not allowed"}]}`, rec.Body.String())
+
+	// not acceptable
+	rec = serveV2("/flow/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, "unexpected")
+	}, "/flow/metrics", "text/csv")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.JSONEq(t, `{"errors": [{"code": "NotAcceptable", "message": "none of the accepted media types is offered: application/json"}]}`, rec.Body.String())
+}
+
+func TestEnvelope_Exports(t *testing.T) {
+	export := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("format=" + r.URL.Query().Get(exportFormatKey)))
+	}
+
+	// exports are written as is, with the format parameter or else the negotiated one
+	rec := serveV2("/loki/export", export, "/loki/export?format=csv", "application/vnd.apache.parquet")
+	assert.Equal(t, "format=csv", rec.Body.String())
+	rec = serveV2("/loki/export", export, "/loki/export", "application/vnd.apache.parquet, text/csv;q=0.5")
+	assert.Equal(t, "format=parquet", rec.Body.String())
+	rec = serveV2("/flow/metrics/export", export, "/flow/metrics/export", "text/vnd.graphviz")
+	assert.Equal(t, "format=dot", rec.Body.String())
+	// wildcards leave the format to the parameter
+	rec = serveV2("/loki/export", export, "/loki/export", "*/*")
+	assert.Equal(t, "format=", rec.Body.String())
+
+	rec = serveV2("/flow/metrics/export", export, "/flow/metrics/export", "application/x-ndjson")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"NotAcceptable"`)
+}
+
+func TestAcceptHeader(t *testing.T) {
+	offers := []string{jsonMediaType, textMediaType}
+	assert.Equal(t, jsonMediaType, parseAccept("").negotiate(offers))
+	assert.Equal(t, jsonMediaType, parseAccept("*/*").negotiate(offers))
+	assert.Equal(t, textMediaType, parseAccept("text/*, application/json;q=0.1").negotiate(offers))
+	assert.Equal(t, jsonMediaType, parseAccept("*/*, text/plain;q=0").negotiate(offers))
+	assert.Empty(t, parseAccept("text/csv").negotiate(offers))
+	// invalid ranges are ignored
+	assert.Equal(t, textMediaType, parseAccept("text/plain;q=x, text/plain;q=0.9").negotiate(offers))
+}
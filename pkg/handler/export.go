@@ -2,8 +2,8 @@ package handler
 
 import (
 	"context"
-	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -12,9 +12,22 @@ import (
 )
 
 const (
-	exportCSVFormat  = "csv"
-	exportFormatKey  = "format"
-	exportcolumnsKey = "columns"
+	exportCSVFormat     = "csv"
+	exportJSONFormat    = "json"
+	exportNDJSONFormat  = "ndjson"
+	exportParquetFormat = "parquet"
+	exportZeekFormat    = "zeek"
+	exportHubbleFormat  = "hubble"
+	exportFormatKey     = "format"
+	exportcolumnsKey    = "columns"
+	exportPrettyKey     = "pretty"
+	exportPagedKey      = "paged"
+	exportMaxRowsKey    = "maxRows"
+
+	// exportFlushEvery is the number of records written between flushes of streamed exports
+	exportFlushEvery = 1000
+	// exportRowGroupSize is the number of rows of Parquet row groups
+	exportRowGroupSize = 10000
 )
 
 func (h *Handlers) ExportFlows(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
@@ -24,7 +37,6 @@ func (h *Handlers) ExportFlows(ctx context.Context) func(w http.ResponseWriter,
 			err.Write(w, http.StatusBadRequest)
 			return
 		}
-		cl := newLokiClient(&h.Cfg.Loki, r.Header, false)
 		var code int
 		startTime := time.Now()
 		defer func() {
@@ -34,25 +46,41 @@ func (h *Handlers) ExportFlows(ctx context.Context) func(w http.ResponseWriter,
 		params := r.URL.Query()
 		hlog.Debugf("ExportFlows query params: %s", params)
 
-		flows, code, err := h.getFlows(ctx, cl, params)
+		tenants, err := getTenants(&h.Cfg.Loki, params)
 		if err != nil {
+			code = http.StatusBadRequest
 			apierrors.Write(w, code, err)
 			return
 		}
+		cl := newLokiQueryClients(&h.Cfg.Loki, r.Header, false, tenants)
+		cl.withPools(&h.Cfg.Concurrency, r.Header)
 
-		exportFormat := params.Get(exportFormatKey)
-		var exportColumns []string
-		if str := params.Get(exportcolumnsKey); len(str) > 0 {
-			exportColumns = strings.Split(str, ",")
+		opts := getExportOptions(params)
+		if params.Get(exportPagedKey) == "true" {
+			// bound to the request, to stop fetching pages when the client disconnects
+			code = h.exportPaged(r.Context(), w, cl, params, h.isAdmin(ctx, r.Header), opts)
+			return
 		}
 
-		switch exportFormat {
-		case exportCSVFormat:
-			code = http.StatusOK
-			writeCSV(w, code, flows, exportColumns)
-		default:
-			code = http.StatusBadRequest
-			apierrors.Write(w, code, fmt.Errorf("export format %q is not valid", exportFormat))
+		flows, code, err := h.getFlows(r.Context(), cl, params, h.isAdmin(ctx, r.Header))
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
 		}
+		code, err = writeFlows(w, http.StatusOK, flows, h.Cfg, opts)
+		if err != nil {
+			apierrors.Write(w, code, err)
+		}
+	}
+}
+
+func getExportOptions(params url.Values) *exportOptions {
+	opts := &exportOptions{
+		format: params.Get(exportFormatKey),
+		pretty: params.Get(exportPrettyKey) == "true",
+	}
+	if str := params.Get(exportcolumnsKey); len(str) > 0 {
+		opts.columns = strings.Split(str, ",")
 	}
+	return opts
 }
@@ -0,0 +1,473 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/kubernetes/auth"
+	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+)
+
+const (
+	exportJobIDKey    = "id"
+	exportJobSpoolExt = ".ndjson"
+)
+
+var exportJobIDRegexp = regexp.MustCompile(`^[a-f0-9]{32}$`)
+
+// ExportJobs runs asynchronous exports, spooling fetched flows to a local directory until their results expire.
+// Results are spooled as Loki streams, one per line, so that they can be downloaded in any export format.
+type ExportJobs struct {
+	ctx  context.Context
+	cfg  *config.ExportJobs
+	mu   sync.Mutex
+	jobs map[string]*exportJob
+	// size is the total size of spooled results
+	size int64
+}
+
+type exportJob struct {
+	// status is guarded by ExportJobs.mu
+	status model.ExportJob
+	owner  string
+	path   string
+	cancel context.CancelFunc
+	// deleted tells the running export to remove its result when it ends
+	deleted bool
+	removed bool
+}
+
+// NewExportJobs creates the spool directory, removes results left by a previous run,
+// then deletes expired results until ctx is done. Running jobs are canceled with ctx.
+func NewExportJobs(ctx context.Context, cfg *config.ExportJobs) (*ExportJobs, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create export jobs directory: %w", err)
+	}
+	leftovers, err := filepath.Glob(filepath.Join(cfg.Dir, "*"+exportJobSpoolExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range leftovers {
+		if err := os.Remove(f); err != nil {
+			hlog.Warnf("Cannot remove export job result %s: %v", f, err)
+		}
+	}
+	j := &ExportJobs{ctx: ctx, cfg: cfg, jobs: map[string]*exportJob{}}
+	go func() {
+		ticker := time.NewTicker(max(min(cfg.Retention.Duration/2, time.Minute), time.Second))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				j.expire(now)
+			}
+		}
+	}()
+	return j, nil
+}
+
+func newExportJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// submit starts fetching flows of the pager in background
+func (j *ExportJobs) submit(owner string, pager *flowPager) (model.ExportJob, int, error) {
+	j.mu.Lock()
+	full := j.cfg.MaxTotalSize > 0 && j.size >= j.cfg.MaxTotalSize
+	j.mu.Unlock()
+	if full {
+		return model.ExportJob{}, http.StatusServiceUnavailable, errors.New("export jobs storage is full: download or delete previous jobs, or retry later")
+	}
+	id, err := newExportJobID()
+	if err != nil {
+		return model.ExportJob{}, http.StatusInternalServerError, err
+	}
+	path := filepath.Join(j.cfg.Dir, id+exportJobSpoolExt)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return model.ExportJob{}, http.StatusInternalServerError, fmt.Errorf("cannot create export job result: %w", err)
+	}
+	ctx, cancel := context.WithCancel(j.ctx)
+	job := &exportJob{
+		status: model.ExportJob{
+			ID:        id,
+			State:     model.ExportJobRunning,
+			MaxRows:   pager.maxRows,
+			CreatedAt: time.Now(),
+		},
+		owner:  owner,
+		path:   path,
+		cancel: cancel,
+	}
+	j.mu.Lock()
+	j.jobs[id] = job
+	status := job.status
+	j.mu.Unlock()
+
+	go j.run(ctx, job, pager, f)
+	return status, http.StatusAccepted, nil
+}
+
+func (j *ExportJobs) run(ctx context.Context, job *exportJob, pager *flowPager, f *os.File) {
+	out := bufio.NewWriter(&spoolWriter{jobs: j, job: job, f: f})
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	var err error
+	for {
+		var page *model.AggregatedQueryResponse
+		if page, _, err = pager.next(ctx); err != nil || page == nil {
+			break
+		}
+		for i := range page.Result.(model.Streams) {
+			if err = encoder.Encode(&page.Result.(model.Streams)[i]); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+		j.mu.Lock()
+		job.status.Rows = pager.rows
+		job.status.Pages++
+		j.mu.Unlock()
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	j.complete(job, pager, err)
+}
+
+func (j *ExportJobs) complete(job *exportJob, pager *flowPager, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job.cancel()
+	now := time.Now()
+	expires := now.Add(j.cfg.Retention.Duration)
+	job.status.Rows = pager.rows
+	job.status.Truncated = pager.truncated
+	job.status.CompletedAt = &now
+	job.status.ExpiresAt = &expires
+	switch {
+	case err == nil:
+		job.status.State = model.ExportJobDone
+	case errors.Is(err, context.Canceled):
+		job.status.State = model.ExportJobCanceled
+	default:
+		hlog.Errorf("Export job %s failed: %v", job.status.ID, err)
+		job.status.State = model.ExportJobFailed
+		job.status.Error = err.Error()
+	}
+	if job.deleted || job.status.State != model.ExportJobDone {
+		// incomplete results can't be downloaded
+		j.removeLocked(job)
+	}
+}
+
+// removeLocked deletes the result of a job that is no longer running
+func (j *ExportJobs) removeLocked(job *exportJob) {
+	if job.removed {
+		return
+	}
+	job.removed = true
+	if err := os.Remove(job.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		hlog.Warnf("Cannot remove export job result %s: %v", job.path, err)
+	}
+	j.size -= job.status.Bytes
+}
+
+// expire deletes jobs completed for longer than the retention
+func (j *ExportJobs) expire(now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for id, job := range j.jobs {
+		if job.status.ExpiresAt != nil && now.After(*job.status.ExpiresAt) {
+			delete(j.jobs, id)
+			j.removeLocked(job)
+		}
+	}
+}
+
+// get returns the status and result path of a job, when owned by the given user
+func (j *ExportJobs) get(owner, id string) (model.ExportJob, string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok || job.owner != owner {
+		return model.ExportJob{}, "", false
+	}
+	return job.status, job.path, true
+}
+
+// list returns the jobs of a user, most recent first
+func (j *ExportJobs) list(owner string) []model.ExportJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jobs := []model.ExportJob{}
+	for _, job := range j.jobs {
+		if job.owner == owner {
+			jobs = append(jobs, job.status)
+		}
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].CreatedAt.After(jobs[b].CreatedAt) })
+	return jobs
+}
+
+// delete cancels a job if it's running, and deletes its result
+func (j *ExportJobs) delete(owner, id string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok || job.owner != owner {
+		return false
+	}
+	delete(j.jobs, id)
+	if job.status.State == model.ExportJobRunning {
+		// the result is removed once the export stops
+		job.deleted = true
+		job.cancel()
+	} else {
+		j.removeLocked(job)
+	}
+	return true
+}
+
+// spoolWriter writes a job result, enforcing size limits
+type spoolWriter struct {
+	jobs *ExportJobs
+	job  *exportJob
+	f    io.Writer
+}
+
+func (s *spoolWriter) Write(p []byte) (int, error) {
+	j := s.jobs
+	j.mu.Lock()
+	if j.cfg.MaxSize > 0 && s.job.status.Bytes+int64(len(p)) > j.cfg.MaxSize {
+		j.mu.Unlock()
+		return 0, fmt.Errorf("result exceeds the maximum size of %d bytes: add filters, select a shorter time range or lower maxRows", j.cfg.MaxSize)
+	}
+	if j.cfg.MaxTotalSize > 0 && j.size+int64(len(p)) > j.cfg.MaxTotalSize {
+		j.mu.Unlock()
+		return 0, errors.New("export jobs storage is full")
+	}
+	j.mu.Unlock()
+	n, err := s.f.Write(p)
+	j.mu.Lock()
+	s.job.status.Bytes += int64(n)
+	j.size += int64(n)
+	j.mu.Unlock()
+	return n, err
+}
+
+// exportJobOwner identifies the user submitting or accessing jobs: by name, or by token when authentication is disabled
+func (h *Handlers) exportJobOwner(ctx context.Context, header http.Header) (string, error) {
+	username, err := h.AuthChecker.GetUsername(ctx, header)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		return "user:" + username, nil
+	}
+	sum := sha256.Sum256([]byte(header.Get(auth.AuthHeader)))
+	return "token:" + hex.EncodeToString(sum[:]), nil
+}
+
+func (h *Handlers) SubmitExportJob(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.Cfg.IsLokiEnabled() {
+			err := apierrors.NewLokiDisabledError("cannot perform flows query with disabled Loki")
+			err.Write(w, http.StatusBadRequest)
+			return
+		}
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("SubmitExportJob", code, startTime)
+		}()
+
+		owner, err := h.exportJobOwner(ctx, r.Header)
+		if err != nil {
+			code = http.StatusUnauthorized
+			apierrors.Write(w, code, err)
+			return
+		}
+		params := r.URL.Query()
+		hlog.Debugf("SubmitExportJob query params: %s", params)
+		tenants, err := getTenants(&h.Cfg.Loki, params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+		// the job outlives the request: keep a copy of its headers for Loki authentication
+		header := r.Header.Clone()
+		cl := newLokiQueryClients(&h.Cfg.Loki, header, false, tenants)
+		cl.withPools(&h.Cfg.Concurrency, header)
+		pager, code, err := h.newExportPager(&cl, params, h.isAdmin(ctx, header))
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		job, code, err := h.ExportJobs.submit(owner, pager)
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		writeJSON(w, code, job)
+	}
+}
+
+func (h *Handlers) GetExportJobs(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("GetExportJobs", code, startTime)
+		}()
+
+		owner, err := h.exportJobOwner(ctx, r.Header)
+		if err != nil {
+			code = http.StatusUnauthorized
+			apierrors.Write(w, code, err)
+			return
+		}
+		code = http.StatusOK
+		writeJSON(w, code, h.ExportJobs.list(owner))
+	}
+}
+
+// getExportJob returns the job of the request path, when owned by the requesting user. Jobs of other users are not found.
+func (h *Handlers) getExportJob(ctx context.Context, r *http.Request) (model.ExportJob, string, int, error) {
+	id := mux.Vars(r)[exportJobIDKey]
+	if !exportJobIDRegexp.MatchString(id) {
+		return model.ExportJob{}, "", http.StatusBadRequest, fmt.Errorf("invalid export job id: %s", id)
+	}
+	owner, err := h.exportJobOwner(ctx, r.Header)
+	if err != nil {
+		return model.ExportJob{}, "", http.StatusUnauthorized, err
+	}
+	job, path, ok := h.ExportJobs.get(owner, id)
+	if !ok {
+		return model.ExportJob{}, "", http.StatusNotFound, fmt.Errorf("export job %s not found", id)
+	}
+	return job, path, http.StatusOK, nil
+}
+
+func (h *Handlers) GetExportJob(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("GetExportJob", code, startTime)
+		}()
+
+		job, _, code, err := h.getExportJob(ctx, r)
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		writeJSON(w, code, job)
+	}
+}
+
+func (h *Handlers) DeleteExportJob(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("DeleteExportJob", code, startTime)
+		}()
+
+		id := mux.Vars(r)[exportJobIDKey]
+		owner, err := h.exportJobOwner(ctx, r.Header)
+		if err != nil {
+			code = http.StatusUnauthorized
+			apierrors.Write(w, code, err)
+			return
+		}
+		if !h.ExportJobs.delete(owner, id) {
+			code = http.StatusNotFound
+			apierrors.Write(w, code, fmt.Errorf("export job %s not found", id))
+			return
+		}
+		code = http.StatusNoContent
+		w.WriteHeader(code)
+	}
+}
+
+// DownloadExportJob writes the result of a completed job in the requested format
+func (h *Handlers) DownloadExportJob(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("DownloadExportJob", code, startTime)
+		}()
+
+		job, path, code, err := h.getExportJob(ctx, r)
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		if job.State != model.ExportJobDone {
+			code = http.StatusConflict
+			apierrors.Write(w, code, fmt.Errorf("export job %s is %s", job.ID, job.State))
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			code = http.StatusNotFound
+			apierrors.Write(w, code, fmt.Errorf("export job %s result not found", job.ID))
+			return
+		}
+		defer f.Close()
+
+		// large results take longer to send than the server write timeout
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		fw, code, err := newFlowsWriter(w, http.StatusOK, h.Cfg, getExportOptions(r.URL.Query()))
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		decoder := json.NewDecoder(bufio.NewReader(f))
+		for err == nil && decoder.More() {
+			var stream model.Stream
+			if err = decoder.Decode(&stream); err == nil {
+				err = fw.writeFlows(&model.AggregatedQueryResponse{ResultType: model.ResultTypeStream, Result: model.Streams{stream}})
+			}
+		}
+		if err == nil {
+			err = fw.close()
+		}
+		if err != nil {
+			// headers are already sent: the truncated output tells the client that the download failed
+			hlog.Errorf("Error while downloading export job %s: %v", job.ID, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportJobsHandlers(t *testing.T, cfg config.ExportJobs) *Handlers {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if cfg.Dir == "" {
+		cfg.Dir = t.TempDir()
+	}
+	cfg.Retention = config.Duration{Duration: time.Hour}
+	h := pagedHandlers()
+	h.Cfg.Export.Jobs = cfg
+	h.AuthChecker = &fakeChecker{username: "alice"}
+	jobs, err := NewExportJobs(ctx, &h.Cfg.Export.Jobs)
+	require.NoError(t, err)
+	h.ExportJobs = jobs
+	return h
+}
+
+func exportJobsRouter(h *Handlers) *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/jobs", h.SubmitExportJob(context.TODO())).Methods(http.MethodPost)
+	r.HandleFunc("/jobs", h.GetExportJobs(context.TODO())).Methods(http.MethodGet)
+	r.HandleFunc("/jobs/{id}", h.GetExportJob(context.TODO())).Methods(http.MethodGet)
+	r.HandleFunc("/jobs/{id}", h.DeleteExportJob(context.TODO())).Methods(http.MethodDelete)
+	r.HandleFunc("/jobs/{id}/download", h.DownloadExportJob(context.TODO())).Methods(http.MethodGet)
+	return r
+}
+
+// submitExportJob submits a job with the given flows, for the user set on the handlers
+func submitExportJob(t *testing.T, h *Handlers, flows []int64, maxRows int) model.ExportJob {
+	cl := clients{loki: &pagedLoki{flows: flows}}
+	params := url.Values{"startTime": {"1000000000"}, "endTime": {"1000000001"}, "limit": {"2"}}
+	if maxRows > 0 {
+		params.Set("maxRows", strconv.Itoa(maxRows))
+	}
+	pager, _, err := h.newExportPager(&cl, params, true)
+	require.NoError(t, err)
+	owner, err := h.exportJobOwner(context.TODO(), http.Header{})
+	require.NoError(t, err)
+	job, code, err := h.ExportJobs.submit(owner, pager)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, model.ExportJobRunning, job.State)
+	return job
+}
+
+func waitExportJob(t *testing.T, r http.Handler, id string) model.ExportJob {
+	var job model.ExportJob
+	require.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+id, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+		return job.State != model.ExportJobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestExportJob(t *testing.T) {
+	h := exportJobsHandlers(t, config.ExportJobs{MaxSize: 1 << 20})
+	r := exportJobsRouter(h)
+	submitted := submitExportJob(t, h, []int64{1e18 + 3, 1e18 + 2, 1e18 + 1}, 0)
+
+	job := waitExportJob(t, r, submitted.ID)
+	assert.Equal(t, model.ExportJobDone, job.State)
+	assert.Equal(t, 3, job.Rows)
+	assert.Equal(t, 100, job.MaxRows)
+	assert.Equal(t, 2, job.Pages)
+	assert.False(t, job.Truncated)
+	assert.Positive(t, job.Bytes)
+	assert.NotNil(t, job.ExpiresAt)
+
+	// download in several formats
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID+"/download?format=ndjson&columns=Id", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"Id\":0}\n{\"Id\":1}\n{\"Id\":2}\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID+"/download?format=csv&columns=Id", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Id\n0\n1\n2\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID+"/download?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// list
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	var jobs []model.ExportJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jobs))
+	require.Len(t, jobs, 1)
+	assert.Equal(t, job.ID, jobs[0].ID)
+
+	// delete
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/jobs/"+job.ID, nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	_, err := os.Stat(filepath.Join(h.Cfg.Export.Jobs.Dir, job.ID+exportJobSpoolExt))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Zero(t, h.ExportJobs.size)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestExportJob_Owner(t *testing.T) {
+	h := exportJobsHandlers(t, config.ExportJobs{})
+	r := exportJobsRouter(h)
+	job := submitExportJob(t, h, []int64{1e18 + 1}, 0)
+	waitExportJob(t, r, job.ID)
+
+	// other users can't see, download nor delete the job
+	h.AuthChecker = &fakeChecker{username: "bob"}
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID, nil),
+		httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID+"/download?format=csv", nil),
+		httptest.NewRequest(http.MethodDelete, "/jobs/"+job.ID, nil),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code, req.Method+" "+req.URL.Path)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	// without authentication, jobs are owned by token
+	h.AuthChecker = &fakeChecker{}
+	owner1, _ := h.exportJobOwner(context.TODO(), http.Header{"Authorization": {"Bearer a"}})
+	owner2, _ := h.exportJobOwner(context.TODO(), http.Header{"Authorization": {"Bearer b"}})
+	assert.NotEqual(t, owner1, owner2)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/not-an-id", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExportJob_Limits(t *testing.T) {
+	// result too large
+	h := exportJobsHandlers(t, config.ExportJobs{MaxSize: 100})
+	r := exportJobsRouter(h)
+	flows := make([]int64, 20)
+	for i := range flows {
+		flows[i] = 1e18 + int64(i)
+	}
+	job := waitExportJob(t, r, submitExportJob(t, h, flows, 0).ID)
+	assert.Equal(t, model.ExportJobFailed, job.State)
+	assert.Contains(t, job.Error, "result exceeds the maximum size of 100 bytes")
+	assert.Zero(t, h.ExportJobs.size)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID+"/download?format=csv", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// maximum number of rows
+	h = exportJobsHandlers(t, config.ExportJobs{})
+	r = exportJobsRouter(h)
+	job = waitExportJob(t, r, submitExportJob(t, h, flows, 5).ID)
+	assert.Equal(t, model.ExportJobDone, job.State)
+	assert.Equal(t, 5, job.Rows)
+	assert.True(t, job.Truncated)
+
+	// storage full
+	h = exportJobsHandlers(t, config.ExportJobs{MaxTotalSize: 10})
+	h.ExportJobs.size = 10
+	_, code, err := h.ExportJobs.submit("user:alice", &flowPager{})
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.ErrorContains(t, err, "export jobs storage is full")
+
+	// invalid params are rejected on submission
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs?maxRows=1000", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "maxRows of 1000 exceeds the maximum of 100")
+}
+
+func TestExportJobs_Expire(t *testing.T) {
+	dir := t.TempDir()
+	// results of a previous run are removed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old"+exportJobSpoolExt), []byte("{}"), 0o600))
+	h := exportJobsHandlers(t, config.ExportJobs{Dir: dir})
+	_, err := os.Stat(filepath.Join(dir, "old"+exportJobSpoolExt))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	r := exportJobsRouter(h)
+	job := waitExportJob(t, r, submitExportJob(t, h, []int64{1e18 + 1}, 0).ID)
+	h.ExportJobs.expire(time.Now())
+	assert.Len(t, h.ExportJobs.list("user:alice"), 1)
+	h.ExportJobs.expire(job.ExpiresAt.Add(time.Second))
+	assert.Empty(t, h.ExportJobs.list("user:alice"))
+	assert.Zero(t, h.ExportJobs.size)
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+)
+
+const (
+	// trailers of paged exports, telling how many flows were written and whether the maximum number of rows was reached
+	exportRowsTrailer      = "X-Export-Rows"
+	exportTruncatedTrailer = "X-Export-Truncated"
+)
+
+// flowPager walks the time range of flows queries backwards, newest flows first, by pages of at most pageSize flows.
+// Each page ends at the oldest timestamp of the previous one, inclusive, skipping the flows already returned with
+// this timestamp, so that no flow is lost nor duplicated when pages are cut between flows of a same timestamp.
+type flowPager struct {
+	cl       *clients
+	queries  *flowQueries
+	pageSize int
+	maxRows  int
+	rows     int
+	start    string
+	// end is the exclusive end of the next page
+	end time.Time
+	// seen are the flows already returned with the oldest timestamp, seenAt
+	seen      map[string]struct{}
+	seenAt    time.Time
+	done      bool
+	truncated bool
+}
+
+type pagedEntry struct {
+	labels map[string]string
+	stream string
+	entry  model.Entry
+	key    string
+}
+
+func newFlowPager(cl *clients, queries *flowQueries, pageSize, maxRows int) *flowPager {
+	start := queries.bounds.start
+	if start.IsZero() {
+		// pin Loki's default range, which would otherwise move with the end of pages
+		start = queries.bounds.end.Add(-lokiDefaultRange)
+	}
+	return &flowPager{
+		cl:       cl,
+		queries:  queries,
+		pageSize: pageSize,
+		maxRows:  maxRows,
+		start:    strconv.FormatInt(start.UnixNano(), 10),
+		end:      queries.bounds.end,
+		seen:     map[string]struct{}{},
+	}
+}
+
+// next fetches the next page of flows, or returns nil when every flow was returned or the maximum number of rows is reached
+func (p *flowPager) next(ctx context.Context) (*model.AggregatedQueryResponse, int, error) {
+	if p.done {
+		return nil, http.StatusOK, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, http.StatusRequestTimeout, err
+	}
+	size := min(p.pageSize, p.maxRows-p.rows)
+	// flows already seen may come back: fetch enough to get a full page without them,
+	// plus one to know whether more flows remain
+	limit := size + len(p.seen) + 1
+	end := strconv.FormatInt(p.end.UnixNano(), 10)
+	var queries []string
+	for _, qb := range p.queries.builders {
+		queries = append(queries, qb.WithPage(p.start, end, strconv.Itoa(limit)).Build())
+	}
+	qr, code, err := p.cl.fetchFlows(ctx, queries, limit, p.queries.isDev)
+	if err != nil {
+		return nil, code, err
+	}
+
+	var entries []pagedEntry
+	for _, s := range qr.Result.(model.Streams) {
+		labels := labelsKey(s.Labels)
+		for _, e := range s.Entries {
+			entries = append(entries, pagedEntry{
+				labels: s.Labels,
+				stream: labels,
+				entry:  e,
+				key:    labels + strconv.FormatInt(e.Timestamp.UnixNano(), 10) + e.Line,
+			})
+		}
+	}
+	// when queries return less than the limit, there are no more flows in the time range
+	exhausted := len(entries) < limit
+	entries = slices.DeleteFunc(entries, func(e pagedEntry) bool {
+		_, ok := p.seen[e.key]
+		return ok
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].entry.Timestamp.After(entries[j].entry.Timestamp)
+	})
+	if len(entries) > size {
+		entries = entries[:size]
+		exhausted = false
+	}
+	if len(entries) == 0 {
+		p.done = true
+		return nil, http.StatusOK, nil
+	}
+
+	oldest := entries[len(entries)-1].entry.Timestamp
+	if !oldest.Equal(p.seenAt) {
+		p.seen = map[string]struct{}{}
+		p.seenAt = oldest
+	}
+	for i := range entries {
+		if entries[i].entry.Timestamp.Equal(oldest) {
+			p.seen[entries[i].key] = struct{}{}
+		}
+	}
+	p.end = oldest.Add(time.Nanosecond)
+	p.rows += len(entries)
+	if exhausted {
+		p.done = true
+	} else if p.rows >= p.maxRows {
+		p.done = true
+		p.truncated = true
+	}
+
+	// keep flows in time order, splitting streams when needed
+	var streams model.Streams
+	for i := range entries {
+		e := &entries[i]
+		if i > 0 && entries[i-1].stream == e.stream {
+			last := len(streams) - 1
+			streams[last].Entries = append(streams[last].Entries, e.entry)
+			continue
+		}
+		streams = append(streams, model.Stream{Labels: e.labels, Entries: []model.Entry{e.entry}})
+	}
+	return &model.AggregatedQueryResponse{
+		ResultType: model.ResultTypeStream,
+		Result:     streams,
+		Stats:      qr.Stats,
+	}, http.StatusOK, nil
+}
+
+// labelsKey identifies stream labels
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb := strings.Builder{}
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(labels[k])
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+// newExportPager checks paged export params and guardrails, and returns a pager for the requested flows
+func (h *Handlers) newExportPager(cl *clients, params url.Values, isAdmin bool) (*flowPager, int, error) {
+	fq, code, err := h.buildFlowQueries(cl, params)
+	if err != nil {
+		return nil, code, err
+	}
+	pageSize, maxRows, err := getExportPaging(&h.Cfg.Export, params, fq.bounds.limit)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	fq.bounds.limit = pageSize
+	if code, err := h.enforceGuardrails(cl.loki, isAdmin, &fq.bounds, fq.builders); err != nil {
+		return nil, code, err
+	}
+	return newFlowPager(cl, fq, pageSize, maxRows), http.StatusOK, nil
+}
+
+// exportPaged writes flows of the whole time range page by page, as they are fetched, up to a maximum number of rows.
+// It stops when the client disconnects.
+func (h *Handlers) exportPaged(ctx context.Context, w http.ResponseWriter, cl clients, params url.Values, isAdmin bool, opts *exportOptions) int {
+	pager, code, err := h.newExportPager(&cl, params, isAdmin)
+	if err != nil {
+		apierrors.Write(w, code, err)
+		return code
+	}
+
+	// fetch the first page before sending headers, so that query errors get a proper status
+	page, code, err := pager.next(ctx)
+	if err != nil {
+		apierrors.Write(w, code, err)
+		return code
+	}
+	w.Header().Set("Trailer", exportRowsTrailer+", "+exportTruncatedTrailer)
+	fw, code, err := newFlowsWriter(w, http.StatusOK, h.Cfg, opts)
+	if err != nil {
+		w.Header().Del("Trailer")
+		apierrors.Write(w, code, err)
+		return code
+	}
+	rc := http.NewResponseController(w)
+	for page != nil && err == nil {
+		// paging altogether takes longer than the server write timeout: give it to each page instead
+		_ = rc.SetWriteDeadline(time.Now().Add(h.Cfg.WriteTimeout()))
+		if err = fw.writeFlows(page); err == nil {
+			page, _, err = pager.next(ctx)
+		}
+	}
+	if err == nil {
+		err = fw.close()
+	}
+	if err != nil {
+		// headers are already sent: the truncated output tells the client that the export failed
+		hlog.Errorf("Error while exporting flows after %d rows: %v", pager.rows, err)
+		return code
+	}
+	if pager.truncated {
+		hlog.Infof("Paged export reached the maximum of %d rows", pager.maxRows)
+	}
+	w.Header().Set(exportRowsTrailer, strconv.Itoa(pager.rows))
+	w.Header().Set(exportTruncatedTrailer, strconv.FormatBool(pager.truncated))
+	return code
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pageParams = regexp.MustCompile(`&start=(\d+)&end=(\d+)&limit=(\d+)`)
+
+// pagedLoki returns the newest flows of the queried time range, up to the limit, as Loki does
+type pagedLoki struct {
+	// flows timestamps, in nanoseconds
+	flows   []int64
+	queries int
+	// delay slows down queries
+	delay time.Duration
+}
+
+func (l *pagedLoki) Get(u string) ([]byte, int, error) {
+	l.queries++
+	time.Sleep(l.delay)
+	m := pageParams.FindStringSubmatch(u)
+	if m == nil {
+		return nil, 400, fmt.Errorf("missing page params: %s", u)
+	}
+	start, _ := strconv.ParseInt(m[1], 10, 64)
+	end, _ := strconv.ParseInt(m[2], 10, 64)
+	limit, _ := strconv.Atoi(m[3])
+	flows := append([]int64{}, l.flows...)
+	sort.Slice(flows, func(i, j int) bool { return flows[i] > flows[j] })
+	var values []string
+	for i, ts := range flows {
+		if ts >= start && ts < end && len(values) < limit {
+			values = append(values, fmt.Sprintf(`["%d","{\"Id\":%d}"]`, ts, i))
+		}
+	}
+	return []byte(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"netobserv-flowcollector"},"values":[` +
+		strings.Join(values, ",") + `]}]}}`), 200, nil
+}
+
+func pagedHandlers() *Handlers {
+	return &Handlers{Cfg: &config.Config{
+		Loki:   config.Loki{URL: "http://loki"},
+		Export: config.Export{PageSize: 1000, MaxRows: 100},
+	}}
+}
+
+func collectPages(t *testing.T, p *flowPager) []string {
+	var ids []string
+	for {
+		page, _, err := p.next(context.Background())
+		require.NoError(t, err)
+		if page == nil {
+			return ids
+		}
+		for _, s := range page.Result.(model.Streams) {
+			for _, e := range s.Entries {
+				ids = append(ids, e.Line)
+			}
+		}
+	}
+}
+
+func TestFlowPager(t *testing.T) {
+	h := pagedHandlers()
+	// flows 2, 3 and 4 share the same timestamp, across pages
+	lk := &pagedLoki{flows: []int64{1e18 + 60, 1e18 + 50, 1e18 + 40, 1e18 + 40, 1e18 + 40, 1e18 + 30, 1e18 + 20}}
+	cl := clients{loki: lk}
+	fq, _, err := h.buildFlowQueries(&cl, url.Values{"startTime": {"1000000000"}, "endTime": {"1000000001"}})
+	require.NoError(t, err)
+
+	p := newFlowPager(&cl, fq, 2, 100)
+	ids := collectPages(t, p)
+	assert.Equal(t, []string{`{"Id":0}`, `{"Id":1}`, `{"Id":2}`, `{"Id":3}`, `{"Id":4}`, `{"Id":5}`, `{"Id":6}`}, ids)
+	assert.Equal(t, 7, p.rows)
+	assert.False(t, p.truncated)
+
+	// maximum number of rows
+	p = newFlowPager(&cl, fq, 2, 3)
+	ids = collectPages(t, p)
+	assert.Equal(t, []string{`{"Id":0}`, `{"Id":1}`, `{"Id":2}`}, ids)
+	assert.True(t, p.truncated)
+
+	// exactly the maximum number of rows: not truncated as every flow was returned
+	p = newFlowPager(&cl, fq, 10, 7)
+	ids = collectPages(t, p)
+	assert.Len(t, ids, 7)
+	assert.False(t, p.truncated)
+}
+
+func TestFlowPager_Canceled(t *testing.T) {
+	h := pagedHandlers()
+	lk := &pagedLoki{flows: []int64{1e18 + 2, 1e18 + 1}}
+	cl := clients{loki: lk}
+	fq, _, err := h.buildFlowQueries(&cl, url.Values{"startTime": {"1000000000"}, "endTime": {"1000000001"}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := newFlowPager(&cl, fq, 1, 100)
+	page, _, err := p.next(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, page)
+	cancel()
+	_, _, err = p.next(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, lk.queries)
+}
+
+func TestExportPaged(t *testing.T) {
+	h := pagedHandlers()
+	lk := &pagedLoki{flows: []int64{1e18 + 3, 1e18 + 2, 1e18 + 1}}
+	params := url.Values{"startTime": {"1000000000"}, "endTime": {"1000000001"}, "limit": {"2"}}
+
+	w := httptest.NewRecorder()
+	code := h.exportPaged(context.Background(), w, clients{loki: lk}, params, true, &exportOptions{format: exportNDJSONFormat, columns: []string{"Id"}})
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "{\"Id\":0}\n{\"Id\":1}\n{\"Id\":2}\n", w.Body.String())
+	assert.Equal(t, "3", w.Header().Get(exportRowsTrailer))
+	assert.Equal(t, "false", w.Header().Get(exportTruncatedTrailer))
+	assert.Equal(t, 2, lk.queries)
+
+	// maximum number of rows
+	params.Set("maxRows", "2")
+	w = httptest.NewRecorder()
+	h.exportPaged(context.Background(), w, clients{loki: &pagedLoki{flows: lk.flows}}, params, true, &exportOptions{format: exportNDJSONFormat, columns: []string{"Id"}})
+	assert.Equal(t, "{\"Id\":0}\n{\"Id\":1}\n", w.Body.String())
+	assert.Equal(t, "true", w.Header().Get(exportTruncatedTrailer))
+
+	// invalid requests
+	params.Set("maxRows", "1000")
+	w = httptest.NewRecorder()
+	code = h.exportPaged(context.Background(), w, clients{loki: lk}, params, true, &exportOptions{format: exportNDJSONFormat})
+	assert.Equal(t, 400, code)
+	assert.Contains(t, w.Body.String(), "maxRows of 1000 exceeds the maximum of 100")
+
+	params.Del("maxRows")
+	w = httptest.NewRecorder()
+	code = h.exportPaged(context.Background(), w, clients{loki: lk}, params, true, &exportOptions{format: "xml"})
+	assert.Equal(t, 400, code)
+	assert.Empty(t, w.Header().Get("Trailer"))
+}
+
+func TestExportPaged_LongerThanWriteTimeout(t *testing.T) {
+	h := pagedHandlers()
+	lk := &pagedLoki{flows: []int64{1e18 + 3, 1e18 + 2, 1e18 + 1}, delay: 100 * time.Millisecond}
+	params := url.Values{"startTime": {"1000000000"}, "endTime": {"1000000001"}, "limit": {"1"}}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.exportPaged(r.Context(), w, clients{loki: lk}, params, true, &exportOptions{format: exportNDJSONFormat, columns: []string{"Id"}})
+	}))
+	// pages take longer altogether
+	srv.Config.WriteTimeout = 250 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"Id\":0}\n{\"Id\":1}\n{\"Id\":2}\n", string(body))
+	assert.Equal(t, "3", resp.Trailer.Get(exportRowsTrailer))
+	assert.Equal(t, "false", resp.Trailer.Get(exportTruncatedTrailer))
+}
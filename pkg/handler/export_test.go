@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportedFlows() *model.AggregatedQueryResponse {
+	return &model.AggregatedQueryResponse{
+		Result: model.Streams{{
+			Labels: map[string]string{"SrcK8S_Namespace": "ns1"},
+			Entries: []model.Entry{
+				{Timestamp: time.Now(), Line: `{"Bytes":100,"SrcAddr":"10.0.0.1"}`},
+				{Timestamp: time.Now(), Line: `{"Bytes":200,"SrcAddr":"10.0.0.2"}`},
+			},
+		}},
+	}
+}
+
+func TestWriteRecords_NDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	_, err := writeFlows(w, 200, exportedFlows(), &config.Config{}, &exportOptions{format: exportNDJSONFormat, columns: []string{"Bytes", "SrcK8S_Namespace"}})
+	require.NoError(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".ndjson")
+	assert.Equal(t, `{"Bytes":100,"SrcK8S_Namespace":"ns1"}
+{"Bytes":200,"SrcK8S_Namespace":"ns1"}
+`, w.Body.String())
+}
+
+func TestWriteRecords_JSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	_, err := writeFlows(w, 200, exportedFlows(), &config.Config{}, &exportOptions{format: exportJSONFormat, columns: []string{"Bytes"}})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"Bytes":100},{"Bytes":200}]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	_, err = writeFlows(w, 200, &model.AggregatedQueryResponse{Result: model.Streams{}}, &config.Config{}, &exportOptions{format: exportJSONFormat})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+func TestWriteParquet(t *testing.T) {
+	cfg := &config.Config{Frontend: config.Frontend{Fields: []config.FieldConfig{
+		{Name: "Bytes", Type: "number"},
+		{Name: "SrcAddr", Type: "string"},
+	}}}
+	w := httptest.NewRecorder()
+	_, err := writeFlows(w, 200, exportedFlows(), cfg, &exportOptions{format: exportParquetFormat})
+	require.NoError(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/vnd.apache.parquet", w.Header().Get("Content-Type"))
+	body := w.Body.Bytes()
+	assert.Equal(t, "PAR1", string(body[:4]))
+	assert.Equal(t, "PAR1", string(body[len(body)-4:]))
+
+	w = httptest.NewRecorder()
+	code, err := writeFlows(w, 200, exportedFlows(), &config.Config{}, &exportOptions{format: exportParquetFormat})
+	assert.Equal(t, 400, code)
+	assert.EqualError(t, err, "no columns to export: fields must be configured")
+}
+
+func TestWriteZeek(t *testing.T) {
+	w := httptest.NewRecorder()
+	_, err := writeFlows(w, 200, exportedFlows(), &config.Config{}, &exportOptions{format: exportZeekFormat})
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".log")
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	require.Len(t, lines, 11)
+	assert.Equal(t, "#path\tconn", lines[4])
+	assert.Contains(t, lines[8], "\t10.0.0.1\t")
+	assert.Contains(t, lines[9], "\t10.0.0.2\t")
+	assert.True(t, strings.HasPrefix(lines[10], "#close\t"))
+
+	// an empty export is still a valid log
+	w = httptest.NewRecorder()
+	_, err = writeFlows(w, 200, &model.AggregatedQueryResponse{Result: model.Streams{}}, &config.Config{}, &exportOptions{format: exportZeekFormat})
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n"), 9)
+}
+
+func TestWriteHubble(t *testing.T) {
+	w := httptest.NewRecorder()
+	// columns don't apply to Hubble flows
+	_, err := writeFlows(w, 200, exportedFlows(), &config.Config{}, &exportOptions{format: exportHubbleFormat, columns: []string{"Bytes"}})
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"flow":{"verdict":"FORWARDED","IP":{"source":"10.0.0.1","ipVersion":"IPv4"},"source":{"namespace":"ns1"},"Type":"L3_L4","event_type":{"type":4}}}
+{"flow":{"verdict":"FORWARDED","IP":{"source":"10.0.0.2","ipVersion":"IPv4"},"source":{"namespace":"ns1"},"Type":"L3_L4","event_type":{"type":4}}}
+`, w.Body.String())
+}
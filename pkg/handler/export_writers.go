@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	csvdata "github.com/netobserv/network-observability-console-plugin/pkg/handler/csv"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/records"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/parquet"
+)
+
+// exportOptions define what is exported, and how
+type exportOptions struct {
+	format  string
+	columns []string
+	pretty  bool
+}
+
+// flowsWriter writes exported flows, possibly from several query responses. Once it is created, response
+// headers are sent: errors can only truncate the output.
+type flowsWriter interface {
+	writeFlows(qr *model.AggregatedQueryResponse) error
+	// close completes the output; it doesn't close the underlying writer
+	close() error
+}
+
+// newFlowsWriter checks export options, then sends response headers and returns a writer for the format
+func newFlowsWriter(w http.ResponseWriter, code int, cfg *config.Config, opts *exportOptions) (flowsWriter, int, error) {
+	var fw flowsWriter
+	var ext, contentType string
+	switch opts.format {
+	case exportCSVFormat:
+		ext, contentType = "csv", "text/csv"
+		fw = &csvFlowsWriter{
+			table: csvdata.NewTable(opts.columns, &csvdata.Options{
+				Columns:    cfg.Frontend.Columns,
+				Fields:     cfg.Frontend.Fields,
+				FieldsType: cfg.Loki.FieldsType,
+				PortNaming: cfg.Frontend.PortNaming,
+				Pretty:     opts.pretty,
+			}),
+			writer: csv.NewWriter(w),
+		}
+	case exportJSONFormat, exportNDJSONFormat, exportHubbleFormat:
+		ext, contentType = "json", "application/json"
+		ndjson := opts.format != exportJSONFormat
+		if ndjson {
+			ext, contentType = "ndjson", "application/x-ndjson"
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		rw := &recordsFlowsWriter{
+			w:          w,
+			encoder:    encoder,
+			fieldsType: cfg.Loki.FieldsType,
+			columns:    opts.columns,
+			ndjson:     ndjson,
+		}
+		if opts.format == exportHubbleFormat {
+			// Hubble flows have their own schema: every field is needed
+			rw.columns = nil
+			rw.mapRecord = func(r records.Record) any { return records.ToHubble(r) }
+		}
+		fw = rw
+	case exportParquetFormat:
+		cols := records.ParquetColumns(cfg.Frontend.Fields, cfg.Loki.Labels, opts.columns)
+		if len(cols) == 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("no columns to export: fields must be configured")
+		}
+		ext, contentType = "parquet", "application/vnd.apache.parquet"
+		fw = &parquetFlowsWriter{
+			cols:       cols,
+			writer:     parquet.NewWriter(w, cols, exportRowGroupSize),
+			fieldsType: cfg.Loki.FieldsType,
+			columns:    opts.columns,
+		}
+	case exportZeekFormat:
+		ext, contentType = "log", "text/plain"
+		fw = &zeekFlowsWriter{w: w, fieldsType: cfg.Loki.FieldsType}
+	default:
+		return nil, http.StatusBadRequest, fmt.Errorf("export format %q is not valid", opts.format)
+	}
+
+	t := time.Now()
+	// output file would be 'export-stdLongYear-stdZeroMonth-stdZeroDay-stdHour-stdZeroMinute.ext'
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=export-%s.%s", t.Format("2006-01-02-15-04"), ext))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(code)
+	return &flushingWriter{flowsWriter: fw, w: w}, code, nil
+}
+
+// writeFlows exports the flows of a single query response
+func writeFlows(w http.ResponseWriter, code int, qr *model.AggregatedQueryResponse, cfg *config.Config, opts *exportOptions) (int, error) {
+	// check the response type before sending headers
+	if _, ok := qr.Result.(model.Streams); !ok {
+		return http.StatusInternalServerError, fmt.Errorf("loki returned an unexpected type: %T", qr.Result)
+	}
+	fw, code, err := newFlowsWriter(w, code, cfg, opts)
+	if err != nil {
+		return code, err
+	}
+	err = fw.writeFlows(qr)
+	if err == nil {
+		err = fw.close()
+	}
+	if err != nil {
+		// headers are already sent: the truncated output tells the client that the export failed
+		hlog.Errorf("Error while exporting flows: %v", err)
+	}
+	return code, nil
+}
+
+// flushingWriter flushes the response after each query response, so that large exports are sent as they are written
+type flushingWriter struct {
+	flowsWriter
+	w io.Writer
+}
+
+func (f *flushingWriter) writeFlows(qr *model.AggregatedQueryResponse) error {
+	if err := f.flowsWriter.writeFlows(qr); err != nil {
+		return err
+	}
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+type csvFlowsWriter struct {
+	table  *csvdata.Table
+	writer *csv.Writer
+}
+
+func (c *csvFlowsWriter) writeFlows(qr *model.AggregatedQueryResponse) error {
+	if err := c.table.Append(qr, c.writer.Write); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvFlowsWriter) close() error {
+	if err := c.table.Close(c.writer.Write); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// recordsFlowsWriter writes flows as JSON records, either as an array (json) or one per line (ndjson)
+type recordsFlowsWriter struct {
+	w          io.Writer
+	encoder    *json.Encoder
+	fieldsType map[string]string
+	columns    []string
+	ndjson     bool
+	// mapRecord optionally converts records before they are written
+	mapRecord func(records.Record) any
+	count     int
+}
+
+func (r *recordsFlowsWriter) write(s string) error {
+	_, err := io.WriteString(r.w, s)
+	return err
+}
+
+func (r *recordsFlowsWriter) writeFlows(qr *model.AggregatedQueryResponse) error {
+	flusher, _ := r.w.(http.Flusher)
+	return records.ForEach(qr, r.fieldsType, r.columns, func(rec records.Record) error {
+		if !r.ndjson {
+			sep := ","
+			if r.count == 0 {
+				sep = "["
+			}
+			if err := r.write(sep); err != nil {
+				return err
+			}
+		}
+		r.count++
+		var v any = rec
+		if r.mapRecord != nil {
+			v = r.mapRecord(rec)
+		}
+		if err := r.encoder.Encode(v); err != nil {
+			return err
+		}
+		if flusher != nil && r.count%exportFlushEvery == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+func (r *recordsFlowsWriter) close() error {
+	if r.ndjson {
+		return nil
+	}
+	if r.count == 0 {
+		return r.write("[]\n")
+	}
+	return r.write("]\n")
+}
+
+// parquetFlowsWriter writes flows as a Parquet file, row group by row group, with a schema derived from the configured fields
+type parquetFlowsWriter struct {
+	cols       []parquet.Column
+	writer     *parquet.Writer
+	fieldsType map[string]string
+	columns    []string
+}
+
+func (p *parquetFlowsWriter) writeFlows(qr *model.AggregatedQueryResponse) error {
+	return records.ForEach(qr, p.fieldsType, p.columns, func(r records.Record) error {
+		row, err := records.ParquetRow(p.cols, r)
+		if err != nil {
+			return err
+		}
+		return p.writer.Write(row)
+	})
+}
+
+func (p *parquetFlowsWriter) close() error {
+	return p.writer.Close()
+}
+
+// zeekFlowsWriter writes flows as a Zeek conn.log, for tools ingesting Zeek logs
+type zeekFlowsWriter struct {
+	w          io.Writer
+	fieldsType map[string]string
+	header     bool
+}
+
+func (z *zeekFlowsWriter) writeHeader() error {
+	if z.header {
+		return nil
+	}
+	z.header = true
+	_, err := io.WriteString(z.w, records.ZeekConnHeader(time.Now()))
+	return err
+}
+
+func (z *zeekFlowsWriter) writeFlows(qr *model.AggregatedQueryResponse) error {
+	if err := z.writeHeader(); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(z.w)
+	err := records.ForEach(qr, z.fieldsType, nil, func(r records.Record) error {
+		_, err := bw.WriteString(strings.Join(records.ZeekConnRow(r), "\t") + "\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (z *zeekFlowsWriter) close() error {
+	if err := z.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(z.w, records.ZeekConnFooter(time.Now()))
+	return err
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
-	"github.com/netobserv/network-observability-console-plugin/pkg/httpclient"
 	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
 	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
 	"github.com/netobserv/network-observability-console-plugin/pkg/model"
@@ -26,6 +25,7 @@ const (
 	filtersKey    = "filters"
 	packetLossKey = "packetLoss"
 	namespaceKey  = "namespace"
+	tenantsKey    = "tenants"
 )
 
 func (h *Handlers) GetFlows(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
@@ -36,7 +36,6 @@ func (h *Handlers) GetFlows(ctx context.Context) func(w http.ResponseWriter, r *
 			return
 		}
 
-		cl := newLokiClient(&h.Cfg.Loki, r.Header, false)
 		var code int
 		startTime := time.Now()
 		defer func() {
@@ -46,7 +45,15 @@ func (h *Handlers) GetFlows(ctx context.Context) func(w http.ResponseWriter, r *
 		params := r.URL.Query()
 		hlog.Debugf("GetFlows query params: %s", params)
 
-		flows, code, err := h.getFlows(ctx, cl, params)
+		tenants, err := getTenants(&h.Cfg.Loki, params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+		cl := newLokiQueryClients(&h.Cfg.Loki, r.Header, false, tenants)
+		cl.withPools(&h.Cfg.Concurrency, r.Header)
+		flows, code, err := h.getFlows(r.Context(), cl, params, h.isAdmin(ctx, r.Header))
 		if err != nil {
 			apierrors.Write(w, code, err)
 			return
@@ -57,12 +64,40 @@ func (h *Handlers) GetFlows(ctx context.Context) func(w http.ResponseWriter, r *
 	}
 }
 
-func (h *Handlers) getFlows(ctx context.Context, lokiClient httpclient.Caller, params url.Values) (*model.AggregatedQueryResponse, int, error) {
-	start, _, err := getStartTime(params)
+func (h *Handlers) getFlows(ctx context.Context, cl clients, params url.Values, isAdmin bool) (*model.AggregatedQueryResponse, int, error) {
+	fq, code, err := h.buildFlowQueries(&cl, params)
+	if err != nil {
+		return nil, code, err
+	}
+	if code, err := h.enforceGuardrails(cl.loki, isAdmin, &fq.bounds, fq.builders); err != nil {
+		return nil, code, err
+	}
+	var queries []string
+	for _, qb := range fq.builders {
+		queries = append(queries, qb.Build())
+	}
+	qr, code, err := cl.fetchFlows(ctx, queries, fq.bounds.limit, fq.isDev)
+	if err != nil {
+		return nil, code, err
+	}
+	hlog.Tracef("GetFlows response: %v", qr)
+	return qr, http.StatusOK, nil
+}
+
+// flowQueries are the queries of a flows request, one per filter group
+type flowQueries struct {
+	builders []*loki.FlowQueryBuilder
+	bounds   queryBounds
+	isDev    bool
+}
+
+// buildFlowQueries parses flows request params. Clients are restricted to the requested namespace when relevant.
+func (h *Handlers) buildFlowQueries(cl *clients, params url.Values) (*flowQueries, int, error) {
+	start, sTime, err := getStartTime(params)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
-	end, _, err := getEndTime(params)
+	end, eTime, err := getEndTime(params)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
@@ -78,15 +113,20 @@ func (h *Handlers) getFlows(ctx context.Context, lokiClient httpclient.Caller, p
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
-	namespace := params.Get(namespaceKey)
+	namespace, err := getNamespace(params)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 	isDev := namespace != ""
 	rawFilters := params.Get(filtersKey)
 	filterGroups, err := filters.Parse(rawFilters)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
-	if namespace != "" {
-		// TODO: this should actually be managed from the loki gateway, with "namespace" query param
+	if h.Cfg.Loki.UseNamespaceParam(namespace) {
+		cl.withLokiNamespace(&h.Cfg.Loki, namespace)
+	} else if namespace != "" {
+		// without gateway, restrict to the namespace on both source and destination sides
 		filterGroups = filterGroups.Distribute(
 			[]filters.SingleQuery{
 				{filters.NewEqualMatch(fields.SrcNamespace, namespace)},
@@ -96,40 +136,39 @@ func (h *Handlers) getFlows(ctx context.Context, lokiClient httpclient.Caller, p
 		)
 	}
 
-	cl := clients{loki: lokiClient}
+	var qbs []*loki.FlowQueryBuilder
+	for _, group := range filterGroups {
+		qb := loki.NewFlowQueryBuilder(&h.Cfg.Loki, start, end, limit, recordType, packetLoss)
+		if err := qb.Filters(group); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("can't build query: %w", err)
+		}
+		qbs = append(qbs, qb)
+	}
+	if len(qbs) == 0 {
+		qbs = append(qbs, loki.NewFlowQueryBuilder(&h.Cfg.Loki, start, end, limit, recordType, packetLoss))
+	}
+	return &flowQueries{
+		builders: qbs,
+		bounds:   queryBounds{start: sTime, end: eTime, limit: reqLimit, queries: len(qbs)},
+		isDev:    isDev,
+	}, http.StatusOK, nil
+}
+
+// fetchFlows runs flows queries and merges their streams
+func (c *clients) fetchFlows(ctx context.Context, queries []string, reqLimit int, isDev bool) (*model.AggregatedQueryResponse, int, error) {
 	merger := loki.NewStreamMerger(reqLimit)
-	if len(filterGroups) > 1 {
+	if len(queries) > 1 {
 		// match any, and multiple filters => run in parallel then aggregate
-		var queries []string
-		for _, group := range filterGroups {
-			qb := loki.NewFlowQueryBuilder(&h.Cfg.Loki, start, end, limit, recordType, packetLoss)
-			err := qb.Filters(group)
-			if err != nil {
-				return nil, http.StatusBadRequest, fmt.Errorf("can't build query: %w", err)
-			}
-			queries = append(queries, qb.Build())
-		}
-		code, err := cl.fetchParallel(ctx, queries, nil, merger, isDev)
+		code, err := c.fetchParallel(ctx, queries, nil, merger, isDev)
 		if err != nil {
 			return nil, code, err
 		}
 	} else {
 		// else, run all at once
-		qb := loki.NewFlowQueryBuilder(&h.Cfg.Loki, start, end, limit, recordType, packetLoss)
-		if len(filterGroups) > 0 {
-			err := qb.Filters(filterGroups[0])
-			if err != nil {
-				return nil, http.StatusBadRequest, err
-			}
-		}
-		query := qb.Build()
-		code, err := cl.fetchSingle(ctx, query, nil, merger, isDev)
+		code, err := c.fetchSingle(ctx, queries[0], nil, merger, isDev)
 		if err != nil {
 			return nil, code, err
 		}
 	}
-
-	qr := merger.Get()
-	hlog.Tracef("GetFlows response: %v", qr)
-	return qr, http.StatusOK, nil
+	return merger.Get(), http.StatusOK, nil
 }
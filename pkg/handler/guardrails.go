@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/httpclient"
+	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
+
+	pmodel "github.com/prometheus/common/model"
+)
+
+const (
+	guardrailMaxRange   = "maxRange"
+	guardrailMinStep    = "minStep"
+	guardrailMaxLimit   = "maxLimit"
+	guardrailMaxQueries = "maxQueries"
+	guardrailMaxCost    = "maxCost"
+
+	// Loki queries the last hour when no start time is provided
+	lokiDefaultRange = time.Hour
+)
+
+// queryBounds describes what a request is about to query, to be checked against guardrails
+type queryBounds struct {
+	start   time.Time
+	end     time.Time
+	step    time.Duration // only set for range queries
+	limit   int
+	queries int
+}
+
+func (b *queryBounds) timeRange() time.Duration {
+	if b.start.IsZero() {
+		return lokiDefaultRange
+	}
+	return b.end.Sub(b.start)
+}
+
+func fmtDuration(d time.Duration) string {
+	return pmodel.Duration(d).String()
+}
+
+// checkGuardrails returns an error for the first limit exceeded by the query, with a hint explaining how to narrow it
+func checkGuardrails(limits *config.GuardrailLimits, b *queryBounds) *apierrors.GuardrailError {
+	r := b.timeRange()
+	if limits.MaxRange.Duration > 0 && r > limits.MaxRange.Duration {
+		return apierrors.NewGuardrailError(
+			guardrailMaxRange,
+			fmt.Sprintf("time range of %s exceeds the maximum of %s", fmtDuration(r), fmtDuration(limits.MaxRange.Duration)),
+			fmt.Sprintf("select a time range of at most %s", fmtDuration(limits.MaxRange.Duration)),
+		)
+	}
+	if b.step > 0 {
+		if minStep := limits.MinStepFor(r); b.step < minStep {
+			return apierrors.NewGuardrailError(
+				guardrailMinStep,
+				fmt.Sprintf("step of %s is below the minimum of %s for a time range of %s", fmtDuration(b.step), fmtDuration(minStep), fmtDuration(r)),
+				fmt.Sprintf("set a step of at least %s, or select a shorter time range", fmtDuration(minStep)),
+			)
+		}
+	}
+	if limits.MaxLimit > 0 {
+		if b.limit <= 0 {
+			return apierrors.NewGuardrailError(
+				guardrailMaxLimit,
+				"a limit is required",
+				fmt.Sprintf("set a limit of at most %d", limits.MaxLimit),
+			)
+		}
+		if b.limit > limits.MaxLimit {
+			return apierrors.NewGuardrailError(
+				guardrailMaxLimit,
+				fmt.Sprintf("limit of %d exceeds the maximum of %d", b.limit, limits.MaxLimit),
+				fmt.Sprintf("set a limit of at most %d", limits.MaxLimit),
+			)
+		}
+	}
+	if limits.MaxQueries > 0 && b.queries > limits.MaxQueries {
+		return apierrors.NewGuardrailError(
+			guardrailMaxQueries,
+			fmt.Sprintf("filters expand to %d queries, exceeding the maximum of %d", b.queries, limits.MaxQueries),
+			"use fewer alternative (OR) filter groups, or filter on a flow direction to avoid querying both reporters",
+		)
+	}
+	return nil
+}
+
+// checkCost estimates the cost of Loki queries from the number of streams matched by their stream selectors, as reported by the index,
+// multiplied by the time range in hours. It only calls Loki when a maximum cost is configured.
+func checkCost(lokiClient httpclient.Caller, limits *config.GuardrailLimits, b *queryBounds, queries []*loki.FlowQueryBuilder) (int, error) {
+	if limits.MaxCost <= 0 || len(queries) == 0 {
+		return http.StatusOK, nil
+	}
+	var streams int64
+	for _, q := range queries {
+		stats, code, err := getLokiIndexStats(lokiClient, q.BuildIndexStats())
+		if err != nil {
+			return code, err
+		}
+		streams += stats.Streams
+	}
+	cost := float64(streams) * b.timeRange().Hours()
+	hlog.Debugf("Estimated query cost: %.1f stream-hours (%d streams)", cost, streams)
+	if cost > limits.MaxCost {
+		return http.StatusBadRequest, apierrors.NewGuardrailError(
+			guardrailMaxCost,
+			fmt.Sprintf("estimated cost of %.0f stream-hours (%d streams over %s) exceeds the maximum of %.0f", cost, streams, fmtDuration(b.timeRange()), limits.MaxCost),
+			"select a shorter time range, or add filters on indexed labels such as namespaces or owners",
+		)
+	}
+	return http.StatusOK, nil
+}
+
+// isAdmin tells whether the user is a cluster admin, to pick the guardrails of their role
+func (h *Handlers) isAdmin(ctx context.Context, header http.Header) bool {
+	return h.AuthChecker.CheckAdmin(ctx, header) == nil
+}
+
+// enforceGuardrails checks the query against the guardrails of the user role: admin limits for cluster admins, dev limits for others
+func (h *Handlers) enforceGuardrails(lokiClient httpclient.Caller, isAdmin bool, b *queryBounds, lokiQueries []*loki.FlowQueryBuilder) (int, error) {
+	limits := h.Cfg.Guardrails.For(isAdmin)
+	if err := checkGuardrails(limits, b); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return checkCost(lokiClient, limits, b, lokiQueries)
+}
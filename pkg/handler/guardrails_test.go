@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexStatsLoki returns the given number of streams for index stats queries, and empty results otherwise
+type indexStatsLoki struct {
+	streams string
+	mu      sync.Mutex
+	urls    []string
+}
+
+func (l *indexStatsLoki) Get(url string) ([]byte, int, error) {
+	l.mu.Lock()
+	l.urls = append(l.urls, url)
+	l.mu.Unlock()
+	if strings.Contains(url, "/index/stats") {
+		return []byte(`{"streams":` + l.streams + `,"chunks":1,"entries":1,"bytes":1}`), 200, nil
+	}
+	return []byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`), 200, nil
+}
+
+func guardrailLimits() *config.GuardrailLimits {
+	return &config.GuardrailLimits{
+		MaxRange: config.Duration{Duration: 7 * 24 * time.Hour},
+		MinSteps: []config.MinStep{
+			{Range: config.Duration{Duration: 24 * time.Hour}, Step: config.Duration{Duration: 5 * time.Minute}},
+			{Range: config.Duration{Duration: 6 * time.Hour}, Step: config.Duration{Duration: time.Minute}},
+		},
+		MaxLimit:   100,
+		MaxQueries: 4,
+	}
+}
+
+func TestMinStepFor(t *testing.T) {
+	limits := guardrailLimits()
+	assert.Equal(t, time.Duration(0), limits.MinStepFor(time.Hour))
+	assert.Equal(t, time.Minute, limits.MinStepFor(12*time.Hour))
+	assert.Equal(t, 5*time.Minute, limits.MinStepFor(48*time.Hour))
+}
+
+func TestCheckGuardrails(t *testing.T) {
+	limits := guardrailLimits()
+	end := time.Unix(100000000, 0)
+	valid := queryBounds{start: end.Add(-time.Hour), end: end, step: 30 * time.Second, limit: 50, queries: 2}
+	assert.Nil(t, checkGuardrails(limits, &valid))
+
+	b := valid
+	b.start = end.Add(-30 * 24 * time.Hour)
+	err := checkGuardrails(limits, &b)
+	require.NotNil(t, err)
+	assert.Equal(t, guardrailMaxRange, err.Guardrail)
+	assert.Equal(t, "time range of 30d exceeds the maximum of 1w", err.Message)
+	assert.Equal(t, "select a time range of at most 1w", err.Hint)
+
+	b = valid
+	b.start = end.Add(-2 * 24 * time.Hour)
+	err = checkGuardrails(limits, &b)
+	require.NotNil(t, err)
+	assert.Equal(t, guardrailMinStep, err.Guardrail)
+	assert.Equal(t, "step of 30s is below the minimum of 5m for a time range of 2d", err.Message)
+
+	b = valid
+	b.limit = 0
+	err = checkGuardrails(limits, &b)
+	require.NotNil(t, err)
+	assert.Equal(t, guardrailMaxLimit, err.Guardrail)
+	assert.Equal(t, "a limit is required", err.Message)
+
+	b = valid
+	b.limit = 1000
+	err = checkGuardrails(limits, &b)
+	require.NotNil(t, err)
+	assert.Equal(t, guardrailMaxLimit, err.Guardrail)
+
+	b = valid
+	b.queries = 8
+	err = checkGuardrails(limits, &b)
+	require.NotNil(t, err)
+	assert.Equal(t, guardrailMaxQueries, err.Guardrail)
+
+	// No start time: Loki default range applies
+	b = valid
+	b.start = time.Time{}
+	assert.Nil(t, checkGuardrails(limits, &b))
+
+	// Unlimited
+	b.start = end.Add(-30 * 24 * time.Hour)
+	b.limit = 0
+	assert.Nil(t, checkGuardrails(&config.GuardrailLimits{}, &b))
+}
+
+func TestGetFlows_Guardrails(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{
+		Loki: config.Loki{URL: "http://loki", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace"}},
+		Guardrails: config.Guardrails{
+			Admin: config.GuardrailLimits{MaxCost: 100},
+			Dev:   config.GuardrailLimits{MaxRange: config.Duration{Duration: 6 * time.Hour}, MaxLimit: 100},
+		},
+	}}
+	params := url.Values{
+		"startTime": {"1000"},
+		"endTime":   {"36999"},
+		"limit":     {"50"},
+		"filters":   {"SrcK8S_Namespace=ns1|DstK8S_Namespace=ns1"},
+	}
+
+	// Admin: 2 queries x 20 streams x 10 hours
+	fake := &indexStatsLoki{streams: "20"}
+	_, code, err := h.getFlows(context.TODO(), clients{loki: fake}, params, true)
+	require.Error(t, err)
+	assert.Equal(t, 400, code)
+	var gerr *apierrors.GuardrailError
+	require.True(t, errors.As(err, &gerr))
+	assert.Equal(t, guardrailMaxCost, gerr.Guardrail)
+	assert.Equal(t, "estimated cost of 400 stream-hours (40 streams over 10h) exceeds the maximum of 100", gerr.Message)
+	// Flows were not queried
+	assert.Len(t, fake.urls, 2)
+
+	fake = &indexStatsLoki{streams: "2"}
+	_, code, err = h.getFlows(context.TODO(), clients{loki: fake}, params, true)
+	require.NoError(t, err)
+	assert.Equal(t, 200, code)
+	assert.Len(t, fake.urls, 4)
+
+	// Dev: cost is not checked, but the range is limited
+	fake = &indexStatsLoki{streams: "20"}
+	_, _, err = h.getFlows(context.TODO(), clients{loki: fake}, params, false)
+	require.True(t, errors.As(err, &gerr))
+	assert.Equal(t, guardrailMaxRange, gerr.Guardrail)
+	assert.Empty(t, fake.urls)
+
+	// The role doesn't depend on the namespace parameter
+	params.Set("namespace", "ns1")
+	fake = &indexStatsLoki{streams: "20"}
+	_, _, err = h.getFlows(context.TODO(), clients{loki: fake}, params, true)
+	require.True(t, errors.As(err, &gerr))
+	assert.Equal(t, guardrailMaxCost, gerr.Guardrail)
+}
+
+func TestGetTopology_Guardrails(t *testing.T) {
+	h := degradationHandlers()
+	h.Cfg.Guardrails.Admin = config.GuardrailLimits{
+		MinSteps: []config.MinStep{{Range: config.Duration{Duration: time.Hour}, Step: config.Duration{Duration: 5 * time.Minute}}},
+	}
+	params := url.Values{
+		"startTime":   {"1000"},
+		"endTime":     {"8200"},
+		"limit":       {"50"},
+		"step":        {"1m"},
+		"aggregateBy": {"namespace"},
+		"type":        {"Flows"},
+		"function":    {"count"},
+	}
+	fake := &limitedLoki{accept: func(string) bool { return true }}
+	_, code, err := h.getTopologyFlows(context.TODO(), clients{loki: fake}, params, constants.DataSourceLoki, true)
+	var gerr *apierrors.GuardrailError
+	require.True(t, errors.As(err, &gerr))
+	assert.Equal(t, 400, code)
+	assert.Equal(t, guardrailMinStep, gerr.Guardrail)
+	assert.Empty(t, fake.urls)
+
+	params.Set("step", "5m")
+	_, code, err = h.getTopologyFlows(context.TODO(), clients{loki: fake}, params, constants.DataSourceLoki, true)
+	require.NoError(t, err)
+	assert.Equal(t, 200, code)
+}
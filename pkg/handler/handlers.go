@@ -2,10 +2,14 @@ package handler
 
 import (
 	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/kubernetes/auth"
 	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
 )
 
 type Handlers struct {
 	Cfg           *config.Config
 	PromInventory *prometheus.Inventory
+	AuthChecker   auth.Checker
+	// ExportJobs is nil when export jobs are disabled
+	ExportJobs *ExportJobs
 }
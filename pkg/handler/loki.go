@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
+	"net/url"
 	"strings"
 	"time"
 
@@ -27,13 +29,19 @@ import (
 var hlog = logrus.WithField("module", "handler")
 
 const (
-	lokiOrgIDHeader = "X-Scope-OrgID"
+	lokiOrgIDHeader    = "X-Scope-OrgID"
+	lokiNamespaceParam = "namespace"
 )
 
 func newLokiClient(cfg *config.Loki, requestHeader http.Header, useStatusConfig bool) httpclient.Caller {
+	return newLokiTenantClient(cfg, requestHeader, useStatusConfig, cfg.GetTenantIDs())
+}
+
+// newLokiTenantClient creates a Loki client for the given tenants; when there are several, Loki must allow multi-tenant queries
+func newLokiTenantClient(cfg *config.Loki, requestHeader http.Header, useStatusConfig bool, tenants []string) httpclient.Caller {
 	headers := map[string][]string{}
-	if cfg.TenantID != "" {
-		headers[lokiOrgIDHeader] = []string{cfg.TenantID}
+	if len(tenants) > 0 {
+		headers[lokiOrgIDHeader] = []string{strings.Join(tenants, "|")}
 	}
 
 	if cfg.ForwardUserToken {
@@ -44,11 +52,11 @@ func newLokiClient(cfg *config.Loki, requestHeader http.Header, useStatusConfig
 			hlog.Debug("Missing Authorization token in user request")
 		}
 	} else if cfg.TokenPath != "" {
-		bytes, err := os.ReadFile(cfg.TokenPath)
+		token, err := httpclient.ReadTokenFile(cfg.TokenPath)
 		if err != nil {
 			hlog.WithError(err).Warnf("Failed to read authorization token from path '%s'. Continuing without token authentication. This may cause authentication failures if the Loki server requires authentication.", cfg.TokenPath)
 		} else {
-			headers[auth.AuthHeader] = []string{"Bearer " + string(bytes)}
+			headers[auth.AuthHeader] = []string{"Bearer " + token}
 		}
 	}
 
@@ -68,7 +76,44 @@ func newLokiClient(cfg *config.Loki, requestHeader http.Header, useStatusConfig
 		userKeyPath = cfg.StatusUserKeyPath
 	}
 
-	return httpclient.NewClientWrapper(cfg.Timeout.Duration, headers, skipTLS, caPath, userCertPath, userKeyPath)
+	return httpclient.NewClientWrapper(cfg.Timeout.Duration, headers, skipTLS, caPath, userCertPath, userKeyPath, cfg.MaxResponseSize)
+}
+
+// namespacedCaller restricts Loki queries to a namespace, enforced by the Loki gateway
+type namespacedCaller struct {
+	httpclient.Caller
+	namespace string
+}
+
+func (c *namespacedCaller) Get(url string) ([]byte, int, error) {
+	return c.Caller.Get(c.namespacedURL(url))
+}
+
+func (c *namespacedCaller) GetStream(url string) (io.ReadCloser, int, error) {
+	if sc, ok := c.Caller.(httpclient.StreamCaller); ok {
+		return sc.GetStream(c.namespacedURL(url))
+	}
+	resp, code, err := c.Caller.Get(c.namespacedURL(url))
+	if err != nil {
+		return nil, code, err
+	}
+	return io.NopCloser(bytes.NewReader(resp)), code, nil
+}
+
+func (c *namespacedCaller) namespacedURL(u string) string {
+	sep := "&"
+	if !strings.Contains(u, "?") {
+		sep = "?"
+	}
+	return u + sep + url.Values{lokiNamespaceParam: {c.namespace}}.Encode()
+}
+
+// withLokiNamespace wraps the Loki client so that it sends the namespace to the gateway, when configured so
+func withLokiNamespace(cfg *config.Loki, cl httpclient.Caller, namespace string) httpclient.Caller {
+	if cl == nil || !cfg.UseNamespaceParam(namespace) {
+		return cl
+	}
+	return &namespacedCaller{Caller: cl, namespace: namespace}
 }
 
 /* loki query will fail if spaces or quotes are not encoded
@@ -114,9 +159,68 @@ func fetchLogQL(logQL string, lokiClient httpclient.Caller) (model.QueryResponse
 		hlog.WithError(err).Errorf("cannot unmarshal, response was: %v", string(resp))
 		return qr, http.StatusInternalServerError, apierrors.NewLokiClientError(err)
 	}
+	observeLokiQueryStats(logQL, qr.Data.Stats)
 	return qr, code, nil
 }
 
+func observeLokiQueryStats(logQL string, stats *model.QueryStats) {
+	if stats != nil {
+		stats.QueryHash = model.HashQuery(logQL)
+		hlog.Debugf("Loki query %s stats: %+v", stats.QueryHash, stats.QueryCosts)
+		metrics.ObserveQueryStats(stats)
+	}
+}
+
+// streamLogQL runs a query and decodes its response incrementally, passing streams to onStream as they are decoded,
+// so that large responses are never fully buffered. The returned response holds everything but streams.
+func streamLogQL(logQL string, lokiClient httpclient.StreamCaller, onStream func(model.Stream) error) (*model.QueryResponse, int, apierrors.StructuredError) {
+	hlog.Debugf("streamLogQL URL: %s", logQL)
+	var code int
+	startTime := time.Now()
+	defer func() {
+		metrics.ObserveLokiCall(code, startTime)
+	}()
+
+	body, code, err := lokiClient.GetStream(logQL)
+	if err != nil {
+		return nil, http.StatusServiceUnavailable, apierrors.NewLokiClientError(err)
+	}
+	defer body.Close()
+	if code != http.StatusOK {
+		resp, err := io.ReadAll(body)
+		if err != nil {
+			newCode, err := lokiReadError(err)
+			return nil, newCode, err
+		}
+		newCode, lerr := getLokiError(resp, code)
+		hlog.Debugf("streamLogQL error: %s", lerr.Error())
+		return nil, newCode, lerr
+	}
+	qr, err := model.DecodeQueryResponse(body, onStream)
+	if err != nil {
+		var tooLarge *httpclient.ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			newCode, err := lokiReadError(err)
+			return nil, newCode, err
+		}
+		hlog.WithError(err).Errorf("cannot decode response of query %s", logQL)
+		return nil, http.StatusInternalServerError, apierrors.NewLokiClientError(err)
+	}
+	observeLokiQueryStats(logQL, qr.Data.Stats)
+	return qr, code, nil
+}
+
+// lokiReadError maps errors reading Loki responses, explaining how to avoid those exceeding the maximum size
+func lokiReadError(err error) (int, apierrors.StructuredError) {
+	var tooLarge *httpclient.ResponseTooLargeError
+	if errors.As(err, &tooLarge) {
+		return http.StatusBadRequest, apierrors.NewLokiClientError(
+			fmt.Errorf("%w: select a shorter time range, add filters or lower the limit", err),
+		)
+	}
+	return http.StatusServiceUnavailable, apierrors.NewLokiClientError(err)
+}
+
 func executeLokiQuery(flowsURL string, lokiClient httpclient.Caller) ([]byte, int, apierrors.StructuredError) {
 	hlog.Debugf("executeLokiQuery URL: %s", flowsURL)
 	var code int
@@ -127,7 +231,8 @@ func executeLokiQuery(flowsURL string, lokiClient httpclient.Caller) ([]byte, in
 
 	resp, code, err := lokiClient.Get(flowsURL)
 	if err != nil {
-		return nil, http.StatusServiceUnavailable, apierrors.NewLokiClientError(err)
+		newCode, err := lokiReadError(err)
+		return nil, newCode, err
 	}
 	if code != http.StatusOK {
 		newCode, err := getLokiError(resp, code)
@@ -160,6 +265,52 @@ func getLokiLabelValues(baseURL string, lokiClient httpclient.Caller, label stri
 	return lvr.Data, http.StatusOK, nil
 }
 
+// getLokiSeries returns the label sets of streams matching the selector within the time range
+func getLokiSeries(baseURL string, lokiClient httpclient.Caller, match, start, end string) ([]map[string]string, int, apierrors.StructuredError) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	url := fmt.Sprintf("%s/loki/api/v1/series?match[]=%s&start=%s&end=%s", baseURL, match, start, end)
+	resp, code, err := executeLokiQuery(EncodeQuery(url), lokiClient)
+	if err != nil {
+		return nil, code, err
+	}
+	hlog.Tracef("getLokiSeries raw response: %s", resp)
+	var sr model.SeriesResponse
+	if err := json.Unmarshal(resp, &sr); err != nil {
+		return nil, http.StatusInternalServerError, apierrors.NewLokiClientError(fmt.Errorf("unmarshal error while fetching series from Loki: %w", err))
+	}
+	return sr.Data, http.StatusOK, nil
+}
+
+// getLokiLabelNames returns the label names indexed in Loki within the time range
+func getLokiLabelNames(baseURL string, lokiClient httpclient.Caller, start, end string) ([]string, int, apierrors.StructuredError) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	url := fmt.Sprintf("%s/loki/api/v1/labels?start=%s&end=%s", baseURL, start, end)
+	resp, code, err := executeLokiQuery(url, lokiClient)
+	if err != nil {
+		return nil, code, err
+	}
+	hlog.Tracef("getLokiLabelNames raw response: %s", resp)
+	var lvr model.LabelValuesResponse
+	if err := json.Unmarshal(resp, &lvr); err != nil {
+		return nil, http.StatusInternalServerError, apierrors.NewLokiClientError(fmt.Errorf("unmarshal error while fetching label names from Loki: %w", err))
+	}
+	return lvr.Data, http.StatusOK, nil
+}
+
+// getLokiIndexStats returns the number of streams, chunks, entries and bytes matched by the stream selector of the query
+func getLokiIndexStats(lokiClient httpclient.Caller, query string) (*model.IndexStats, int, apierrors.StructuredError) {
+	resp, code, err := executeLokiQuery(EncodeQuery(query), lokiClient)
+	if err != nil {
+		return nil, code, err
+	}
+	hlog.Tracef("getLokiIndexStats raw response: %s", resp)
+	var stats model.IndexStats
+	if err := json.Unmarshal(resp, &stats); err != nil {
+		return nil, http.StatusInternalServerError, apierrors.NewLokiClientError(fmt.Errorf("unmarshal error while fetching index stats from Loki: %w", err))
+	}
+	return &stats, http.StatusOK, nil
+}
+
 func getLokiNamesForPrefix(cfg *config.Loki, lokiClient httpclient.Caller, filts filters.SingleQuery, searchField string) ([]string, int, apierrors.StructuredError) {
 	queryBuilder := loki.NewFlowQueryBuilderWithDefaults(cfg)
 	if err := queryBuilder.Filters(filts); err != nil {
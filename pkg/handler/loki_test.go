@@ -1,13 +1,23 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/httpclient"
 	"github.com/netobserv/network-observability-console-plugin/pkg/httpclient/httpclienttest"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 )
 
 func TestFetchLimits(t *testing.T) {
@@ -46,3 +56,185 @@ func TestFetchMaxChunkAge_Absent(t *testing.T) {
 	// Default value
 	assert.Equal(t, 2*time.Hour, mca)
 }
+
+func TestGetFlows_NamespaceParam(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{
+		Loki: config.Loki{URL: "http://loki", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace"}},
+	}}
+	params := url.Values{"namespace": {"ns1"}, "limit": {"50"}}
+
+	// Plain Loki: queries are expanded on source and destination namespaces
+	fake := &indexStatsLoki{}
+	_, _, err := h.getFlows(context.TODO(), clients{loki: fake}, params, false)
+	require.NoError(t, err)
+	require.Len(t, fake.urls, 2)
+	assert.NotContains(t, fake.urls[0], "namespace=ns1")
+
+	// Gateway: a single query, restricted by the gateway
+	h.Cfg.Loki.NamespaceParam = true
+	fake = &indexStatsLoki{}
+	_, _, err = h.getFlows(context.TODO(), clients{loki: fake}, params, false)
+	require.NoError(t, err)
+	require.Len(t, fake.urls, 1)
+	assert.Equal(t, `http://loki/loki/api/v1/query_range?query={app="netobserv-flowcollector"}&limit=50&namespace=ns1`, fake.urls[0])
+
+	// Namespaces injecting other parameters are rejected
+	fake = &indexStatsLoki{}
+	_, code, err := h.getFlows(context.TODO(), clients{loki: fake}, url.Values{"namespace": {"foo&namespace=kube-system"}}, false)
+	require.ErrorContains(t, err, "invalid namespace")
+	assert.Equal(t, 400, code)
+	assert.Empty(t, fake.urls)
+}
+
+func TestNamespacedCaller_Escaping(t *testing.T) {
+	fake := &indexStatsLoki{}
+	cl := namespacedCaller{Caller: fake, namespace: "foo&namespace=kube-system"}
+	_, _, err := cl.Get("http://loki/loki/api/v1/query_range?query=q")
+	require.NoError(t, err)
+	require.Len(t, fake.urls, 1)
+	assert.Equal(t, "http://loki/loki/api/v1/query_range?query=q&namespace=foo%26namespace%3Dkube-system", fake.urls[0])
+	parsed, err := url.Parse(fake.urls[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo&namespace=kube-system"}, parsed.Query()["namespace"])
+}
+
+// streamLoki returns a single stream with the given line
+type streamLoki struct {
+	line string
+}
+
+func (l *streamLoki) Get(_ string) ([]byte, int, error) {
+	return []byte(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"netobserv-flowcollector"},"values":[["1700000000000000000","` + l.line + `"]]}]}}`), 200, nil
+}
+
+func TestGetTenants(t *testing.T) {
+	cfg := config.Loki{TenantIDs: []string{"infra", "app"}}
+	tenants, err := getTenants(&cfg, url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"infra", "app"}, tenants)
+
+	tenants, err = getTenants(&cfg, url.Values{"tenants": {"app"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app"}, tenants)
+
+	_, err = getTenants(&cfg, url.Values{"tenants": {"app,other"}})
+	require.ErrorContains(t, err, "invalid tenant: other")
+
+	// Single tenant ID
+	tenants, err = getTenants(&config.Loki{TenantID: "netobserv"}, url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"netobserv"}, tenants)
+}
+
+func TestNewLokiQueryClients(t *testing.T) {
+	cfg := config.Loki{URL: "http://loki", TenantIDs: []string{"infra", "app"}}
+	cl := newLokiQueryClients(&cfg, nil, false, cfg.GetTenantIDs())
+	assert.NotNil(t, cl.loki)
+	assert.Empty(t, cl.lokiTenants)
+
+	cfg.MultiTenancy = config.MultiTenancySplit
+	cl = newLokiQueryClients(&cfg, nil, false, cfg.GetTenantIDs())
+	require.Len(t, cl.lokiTenants, 2)
+	assert.Equal(t, "infra", cl.lokiTenants[0].tenant)
+
+	// A single selected tenant doesn't need splitting
+	cl = newLokiQueryClients(&cfg, nil, false, []string{"app"})
+	assert.Empty(t, cl.lokiTenants)
+}
+
+func TestGetFlows_SplitTenants(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{Loki: config.Loki{URL: "http://loki"}}}
+	cl := clients{
+		loki: &streamLoki{line: "unused"},
+		lokiTenants: []tenantClient{
+			{tenant: "infra", client: &streamLoki{line: "{}"}},
+			{tenant: "app", client: &streamLoki{line: "{}"}},
+		},
+	}
+	res, code, err := h.getFlows(context.TODO(), cl, url.Values{"limit": {"50"}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 2, res.Stats.NumQueries)
+	streams := res.Result.(model.Streams)
+	require.Len(t, streams, 2)
+	// tenants are queried in parallel
+	assert.ElementsMatch(t, []string{"infra", "app"}, []string{streams[0].Labels[constants.TenantLabel], streams[1].Labels[constants.TenantLabel]})
+}
+
+// concurrentLoki answers once all the expected queries run at once, or fails after a while
+type concurrentLoki struct {
+	streamLoki
+	running *sync.WaitGroup
+}
+
+func (l *concurrentLoki) Get(url string) ([]byte, int, error) {
+	l.running.Done()
+	all := make(chan struct{})
+	go func() {
+		l.running.Wait()
+		close(all)
+	}()
+	select {
+	case <-all:
+		return l.streamLoki.Get(url)
+	case <-time.After(time.Second):
+		return nil, 500, errors.New("queries are not running in parallel")
+	}
+}
+
+func TestGetFlows_SplitTenantsInParallel(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{Loki: config.Loki{URL: "http://loki"}}}
+	var running sync.WaitGroup
+	running.Add(2)
+	cl := clients{
+		loki: &streamLoki{line: "unused"},
+		lokiTenants: []tenantClient{
+			{tenant: "infra", client: &concurrentLoki{streamLoki: streamLoki{line: "{}"}, running: &running}},
+			{tenant: "app", client: &concurrentLoki{streamLoki: streamLoki{line: "{}"}, running: &running}},
+		},
+		lokiPool: newWorkerPool(2),
+	}
+	res, code, err := h.getFlows(context.TODO(), cl, url.Values{"limit": {"50"}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 200, code)
+	assert.Len(t, res.Result.(model.Streams), 2)
+}
+
+// streamingLoki serves the response of streamLoki as a stream, limited to maxSize bytes
+type streamingLoki struct {
+	streamLoki
+	maxSize int64
+}
+
+func (l *streamingLoki) GetStream(url string) (io.ReadCloser, int, error) {
+	resp, code, err := l.Get(url)
+	return httpclient.LimitReadCloser(io.NopCloser(bytes.NewReader(resp)), l.maxSize), code, err
+}
+
+func TestGetFlows_Streaming(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{Loki: config.Loki{URL: "http://loki"}}}
+	cl := clients{
+		loki: &streamingLoki{streamLoki: streamLoki{line: "unused"}},
+		lokiTenants: []tenantClient{
+			{tenant: "infra", client: &streamingLoki{streamLoki: streamLoki{line: "{}"}}},
+			{tenant: "app", client: &streamingLoki{streamLoki: streamLoki{line: "{}"}}},
+		},
+	}
+	res, code, err := h.getFlows(context.TODO(), cl, url.Values{"limit": {"1"}}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 2, res.Stats.NumQueries)
+	assert.Equal(t, 2, res.Stats.TotalEntries)
+	assert.True(t, res.Stats.LimitReached)
+	streams := res.Result.(model.Streams)
+	require.Len(t, streams, 2)
+	// tenants are queried in parallel
+	assert.ElementsMatch(t, []string{"infra", "app"}, []string{streams[0].Labels[constants.TenantLabel], streams[1].Labels[constants.TenantLabel]})
+
+	// Response too large
+	cl = clients{loki: &streamingLoki{streamLoki: streamLoki{line: "{}"}, maxSize: 50}}
+	_, code, err = h.getFlows(context.TODO(), cl, url.Values{"limit": {"1"}}, true)
+	require.Error(t, err)
+	assert.Equal(t, 400, code)
+	assert.Contains(t, err.Error(), "response exceeds the maximum size of 50 bytes: select a shorter time range, add filters or lower the limit")
+}
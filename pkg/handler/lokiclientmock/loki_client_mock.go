@@ -7,6 +7,7 @@ import (
 
 	"github.com/netobserv/network-observability-console-plugin/pkg/decoders"
 	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	pmodel "github.com/prometheus/common/model"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,11 +22,19 @@ func (o *LokiClientMock) Get(url string) ([]byte, int, error) {
 	parseNetEvents := false
 	mlog.Debugf("Get url: %s", url)
 
+	if strings.Contains(url, "/loki/api/v1/series") || strings.Contains(url, "/loki/api/v1/labels") {
+		return seriesFromRecords(strings.Contains(url, "/labels"))
+	}
+	if strings.Contains(url, "/loki/api/v1/index/stats") {
+		return indexStatsFromRecords()
+	}
+
 	isLabel := strings.Contains(url, "/label/")
 	if isLabel {
 		path = "mocks/loki/namespaces.json"
 	} else {
-		if strings.Contains(url, "query=topk") || strings.Contains(url, "query=bottomk") {
+		isVolume := strings.Contains(url, "/index/volume")
+		if strings.Contains(url, "query=topk") || strings.Contains(url, "query=bottomk") || isVolume {
 			// Simulate error for packet rate metrics (non-dropped packets)
 			if strings.Contains(url, "unwrap%20Packets") && !strings.Contains(url, "|unwrap%20PktDrop") {
 				errorResponse := []byte(`{
@@ -57,29 +66,34 @@ func (o *LokiClientMock) Get(url string) ([]byte, int, error) {
 			}
 
 			path = "mocks/loki/flow_metrics"
+			groupBy := url
+			if isVolume {
+				// Volume queries are mocked with metrics grouped by their target labels
+				groupBy = targetLabelsAsGroupBy(url)
+			}
 
 			if strings.Contains(url, "|unwrap%20PktDrop") {
 				path += "_dropped"
 			}
 
 			//nolint:gocritic // if-else is ok
-			if strings.Contains(url, "by(app)") {
+			if strings.Contains(groupBy, "by(app)") {
 				path += "_app.json"
-			} else if strings.Contains(url, "by(PktDropLatestState)") {
+			} else if strings.Contains(groupBy, "by(PktDropLatestState)") {
 				path += "_state.json"
-			} else if strings.Contains(url, "by(PktDropLatestDropCause)") {
+			} else if strings.Contains(groupBy, "by(PktDropLatestDropCause)") {
 				path += "_cause.json"
-			} else if strings.Contains(url, "by(K8S_ClusterName)") {
+			} else if strings.Contains(groupBy, "by(K8S_ClusterName)") {
 				path += "_cluster.json"
-			} else if strings.Contains(url, "by(SrcK8S_NetworkName,DstK8S_NetworkName)") {
+			} else if strings.Contains(groupBy, "by(SrcK8S_NetworkName,DstK8S_NetworkName)") {
 				path += "_udn.json"
-			} else if strings.Contains(url, "by(SrcK8S_Zone,DstK8S_Zone)") {
+			} else if strings.Contains(groupBy, "by(SrcK8S_Zone,DstK8S_Zone)") {
 				path += "_zone.json"
-			} else if strings.Contains(url, "by(SrcK8S_HostName,DstK8S_HostName)") {
+			} else if strings.Contains(groupBy, "by(SrcK8S_HostName,DstK8S_HostName)") {
 				path += "_host.json"
-			} else if strings.Contains(url, "by(SrcK8S_Namespace,DstK8S_Namespace)") {
+			} else if strings.Contains(groupBy, "by(SrcK8S_Namespace,DstK8S_Namespace)") {
 				path += "_namespace.json"
-			} else if strings.Contains(url, "by(SrcK8S_OwnerName,SrcK8S_OwnerType,DstK8S_OwnerName,DstK8S_OwnerType,SrcK8S_Namespace,DstK8S_Namespace)") {
+			} else if strings.Contains(groupBy, "by(SrcK8S_OwnerName,SrcK8S_OwnerType,DstK8S_OwnerName,DstK8S_OwnerType,SrcK8S_Namespace,DstK8S_Namespace)") {
 				path += "_owner.json"
 			} else {
 				path += "_resource.json"
@@ -157,5 +171,107 @@ func (o *LokiClientMock) Get(url string) ([]byte, int, error) {
 		}
 	}
 
+	if strings.Contains(url, "/loki/api/v1/query?") || strings.Contains(url, "/loki/api/v1/index/volume?") {
+		// Instant query: mocks are stored as matrices, convert them to vectors
+		file, err = matrixToVector(file)
+		if err != nil {
+			return nil, 500, err
+		}
+	}
+
 	return []byte(file), 200, nil
 }
+
+// seriesFromRecords mocks series from the streams of flow records, or only their label names
+func seriesFromRecords(labelNamesOnly bool) ([]byte, int, error) {
+	file, err := os.ReadFile("mocks/loki/flow_records.json")
+	if err != nil {
+		return nil, 500, err
+	}
+	var qr model.QueryResponse
+	if err := json.Unmarshal(file, &qr); err != nil {
+		return nil, 500, err
+	}
+	series := []map[string]string{}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, s := range qr.Data.Result.(model.Streams) {
+		series = append(series, s.Labels)
+		for k := range s.Labels {
+			if !seen[k] {
+				seen[k] = true
+				names = append(names, k)
+			}
+		}
+	}
+	var res []byte
+	if labelNamesOnly {
+		res, err = json.Marshal(model.LabelValuesResponse{Status: "success", Data: names})
+	} else {
+		res, err = json.Marshal(model.SeriesResponse{Status: "success", Data: series})
+	}
+	if err != nil {
+		return nil, 500, err
+	}
+	return res, 200, nil
+}
+
+// indexStatsFromRecords mocks index stats from the streams of flow records
+func indexStatsFromRecords() ([]byte, int, error) {
+	file, err := os.ReadFile("mocks/loki/flow_records.json")
+	if err != nil {
+		return nil, 500, err
+	}
+	var qr model.QueryResponse
+	if err := json.Unmarshal(file, &qr); err != nil {
+		return nil, 500, err
+	}
+	stats := model.IndexStats{}
+	for _, s := range qr.Data.Result.(model.Streams) {
+		stats.Streams++
+		stats.Chunks++
+		stats.Entries += int64(len(s.Entries))
+		for _, e := range s.Entries {
+			stats.Bytes += int64(len(e.Line))
+		}
+	}
+	res, err := json.Marshal(stats)
+	if err != nil {
+		return nil, 500, err
+	}
+	return res, 200, nil
+}
+
+// targetLabelsAsGroupBy turns the targetLabels param of a volume query into a "by(<labels>)" clause
+func targetLabelsAsGroupBy(url string) string {
+	_, after, found := strings.Cut(url, "targetLabels=")
+	if !found {
+		return ""
+	}
+	labels, _, _ := strings.Cut(after, "&")
+	return "by(" + labels + ")"
+}
+
+// matrixToVector sums the values of each series, keeping the last timestamp
+func matrixToVector(file []byte) ([]byte, error) {
+	var qr model.QueryResponse
+	if err := json.Unmarshal(file, &qr); err != nil {
+		return nil, err
+	}
+	matrix, ok := qr.Data.Result.(model.Matrix)
+	if !ok {
+		return file, nil
+	}
+	vector := model.Vector{}
+	for _, ss := range matrix {
+		sample := pmodel.Sample{Metric: ss.Metric}
+		for _, v := range ss.Values {
+			sample.Value += v.Value
+			sample.Timestamp = v.Timestamp
+		}
+		vector = append(vector, sample)
+	}
+	qr.Data.ResultType = model.ResultTypeVector
+	qr.Data.Result = vector
+	return json.Marshal(qr)
+}
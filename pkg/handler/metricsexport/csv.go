@@ -0,0 +1,73 @@
+package metricsexport
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	pmodel "github.com/prometheus/common/model"
+)
+
+// WriteCSV writes one row per series and timestamp, with a column per label
+func WriteCSV(w io.Writer, series []Series) error {
+	names := labelNames(series)
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append(append([]string{"Timestamp"}, names...), "Value")); err != nil {
+		return err
+	}
+	for i := range series {
+		labels := labelValues(&series[i], names)
+		for _, v := range series[i].Values {
+			row := make([]string, 0, len(names)+2)
+			row = append(row, v.Timestamp.Time().UTC().Format(time.RFC3339))
+			row = append(row, labels...)
+			row = append(row, formatFloat(float64(v.Value)))
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteTotalsCSV writes one row per series, with a column per label followed by the series stats.
+// Totals are only written for cumulative metrics.
+func WriteTotalsCSV(w io.Writer, series []Series, opts *Options) error {
+	names := labelNames(series)
+	header := append([]string{}, names...)
+	header = append(header, "Avg", "Min", "Max", "Latest")
+	if opts.Cumulative {
+		header = append(header, "Total")
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for i := range series {
+		stats := series[i].GetStats(opts)
+		row := labelValues(&series[i], names)
+		row = append(row, formatFloat(stats.Avg), formatFloat(stats.Min), formatFloat(stats.Max), formatFloat(stats.Latest))
+		if opts.Cumulative {
+			row = append(row, formatFloat(stats.Total))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func labelValues(s *Series, names []string) []string {
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		values = append(values, string(s.Labels[pmodel.LabelName(name)]))
+	}
+	return values
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
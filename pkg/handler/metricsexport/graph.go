@@ -0,0 +1,258 @@
+package metricsexport
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	pmodel "github.com/prometheus/common/model"
+)
+
+const (
+	srcPrefix = "Src"
+	dstPrefix = "Dst"
+
+	unknownNode = "(unknown)"
+)
+
+var dotEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Graph is a directed graph of scope nodes, such as namespaces, with edges weighted by metric values
+type Graph struct {
+	// Attributes are the names of node attributes, which are scope labels without their Src / Dst prefix
+	Attributes []string
+	Nodes      []Node
+	Edges      []Edge
+	// Cumulative tells that edges have totals
+	Cumulative bool
+}
+
+type Node struct {
+	ID         string
+	Label      string
+	Attributes map[string]string
+}
+
+type Edge struct {
+	Source string
+	Target string
+	// Weight is the sum of series averages between both nodes
+	Weight float64
+	// Total is the sum of series totals between both nodes, for cumulative metrics
+	Total float64
+}
+
+// NewGraph builds a graph from series, with nodes identified by the source and destination scope labels.
+// Labels that have neither a Src nor a Dst prefix, such as the cluster name, apply to both nodes.
+func NewGraph(series []Series, opts *Options) (*Graph, error) {
+	var attributes []string
+	endpoints := false
+	for _, label := range opts.Labels {
+		attr := label
+		if strings.HasPrefix(label, srcPrefix) || strings.HasPrefix(label, dstPrefix) {
+			attr = label[len(srcPrefix):]
+			endpoints = true
+		}
+		if !slices.Contains(attributes, attr) {
+			attributes = append(attributes, attr)
+		}
+	}
+	if !endpoints {
+		return nil, errors.New("graph formats require an aggregation by a scope with source and destination labels")
+	}
+
+	g := Graph{Attributes: attributes, Cumulative: opts.Cumulative}
+	nodes := map[string]*Node{}
+	edges := map[[2]string]*Edge{}
+	for i := range series {
+		src := g.node(nodes, series[i].Labels, srcPrefix)
+		dst := g.node(nodes, series[i].Labels, dstPrefix)
+		key := [2]string{src, dst}
+		edge, ok := edges[key]
+		if !ok {
+			edge = &Edge{Source: src, Target: dst}
+			edges[key] = edge
+		}
+		stats := series[i].GetStats(opts)
+		edge.Weight += stats.Avg
+		edge.Total += stats.Total
+	}
+
+	// stable output, ordered by node labels
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, *n)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		if g.Nodes[i].Label != g.Nodes[j].Label {
+			return g.Nodes[i].Label < g.Nodes[j].Label
+		}
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	index := map[string]int{}
+	for i := range g.Nodes {
+		index[g.Nodes[i].ID] = i
+		g.Nodes[i].ID = fmt.Sprintf("n%d", i)
+	}
+	for _, e := range edges {
+		g.Edges = append(g.Edges, *e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return index[g.Edges[i].Source] < index[g.Edges[j].Source]
+		}
+		return index[g.Edges[i].Target] < index[g.Edges[j].Target]
+	})
+	for i := range g.Edges {
+		g.Edges[i].Source = g.Nodes[index[g.Edges[i].Source]].ID
+		g.Edges[i].Target = g.Nodes[index[g.Edges[i].Target]].ID
+	}
+	return &g, nil
+}
+
+// node returns the key of the node for one side of a series, adding it when needed
+func (g *Graph) node(nodes map[string]*Node, labels pmodel.Metric, prefix string) string {
+	attrs := map[string]string{}
+	var values, parts []string
+	for _, attr := range g.Attributes {
+		value, ok := labels[pmodel.LabelName(prefix+attr)]
+		if !ok {
+			value = labels[pmodel.LabelName(attr)]
+		}
+		attrs[attr] = string(value)
+		parts = append(parts, attr+"="+string(value))
+		if value != "" {
+			values = append(values, string(value))
+		}
+	}
+	key := strings.Join(parts, ",")
+	if _, ok := nodes[key]; !ok {
+		label := strings.Join(values, " / ")
+		if label == "" {
+			label = unknownNode
+		}
+		nodes[key] = &Node{ID: key, Label: label, Attributes: attrs}
+	}
+	return key
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph in the GraphML format, with node labels and scope attributes, and edge weights
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  []graphMLKey{{ID: "label", For: "node", Name: "label", Type: "string"}},
+		Graph: graphMLGraph{ID: "netobserv", EdgeDefault: "directed"},
+	}
+	for _, attr := range g.Attributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: attr, For: "node", Name: attr, Type: "string"})
+	}
+	doc.Keys = append(doc.Keys, graphMLKey{ID: "weight", For: "edge", Name: "weight", Type: "double"})
+	if g.Cumulative {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "total", For: "edge", Name: "total", Type: "double"})
+	}
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		node := graphMLNode{ID: n.ID, Data: []graphMLData{{Key: "label", Value: n.Label}}}
+		for _, attr := range g.Attributes {
+			node.Data = append(node.Data, graphMLData{Key: attr, Value: n.Attributes[attr]})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for i := range g.Edges {
+		e := &g.Edges[i]
+		edge := graphMLEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: e.Source,
+			Target: e.Target,
+			Data:   []graphMLData{{Key: "weight", Value: formatFloat(e.Weight)}},
+		}
+		if g.Cumulative {
+			edge.Data = append(edge.Data, graphMLData{Key: "total", Value: formatFloat(e.Total)})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. As dot layouts require integer weights,
+// edge weights are rounded, while exact values are kept in edge labels and value attributes.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph netobserv {\n")
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		bw.WriteString(fmt.Sprintf("  %s [label=%s", n.ID, dotString(n.Label)))
+		for _, attr := range g.Attributes {
+			bw.WriteString(fmt.Sprintf(", %s=%s", attr, dotString(n.Attributes[attr])))
+		}
+		bw.WriteString("];\n")
+	}
+	for i := range g.Edges {
+		e := &g.Edges[i]
+		value := formatFloat(e.Weight)
+		bw.WriteString(fmt.Sprintf("  %s -> %s [weight=%d, label=%s, value=%s", e.Source, e.Target, int64(math.Max(1, math.Round(e.Weight))), dotString(value), value))
+		if g.Cumulative {
+			bw.WriteString(", total=" + formatFloat(e.Total))
+		}
+		bw.WriteString("];\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func dotString(s string) string {
+	return `"` + dotEscape.Replace(s) + `"`
+}
@@ -0,0 +1,96 @@
+package metricsexport
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	pmodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ownerLabels = []string{"SrcK8S_OwnerName", "DstK8S_OwnerName", "SrcK8S_Namespace", "DstK8S_Namespace", "K8S_ClusterName"}
+
+func ownerSeries() []Series {
+	series, _ := GetSeries(model.Vector{
+		{Metric: pmodel.Metric{"SrcK8S_OwnerName": "front", "SrcK8S_Namespace": "shop", "DstK8S_OwnerName": "db", "DstK8S_Namespace": "shop", "K8S_ClusterName": "c1"}, Value: 100},
+		{Metric: pmodel.Metric{"SrcK8S_OwnerName": "front", "SrcK8S_Namespace": "shop", "DstK8S_OwnerName": "db", "DstK8S_Namespace": "shop", "K8S_ClusterName": "c1"}, Value: 20.5},
+		{Metric: pmodel.Metric{"SrcK8S_OwnerName": "db", "SrcK8S_Namespace": "shop", "K8S_ClusterName": "c1"}, Value: 3},
+	})
+	return series
+}
+
+func TestNewGraph(t *testing.T) {
+	g, err := NewGraph(ownerSeries(), &Options{Labels: ownerLabels})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"K8S_OwnerName", "K8S_Namespace", "K8S_ClusterName"}, g.Attributes)
+	require.Len(t, g.Nodes, 3)
+	assert.Equal(t, "c1", g.Nodes[0].Label)
+	assert.Equal(t, map[string]string{"K8S_OwnerName": "", "K8S_Namespace": "", "K8S_ClusterName": "c1"}, g.Nodes[0].Attributes)
+	assert.Equal(t, "db / shop / c1", g.Nodes[1].Label)
+	assert.Equal(t, "front / shop / c1", g.Nodes[2].Label)
+	assert.Equal(t, []Edge{
+		{Source: "n1", Target: "n0", Weight: 3, Total: 3},
+		{Source: "n2", Target: "n1", Weight: 120.5, Total: 120.5},
+	}, g.Edges)
+
+	_, err = NewGraph(ownerSeries(), &Options{Labels: []string{"app"}})
+	assert.EqualError(t, err, "graph formats require an aggregation by a scope with source and destination labels")
+}
+
+func TestWriteGraphML(t *testing.T) {
+	g, err := NewGraph(ownerSeries(), &Options{Labels: []string{"SrcK8S_OwnerName", "DstK8S_OwnerName"}, Cumulative: true})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteGraphML(&buf))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"></key>
+  <key id="K8S_OwnerName" for="node" attr.name="K8S_OwnerName" attr.type="string"></key>
+  <key id="weight" for="edge" attr.name="weight" attr.type="double"></key>
+  <key id="total" for="edge" attr.name="total" attr.type="double"></key>
+  <graph id="netobserv" edgedefault="directed">
+    <node id="n0">
+      <data key="label">(unknown)</data>
+      <data key="K8S_OwnerName"></data>
+    </node>
+    <node id="n1">
+      <data key="label">db</data>
+      <data key="K8S_OwnerName">db</data>
+    </node>
+    <node id="n2">
+      <data key="label">front</data>
+      <data key="K8S_OwnerName">front</data>
+    </node>
+    <edge id="e0" source="n1" target="n0">
+      <data key="weight">3</data>
+      <data key="total">3</data>
+    </edge>
+    <edge id="e1" source="n2" target="n1">
+      <data key="weight">120.5</data>
+      <data key="total">120.5</data>
+    </edge>
+  </graph>
+</graphml>
+`, buf.String())
+}
+
+func TestWriteDOT(t *testing.T) {
+	series := ownerSeries()
+	series[0].Labels["SrcK8S_OwnerName"] = `fr"ont`
+	g, err := NewGraph(series, &Options{Labels: []string{"SrcK8S_OwnerName", "DstK8S_OwnerName"}})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteDOT(&buf))
+	assert.Equal(t, `digraph netobserv {
+  n0 [label="(unknown)", K8S_OwnerName=""];
+  n1 [label="db", K8S_OwnerName="db"];
+  n2 [label="fr\"ont", K8S_OwnerName="fr\"ont"];
+  n3 [label="front", K8S_OwnerName="front"];
+  n1 -> n0 [weight=3, label="3", value=3];
+  n2 -> n1 [weight=100, label="100", value=100];
+  n3 -> n1 [weight=21, label="20.5", value=20.5];
+}
+`, buf.String())
+}
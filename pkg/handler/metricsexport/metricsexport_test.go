@@ -0,0 +1,90 @@
+package metricsexport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	pmodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matrix() model.Matrix {
+	return model.Matrix{
+		{
+			Metric: pmodel.Metric{"SrcK8S_Namespace": "ns1", "DstK8S_Namespace": "ns2"},
+			Values: []pmodel.SamplePair{{Timestamp: 1700000000000, Value: 10}, {Timestamp: 1700000030000, Value: 30}},
+		},
+		{
+			Metric: pmodel.Metric{"SrcK8S_Namespace": "ns2", "DstK8S_Namespace": ""},
+			Values: []pmodel.SamplePair{{Timestamp: 1700000000000, Value: 1.5}},
+		},
+	}
+}
+
+func TestGetSeries(t *testing.T) {
+	series, err := GetSeries(matrix())
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	// rates of matrices are integrated over their steps
+	assert.Equal(t, Stats{Avg: 20, Min: 10, Max: 30, Latest: 30, Total: 1200}, series[0].GetStats(&Options{Rate: true, Step: 30 * time.Second}))
+	assert.Equal(t, Stats{Avg: 20, Min: 10, Max: 30, Latest: 30, Total: 40}, series[0].GetStats(&Options{}))
+
+	series, err = GetSeries(model.Vector{{Metric: pmodel.Metric{"app": "netobserv"}, Value: 5, Timestamp: 1700000000000}})
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, []pmodel.SamplePair{{Timestamp: 1700000000000, Value: 5}}, series[0].Values)
+	// rates of vectors are already totals over the time range
+	assert.Equal(t, Stats{Avg: 5, Min: 5, Max: 5, Latest: 5, Total: 5}, series[0].GetStats(&Options{Rate: true, Step: 30 * time.Second}))
+
+	_, err = GetSeries(model.Streams{})
+	assert.EqualError(t, err, "unexpected result type: model.Streams")
+}
+
+func TestWriteCSV(t *testing.T) {
+	series, _ := GetSeries(matrix())
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, series))
+	assert.Equal(t, `Timestamp,DstK8S_Namespace,SrcK8S_Namespace,Value
+2023-11-14T22:13:20Z,ns2,ns1,10
+2023-11-14T22:13:50Z,ns2,ns1,30
+2023-11-14T22:13:20Z,,ns2,1.5
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteTotalsCSV(&buf, series, &Options{Rate: true, Cumulative: true, Step: 30 * time.Second}))
+	assert.Equal(t, `DstK8S_Namespace,SrcK8S_Namespace,Avg,Min,Max,Latest,Total
+ns2,ns1,20,10,30,30,1200
+,ns2,1.5,1.5,1.5,1.5,45
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteTotalsCSV(&buf, series, &Options{}))
+	assert.Equal(t, `DstK8S_Namespace,SrcK8S_Namespace,Avg,Min,Max,Latest
+ns2,ns1,20,10,30,30
+,ns2,1.5,1.5,1.5,1.5
+`, buf.String())
+}
+
+func TestMetricName(t *testing.T) {
+	assert.Equal(t, "netobserv_bytes_rate", MetricName("Bytes", "rate"))
+	assert.Equal(t, "netobserv_pkt_drop_bytes_rate", MetricName("PktDropBytes", "rate"))
+	assert.Equal(t, "netobserv_dns_latency_ms_p90", MetricName("DnsLatencyMs", "p90"))
+	assert.Equal(t, "netobserv_time_flow_rtt_ns_avg", MetricName("TimeFlowRttNs", "avg"))
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	series, _ := GetSeries(matrix())
+	series[1].Labels["SrcK8S_Namespace"] = `a"b\c`
+	var buf bytes.Buffer
+	require.NoError(t, WriteOpenMetrics(&buf, series, &Options{Name: "netobserv_bytes_rate", Help: "Rate of bytes"}))
+	assert.Equal(t, `# HELP netobserv_bytes_rate Rate of bytes
+# TYPE netobserv_bytes_rate gauge
+netobserv_bytes_rate{DstK8S_Namespace="ns2",SrcK8S_Namespace="ns1"} 10 1700000000
+netobserv_bytes_rate{DstK8S_Namespace="ns2",SrcK8S_Namespace="ns1"} 30 1700000030
+netobserv_bytes_rate{SrcK8S_Namespace="a\"b\\c"} 1.5 1700000000
+# EOF
+`, buf.String())
+}
@@ -0,0 +1,89 @@
+package metricsexport
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	pmodel "github.com/prometheus/common/model"
+)
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	labelValueEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// MetricName builds an OpenMetrics name from a metric type and function, such as netobserv_pkt_drop_bytes_rate
+func MetricName(metricType, function string) string {
+	var sb strings.Builder
+	sb.WriteString("netobserv_")
+	runes := []rune(metricType)
+	for i, r := range runes {
+		// split words on case changes, keeping acronyms together
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	if function != "" {
+		sb.WriteByte('_')
+		sb.WriteString(strings.ToLower(function))
+	}
+	return invalidNameChars.ReplaceAllString(sb.String(), "_")
+}
+
+// WriteOpenMetrics writes series as an OpenMetrics gauge, with a sample per series and timestamp
+func WriteOpenMetrics(w io.Writer, series []Series, opts *Options) error {
+	bw := bufio.NewWriter(w)
+	if opts.Help != "" {
+		bw.WriteString("# HELP " + opts.Name + " " + opts.Help + "\n")
+	}
+	bw.WriteString("# TYPE " + opts.Name + " gauge\n")
+	names := labelNames(series)
+	for i := range series {
+		labels := openMetricsLabels(&series[i], names)
+		for _, v := range series[i].Values {
+			bw.WriteString(opts.Name)
+			bw.WriteString(labels)
+			bw.WriteByte(' ')
+			bw.WriteString(openMetricsValue(float64(v.Value)))
+			bw.WriteByte(' ')
+			// timestamps are in seconds
+			bw.WriteString(strconv.FormatFloat(float64(v.Timestamp)/1000, 'f', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// openMetricsLabels formats the non-empty labels of a series, in a stable order
+func openMetricsLabels(s *Series, names []string) string {
+	var parts []string
+	for _, name := range names {
+		if value := string(s.Labels[pmodel.LabelName(name)]); value != "" {
+			parts = append(parts, name+`="`+labelValueEscape.Replace(value)+`"`)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func openMetricsValue(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	default:
+		return formatFloat(f)
+	}
+}
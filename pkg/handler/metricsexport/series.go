@@ -0,0 +1,115 @@
+package metricsexport
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	pmodel "github.com/prometheus/common/model"
+)
+
+// Options define how metrics are exported
+type Options struct {
+	// Name is the OpenMetrics metric name
+	Name string
+	// Help describes the metric in OpenMetrics
+	Help string
+	// Rate tells that values are per-second rates: their totals are integrated over time
+	Rate bool
+	// Step is the interval between values of matrices, over which rates are integrated
+	Step time.Duration
+	// Cumulative tells that values can be added up, so that totals are meaningful
+	Cumulative bool
+	// Labels are the scope labels forming graph nodes
+	Labels []string
+}
+
+// Series is a time series of a metrics query result
+type Series struct {
+	Labels pmodel.Metric
+	Values []pmodel.SamplePair
+	// Instant is set for series of vectors, whose single value covers the whole time range
+	Instant bool
+}
+
+// Stats summarize the values of a series
+type Stats struct {
+	Avg    float64
+	Min    float64
+	Max    float64
+	Latest float64
+	// Total is the sum of values, or their integral over time for rates of matrices
+	Total float64
+}
+
+// GetSeries returns the series of a matrix or vector result
+func GetSeries(result model.ResultValue) ([]Series, error) {
+	switch r := result.(type) {
+	case model.Matrix:
+		series := make([]Series, 0, len(r))
+		for i := range r {
+			series = append(series, Series{Labels: r[i].Metric, Values: r[i].Values})
+		}
+		return series, nil
+	case model.Vector:
+		series := make([]Series, 0, len(r))
+		for i := range r {
+			series = append(series, Series{
+				Labels:  r[i].Metric,
+				Values:  []pmodel.SamplePair{{Timestamp: r[i].Timestamp, Value: r[i].Value}},
+				Instant: true,
+			})
+		}
+		return series, nil
+	default:
+		return nil, fmt.Errorf("unexpected result type: %T", result)
+	}
+}
+
+// GetStats computes the stats of a series, ignoring NaN values, as the UI does
+func (s *Series) GetStats(opts *Options) Stats {
+	var stats Stats
+	var count int
+	for _, v := range s.Values {
+		f := float64(v.Value)
+		if math.IsNaN(f) {
+			continue
+		}
+		if count == 0 || f < stats.Min {
+			stats.Min = f
+		}
+		if count == 0 || f > stats.Max {
+			stats.Max = f
+		}
+		stats.Total += f
+		stats.Latest = f
+		count++
+	}
+	if count == 0 {
+		return stats
+	}
+	stats.Avg = stats.Total / float64(count)
+	if opts.Rate && !s.Instant {
+		// each value stands for the step that ends at its timestamp; values of vectors are already totals
+		stats.Total *= opts.Step.Seconds()
+	}
+	return stats
+}
+
+// labelNames returns the sorted names of labels found in any series
+func labelNames(series []Series) []string {
+	set := map[string]struct{}{}
+	for i := range series {
+		for name := range series[i].Labels {
+			set[string(name)] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,303 @@
+package records
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	hubbleForwarded = "FORWARDED"
+	hubbleDropped   = "DROPPED"
+
+	hubbleEventDrop  = 1
+	hubbleEventTrace = 4
+)
+
+// HubbleResponse is a Hubble observer response, holding a flow
+type HubbleResponse struct {
+	Flow     *HubbleFlow `json:"flow"`
+	NodeName string      `json:"node_name,omitempty"`
+	Time     string      `json:"time,omitempty"`
+}
+
+type HubbleFlow struct {
+	Time             string           `json:"time,omitempty"`
+	Verdict          string           `json:"verdict"`
+	DropReasonDesc   string           `json:"drop_reason_desc,omitempty"`
+	Ethernet         *HubbleEthernet  `json:"ethernet,omitempty"`
+	IP               *HubbleIP        `json:"IP,omitempty"`
+	L4               *HubbleLayer4    `json:"l4,omitempty"`
+	Source           *HubbleEndpoint  `json:"source,omitempty"`
+	Destination      *HubbleEndpoint  `json:"destination,omitempty"`
+	Type             string           `json:"Type"`
+	NodeName         string           `json:"node_name,omitempty"`
+	EventType        *HubbleEventType `json:"event_type"`
+	SrcService       *HubbleService   `json:"source_service,omitempty"`
+	DstService       *HubbleService   `json:"destination_service,omitempty"`
+	TrafficDirection string           `json:"traffic_direction,omitempty"`
+	EgressAllowedBy  []HubblePolicy   `json:"egress_allowed_by,omitempty"`
+	IngressAllowedBy []HubblePolicy   `json:"ingress_allowed_by,omitempty"`
+	EgressDeniedBy   []HubblePolicy   `json:"egress_denied_by,omitempty"`
+	IngressDeniedBy  []HubblePolicy   `json:"ingress_denied_by,omitempty"`
+	Summary          string           `json:"Summary,omitempty"`
+}
+
+type HubbleEthernet struct {
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+}
+
+type HubbleIP struct {
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	IPVersion   string `json:"ipVersion,omitempty"`
+}
+
+type HubbleLayer4 struct {
+	TCP    *HubbleTCP  `json:"TCP,omitempty"`
+	UDP    *HubblePort `json:"UDP,omitempty"`
+	SCTP   *HubblePort `json:"SCTP,omitempty"`
+	ICMPv4 *HubbleICMP `json:"ICMPv4,omitempty"`
+	ICMPv6 *HubbleICMP `json:"ICMPv6,omitempty"`
+}
+
+type HubblePort struct {
+	SourcePort      uint32 `json:"source_port,omitempty"`
+	DestinationPort uint32 `json:"destination_port,omitempty"`
+}
+
+type HubbleTCP struct {
+	HubblePort
+	Flags map[string]bool `json:"flags,omitempty"`
+}
+
+type HubbleICMP struct {
+	Type uint32 `json:"type,omitempty"`
+	Code uint32 `json:"code,omitempty"`
+}
+
+type HubbleEndpoint struct {
+	ClusterName string           `json:"cluster_name,omitempty"`
+	Namespace   string           `json:"namespace,omitempty"`
+	PodName     string           `json:"pod_name,omitempty"`
+	Workloads   []HubbleWorkload `json:"workloads,omitempty"`
+}
+
+type HubbleWorkload struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type HubbleService struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type HubbleEventType struct {
+	Type int `json:"type"`
+}
+
+type HubblePolicy struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+// hubbleTCPFlags are the Hubble flags of netobserv TCP flags
+var hubbleTCPFlags = map[string][]string{
+	"FIN":     {"FIN"},
+	"SYN":     {"SYN"},
+	"RST":     {"RST"},
+	"PSH":     {"PSH"},
+	"ACK":     {"ACK"},
+	"URG":     {"URG"},
+	"ECE":     {"ECE"},
+	"CWR":     {"CWR"},
+	"SYN_ACK": {"SYN", "ACK"},
+	"FIN_ACK": {"FIN", "ACK"},
+	"RST_ACK": {"RST", "ACK"},
+}
+
+// hubbleFlagsOrder is the order of flags in summaries, as in Hubble
+var hubbleFlagsOrder = []string{"SYN", "FIN", "RST", "PSH", "ACK", "URG", "ECE", "CWR"}
+
+// ToHubble maps a flow to a Hubble observer response, as written by `hubble observe -o jsonpb`, so that it can be read
+// by tools built for Hubble. Only the subset of the Hubble flow schema that netobserv flows can fill is used:
+//
+//	time                      TimeFlowStartMs
+//	verdict                   DROPPED when packets were dropped, or when a network event denied the flow; else FORWARDED
+//	drop_reason_desc          POLICY_DENIED for network policy drops; else DROP_REASON_UNKNOWN, as kernel drop causes
+//	                          have no Hubble equivalent: they are kept in Summary
+//	event_type.type           1 (drop) for dropped flows, 4 (trace) otherwise
+//	ethernet                  SrcMac, DstMac
+//	IP                        SrcAddr, DstAddr, with ipVersion from the address
+//	l4                        TCP / UDP / SCTP ports from SrcPort, DstPort and TCP flags from Flags, where SYN_ACK,
+//	                          FIN_ACK and RST_ACK set both flags; ICMPv4 / ICMPv6 type and code from IcmpType, IcmpCode
+//	source, destination       Src/DstK8S_Namespace, pod_name from Src/DstK8S_Name for pods, workloads from
+//	                          Src/DstK8S_OwnerName and Src/DstK8S_OwnerType, cluster_name from K8S_ClusterName
+//	source/destination_service Src/DstK8S_Name and Src/DstK8S_Namespace for services
+//	node_name                 the host of the reporting side: DstK8S_HostName for ingress, SrcK8S_HostName for egress,
+//	                          prefixed by the cluster name when known, as Hubble Relay does
+//	traffic_direction         FlowDirection: 0 is INGRESS, 1 is EGRESS
+//	*_allowed_by, *_denied_by network policy events, from NetworkEvents with their Action and Direction
+//	Summary                   protocol and TCP flags, and drop causes
+//
+// As netobserv flows aggregate packets, a flow with any dropped packet is reported as dropped.
+func ToHubble(r Record) *HubbleResponse {
+	f := HubbleFlow{
+		Verdict:   hubbleForwarded,
+		Type:      "L3_L4",
+		EventType: &HubbleEventType{Type: hubbleEventTrace},
+	}
+	if start, ok := number(r["TimeFlowStartMs"]); ok {
+		f.Time = time.UnixMilli(int64(start)).UTC().Format(time.RFC3339Nano)
+	}
+	if src, dst := str(r["SrcMac"]), str(r["DstMac"]); src != "" || dst != "" {
+		f.Ethernet = &HubbleEthernet{Source: src, Destination: dst}
+	}
+	if src, dst := str(r["SrcAddr"]), str(r["DstAddr"]); src != "" || dst != "" {
+		version := "IPv4"
+		if strings.Contains(src+dst, ":") {
+			version = "IPv6"
+		}
+		f.IP = &HubbleIP{Source: src, Destination: dst, IPVersion: version}
+	}
+	var summary []string
+	f.L4, summary = hubbleL4(r)
+
+	cluster := str(r["K8S_ClusterName"])
+	f.Source, f.SrcService = hubbleEndpoint(r, "Src", cluster)
+	f.Destination, f.DstService = hubbleEndpoint(r, "Dst", cluster)
+	switch str(r["FlowDirection"]) {
+	case "0":
+		f.TrafficDirection = "INGRESS"
+		f.NodeName = str(r["DstK8S_HostName"])
+	case "1":
+		f.TrafficDirection = "EGRESS"
+		f.NodeName = str(r["SrcK8S_HostName"])
+	}
+	if f.NodeName != "" && cluster != "" {
+		f.NodeName = cluster + "/" + f.NodeName
+	}
+
+	policyDrop := hubblePolicies(r, &f)
+	if drops, ok := number(r["PktDropPackets"]); (ok && drops > 0) || policyDrop {
+		f.Verdict = hubbleDropped
+		f.EventType.Type = hubbleEventDrop
+		f.DropReasonDesc = "DROP_REASON_UNKNOWN"
+		if policyDrop {
+			f.DropReasonDesc = "POLICY_DENIED"
+		}
+		if cause := str(r["PktDropLatestDropCause"]); cause != "" {
+			summary = append(summary, "Drop cause: "+cause)
+		}
+	}
+	f.Summary = strings.Join(summary, "; ")
+	return &HubbleResponse{Flow: &f, NodeName: f.NodeName, Time: f.Time}
+}
+
+// hubbleL4 returns the L4 info of a flow, and its summary
+func hubbleL4(r Record) (*HubbleLayer4, []string) {
+	ports := HubblePort{SourcePort: uint32Of(r["SrcPort"]), DestinationPort: uint32Of(r["DstPort"])}
+	proto, _ := number(r["Proto"])
+	switch proto {
+	case 6:
+		tcp := HubbleTCP{HubblePort: ports, Flags: map[string]bool{}}
+		for name, set := range tcpFlags(r["Flags"]) {
+			if set {
+				for _, flag := range hubbleTCPFlags[name] {
+					tcp.Flags[flag] = true
+				}
+			}
+		}
+		var names []string
+		for _, flag := range hubbleFlagsOrder {
+			if tcp.Flags[flag] {
+				names = append(names, flag)
+			}
+		}
+		summary := "TCP"
+		if len(names) > 0 {
+			summary = "TCP Flags: " + strings.Join(names, ", ")
+		}
+		return &HubbleLayer4{TCP: &tcp}, []string{summary}
+	case 17:
+		return &HubbleLayer4{UDP: &ports}, []string{"UDP"}
+	case 132:
+		return &HubbleLayer4{SCTP: &ports}, []string{"SCTP"}
+	case 1:
+		return &HubbleLayer4{ICMPv4: &HubbleICMP{Type: uint32Of(r["IcmpType"]), Code: uint32Of(r["IcmpCode"])}}, []string{"ICMPv4"}
+	case 58:
+		return &HubbleLayer4{ICMPv6: &HubbleICMP{Type: uint32Of(r["IcmpType"]), Code: uint32Of(r["IcmpCode"])}}, []string{"ICMPv6"}
+	default:
+		return nil, nil
+	}
+}
+
+// hubbleEndpoint returns one side of a flow, either as a service or as an endpoint
+func hubbleEndpoint(r Record, prefix, cluster string) (*HubbleEndpoint, *HubbleService) {
+	name, namespace := str(r[prefix+"K8S_Name"]), str(r[prefix+"K8S_Namespace"])
+	kind := str(r[prefix+"K8S_Type"])
+	if kind == "Service" {
+		return nil, &HubbleService{Name: name, Namespace: namespace}
+	}
+	ep := HubbleEndpoint{ClusterName: cluster, Namespace: namespace}
+	if kind == "Pod" {
+		ep.PodName = name
+	}
+	if owner := str(r[prefix+"K8S_OwnerName"]); owner != "" && owner != name {
+		ep.Workloads = []HubbleWorkload{{Name: owner, Kind: str(r[prefix+"K8S_OwnerType"])}}
+	}
+	if ep.Namespace == "" && ep.PodName == "" && len(ep.Workloads) == 0 {
+		return nil, nil
+	}
+	return &ep, nil
+}
+
+// hubblePolicies sets the policies that allowed or denied a flow from its network events, and tells whether one denied it
+func hubblePolicies(r Record, f *HubbleFlow) bool {
+	events, _ := r["NetworkEvents"].([]any)
+	denied := false
+	for _, e := range events {
+		event, ok := e.(map[string]any)
+		if feature := str(event["Feature"]); !ok || (feature != "" && feature != "acl") {
+			// only network policy events
+			continue
+		}
+		policy := HubblePolicy{Name: str(event["Name"]), Namespace: str(event["Namespace"]), Kind: str(event["Type"])}
+		drop := strings.EqualFold(str(event["Action"]), "drop")
+		ingress := strings.EqualFold(str(event["Direction"]), "ingress")
+		switch {
+		case drop && ingress:
+			f.IngressDeniedBy = append(f.IngressDeniedBy, policy)
+		case drop:
+			f.EgressDeniedBy = append(f.EgressDeniedBy, policy)
+		case ingress:
+			f.IngressAllowedBy = append(f.IngressAllowedBy, policy)
+		default:
+			f.EgressAllowedBy = append(f.EgressAllowedBy, policy)
+		}
+		denied = denied || drop
+	}
+	return denied
+}
+
+// str returns a field as a string, keeping numbers as written, such as FlowDirection when it isn't a label
+func str(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	default:
+		return ""
+	}
+}
+
+func uint32Of(v any) uint32 {
+	if n, ok := number(v); ok && n >= 0 {
+		return uint32(n)
+	}
+	return 0
+}
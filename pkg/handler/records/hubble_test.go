@@ -0,0 +1,78 @@
+package records
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hubbleJSON(t *testing.T, line string) string {
+	out, err := json.Marshal(ToHubble(decode(t, line)))
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestToHubble_TCP(t *testing.T) {
+	out := hubbleJSON(t, `{"TimeFlowStartMs":1700000000123,"SrcMac":"0A:58:0A:80:00:01","DstMac":"0A:58:0A:80:00:02",
+		"SrcAddr":"10.128.0.1","DstAddr":"10.128.0.2","SrcPort":51000,"DstPort":8080,"Proto":6,"Flags":["SYN_ACK","PSH"],
+		"SrcK8S_Name":"front-abc","SrcK8S_Type":"Pod","SrcK8S_Namespace":"shop","SrcK8S_OwnerName":"front","SrcK8S_OwnerType":"Deployment",
+		"DstK8S_Name":"db","DstK8S_Type":"Service","DstK8S_Namespace":"shop","SrcK8S_HostName":"worker-1",
+		"K8S_ClusterName":"east","FlowDirection":"1","Packets":3}`)
+	assert.JSONEq(t, `{
+		"flow": {
+			"time": "2023-11-14T22:13:20.123Z",
+			"verdict": "FORWARDED",
+			"ethernet": {"source": "0A:58:0A:80:00:01", "destination": "0A:58:0A:80:00:02"},
+			"IP": {"source": "10.128.0.1", "destination": "10.128.0.2", "ipVersion": "IPv4"},
+			"l4": {"TCP": {"source_port": 51000, "destination_port": 8080, "flags": {"SYN": true, "ACK": true, "PSH": true}}},
+			"source": {"cluster_name": "east", "namespace": "shop", "pod_name": "front-abc", "workloads": [{"name": "front", "kind": "Deployment"}]},
+			"destination_service": {"name": "db", "namespace": "shop"},
+			"Type": "L3_L4",
+			"node_name": "east/worker-1",
+			"event_type": {"type": 4},
+			"traffic_direction": "EGRESS",
+			"Summary": "TCP Flags: SYN, PSH, ACK"
+		},
+		"node_name": "east/worker-1",
+		"time": "2023-11-14T22:13:20.123Z"
+	}`, out)
+}
+
+func TestToHubble_Drops(t *testing.T) {
+	// kernel drops
+	out := hubbleJSON(t, `{"SrcAddr":"fd00::1","DstAddr":"fd00::2","Proto":58,"IcmpType":128,"FlowDirection":0,
+		"DstK8S_HostName":"worker-2","PktDropPackets":2,"PktDropLatestDropCause":"SKB_DROP_REASON_NO_SOCKET"}`)
+	assert.JSONEq(t, `{
+		"flow": {
+			"verdict": "DROPPED",
+			"drop_reason_desc": "DROP_REASON_UNKNOWN",
+			"IP": {"source": "fd00::1", "destination": "fd00::2", "ipVersion": "IPv6"},
+			"l4": {"ICMPv6": {"type": 128}},
+			"Type": "L3_L4",
+			"node_name": "worker-2",
+			"event_type": {"type": 1},
+			"traffic_direction": "INGRESS",
+			"Summary": "ICMPv6; Drop cause: SKB_DROP_REASON_NO_SOCKET"
+		},
+		"node_name": "worker-2"
+	}`, out)
+
+	// network policies
+	r := ToHubble(decode(t, `{"Proto":17,"SrcPort":53,"DstPort":40000,"NetworkEvents":[
+		{"Feature":"acl","Type":"NetworkPolicy","Namespace":"shop","Name":"allow-dns","Action":"allow","Direction":"Egress"},
+		{"Feature":"acl","Type":"AdminNetworkPolicy","Name":"deny-all","Action":"drop","Direction":"Ingress"},
+		{"Feature":"other","Action":"drop"}]}`))
+	assert.Equal(t, "DROPPED", r.Flow.Verdict)
+	assert.Equal(t, "POLICY_DENIED", r.Flow.DropReasonDesc)
+	assert.Equal(t, []HubblePolicy{{Name: "allow-dns", Namespace: "shop", Kind: "NetworkPolicy"}}, r.Flow.EgressAllowedBy)
+	assert.Equal(t, []HubblePolicy{{Name: "deny-all", Kind: "AdminNetworkPolicy"}}, r.Flow.IngressDeniedBy)
+	assert.Empty(t, r.Flow.EgressDeniedBy)
+	assert.Equal(t, &HubblePort{SourcePort: 53, DestinationPort: 40000}, r.Flow.L4.UDP)
+
+	// allowed only
+	r = ToHubble(decode(t, `{"NetworkEvents":[{"Feature":"acl","Name":"allow","Action":"allow","Direction":"Ingress"}]}`))
+	assert.Equal(t, "FORWARDED", r.Flow.Verdict)
+	assert.Len(t, r.Flow.IngressAllowedBy, 1)
+}
@@ -0,0 +1,119 @@
+package records
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/parquet"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils"
+)
+
+// ParquetColumns derives a Parquet schema from the configured fields, restricted to columns when set.
+// Numbers are doubles, since fields don't tell integers apart, except timestamps.
+// Labels that aren't described as fields become string columns.
+func ParquetColumns(fields []config.FieldConfig, labels []string, columns []string) []parquet.Column {
+	columnsMap := utils.GetMapInterface(columns)
+	keep := func(name string) bool {
+		_, exists := columnsMap[name]
+		return exists || len(columns) == 0
+	}
+	var cols []parquet.Column
+	described := map[string]struct{}{}
+	for _, f := range fields {
+		described[f.Name] = struct{}{}
+		if !keep(f.Name) {
+			continue
+		}
+		col := parquet.Column{Name: f.Name, List: strings.HasSuffix(f.Type, "[]")}
+		switch strings.TrimSuffix(f.Type, "[]") {
+		case "number":
+			col.Type = parquet.Double
+			if _, ok := timestampUnits[f.Name]; ok && !col.List {
+				col.Type = parquet.Int64
+				col.Logical = parquet.TimestampMillis
+			}
+		default:
+			col.Type = parquet.ByteArray
+			col.Logical = parquet.String
+		}
+		cols = append(cols, col)
+	}
+	for _, l := range labels {
+		if _, ok := described[l]; !ok && keep(l) {
+			cols = append(cols, parquet.Column{Name: l, Type: parquet.ByteArray, Logical: parquet.String})
+		}
+	}
+	return cols
+}
+
+// ParquetRow returns the values of a record for the given columns
+func ParquetRow(cols []parquet.Column, r Record) ([]any, error) {
+	row := make([]any, len(cols))
+	for i := range cols {
+		col := &cols[i]
+		v, ok := r[col.Name]
+		if !ok || v == nil {
+			continue
+		}
+		if !col.List {
+			value, err := parquetValue(col, v)
+			if err != nil {
+				return nil, err
+			}
+			row[i] = value
+			continue
+		}
+		items, ok := v.([]any)
+		if !ok {
+			// single values are lists of one
+			items = []any{v}
+		}
+		values := make([]any, len(items))
+		for j, item := range items {
+			value, err := parquetValue(col, item)
+			if err != nil {
+				return nil, err
+			}
+			values[j] = value
+		}
+		row[i] = values
+	}
+	return row, nil
+}
+
+func parquetValue(col *parquet.Column, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if col.Type == parquet.ByteArray {
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	var n json.Number
+	switch x := v.(type) {
+	case json.Number:
+		n = x
+	case string:
+		n = json.Number(x)
+	default:
+		return nil, fmt.Errorf("field %s: expected a number, got %v", col.Name, v)
+	}
+	if i, err := n.Int64(); err == nil {
+		if col.Logical == parquet.TimestampMillis {
+			return i * timestampUnits[col.Name], nil
+		}
+		return i, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("field %s: expected a number, got %v", col.Name, v)
+	}
+	if col.Logical == parquet.TimestampMillis {
+		return int64(f * float64(timestampUnits[col.Name])), nil
+	}
+	return f, nil
+}
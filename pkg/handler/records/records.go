@@ -0,0 +1,80 @@
+package records
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils"
+)
+
+// timestampUnits are the timestamp fields, with their factor to milliseconds
+var timestampUnits = map[string]int64{
+	"TimeFlowStartMs": 1,
+	"TimeFlowEndMs":   1,
+	"TimeReceived":    1000,
+}
+
+// TimestampFactor tells whether a field is a timestamp, and its factor to milliseconds
+func TimestampFactor(field string) (int64, bool) {
+	f, ok := timestampUnits[field]
+	return f, ok
+}
+
+// Record is a decoded flow, merging stream labels with the fields of the JSON line
+type Record map[string]any
+
+// ForEach decodes every flow of a streams response as a record. Loki stores labels as strings: they are converted
+// according to fieldsType, so that records keep the types of the original flow. When columns are set, other fields are left out.
+func ForEach(qr *model.AggregatedQueryResponse, fieldsType map[string]string, columns []string, f func(Record) error) error {
+	streams, ok := qr.Result.(model.Streams)
+	if !ok {
+		return fmt.Errorf("loki returned an unexpected type: %T", qr.Result)
+	}
+	columnsMap := utils.GetMapInterface(columns)
+	keep := func(name string) bool {
+		_, exists := columnsMap[name]
+		return exists || len(columns) == 0
+	}
+	for _, stream := range streams {
+		labels := make(Record, len(stream.Labels))
+		for name, value := range stream.Labels {
+			if keep(name) {
+				labels[name] = typedLabel(value, fieldsType[name])
+			}
+		}
+		for _, entry := range stream.Entries {
+			var line Record
+			decoder := json.NewDecoder(strings.NewReader(entry.Line))
+			// keep numbers as written in the flow, without float rounding
+			decoder.UseNumber()
+			if err := decoder.Decode(&line); err != nil {
+				return fmt.Errorf("cannot unmarshal line %s", entry.Line)
+			}
+			record := make(Record, len(labels)+len(line))
+			for name, value := range line {
+				if keep(name) {
+					record[name] = value
+				}
+			}
+			for name, value := range labels {
+				record[name] = value
+			}
+			if err := f(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func typedLabel(value, fieldType string) any {
+	if fieldType == "number" {
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	}
+	return value
+}
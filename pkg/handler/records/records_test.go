@@ -0,0 +1,97 @@
+package records
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/parquet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func streams() *model.AggregatedQueryResponse {
+	return &model.AggregatedQueryResponse{
+		Result: model.Streams{{
+			Labels: map[string]string{"SrcK8S_Namespace": "ns1", "FlowDirection": "1"},
+			Entries: []model.Entry{
+				{Timestamp: time.Now(), Line: `{"Bytes":12345678901234567,"SrcAddr":"10.0.0.1","Interfaces":["eth0"]}`},
+				{Timestamp: time.Now(), Line: `{"Bytes":10,"SrcAddr":"10.0.0.2"}`},
+			},
+		}},
+	}
+}
+
+func TestForEach(t *testing.T) {
+	var recs []Record
+	err := ForEach(streams(), map[string]string{"FlowDirection": "number"}, nil, func(r Record) error {
+		recs = append(recs, r)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	js, err := json.Marshal(recs[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Bytes":12345678901234567,"SrcAddr":"10.0.0.1","Interfaces":["eth0"],"SrcK8S_Namespace":"ns1","FlowDirection":1}`, string(js))
+	assert.Equal(t, "10.0.0.2", recs[1]["SrcAddr"])
+}
+
+func TestForEach_Columns(t *testing.T) {
+	var recs []Record
+	err := ForEach(streams(), nil, []string{"SrcAddr", "FlowDirection"}, func(r Record) error {
+		recs = append(recs, r)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Record{"SrcAddr": "10.0.0.1", "FlowDirection": "1"}, recs[0])
+}
+
+func TestForEach_Errors(t *testing.T) {
+	err := ForEach(&model.AggregatedQueryResponse{Result: model.Matrix{}}, nil, nil, func(Record) error { return nil })
+	assert.EqualError(t, err, "loki returned an unexpected type: model.Matrix")
+
+	qr := streams()
+	qr.Result.(model.Streams)[0].Entries[1].Line = "not json"
+	err = ForEach(qr, nil, nil, func(Record) error { return nil })
+	assert.EqualError(t, err, "cannot unmarshal line not json")
+}
+
+func TestParquetRow(t *testing.T) {
+	fields := []config.FieldConfig{
+		{Name: "TimeReceived", Type: "number"},
+		{Name: "Bytes", Type: "number"},
+		{Name: "SrcAddr", Type: "string"},
+		{Name: "Interfaces", Type: "string[]"},
+		{Name: "FlowDirection", Type: "number"},
+	}
+	cols := ParquetColumns(fields, []string{"FlowDirection", "SrcK8S_Namespace"}, nil)
+	assert.Equal(t, []parquet.Column{
+		{Name: "TimeReceived", Type: parquet.Int64, Logical: parquet.TimestampMillis},
+		{Name: "Bytes", Type: parquet.Double},
+		{Name: "SrcAddr", Type: parquet.ByteArray, Logical: parquet.String},
+		{Name: "Interfaces", Type: parquet.ByteArray, Logical: parquet.String, List: true},
+		{Name: "FlowDirection", Type: parquet.Double},
+		{Name: "SrcK8S_Namespace", Type: parquet.ByteArray, Logical: parquet.String},
+	}, cols)
+
+	var rows [][]any
+	qr := streams()
+	qr.Result.(model.Streams)[0].Entries[0].Line = `{"TimeReceived":1700000000,"Bytes":12345678901234567,"SrcAddr":"10.0.0.1","Interfaces":["eth0"]}`
+	err := ForEach(qr, map[string]string{"FlowDirection": "number"}, nil, func(r Record) error {
+		row, err := ParquetRow(cols, r)
+		rows = append(rows, row)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(1700000000000), int64(12345678901234567), "10.0.0.1", []any{"eth0"}, int64(1), "ns1"}, rows[0])
+	assert.Equal(t, []any{nil, int64(10), "10.0.0.2", nil, int64(1), "ns1"}, rows[1])
+
+	// Columns
+	cols = ParquetColumns(fields, []string{"SrcK8S_Namespace"}, []string{"Bytes", "SrcK8S_Namespace"})
+	assert.Equal(t, []parquet.Column{
+		{Name: "Bytes", Type: parquet.Double},
+		{Name: "SrcK8S_Namespace", Type: parquet.ByteArray, Logical: parquet.String},
+	}, cols)
+}
@@ -0,0 +1,266 @@
+package records
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	zeekUnset      = "-"
+	zeekTimeFormat = "2006-01-02-15-04-05"
+
+	recordTypeFlowLog = "flowLog"
+	recordTypeEnd     = "endConnection"
+)
+
+// zeekConnFields are the fields of Zeek conn.log, with their types
+var zeekConnFields = [][2]string{
+	{"ts", "time"},
+	{"uid", "string"},
+	{"id.orig_h", "addr"},
+	{"id.orig_p", "port"},
+	{"id.resp_h", "addr"},
+	{"id.resp_p", "port"},
+	{"proto", "enum"},
+	{"service", "string"},
+	{"duration", "interval"},
+	{"orig_bytes", "count"},
+	{"resp_bytes", "count"},
+	{"conn_state", "string"},
+	{"local_orig", "bool"},
+	{"local_resp", "bool"},
+	{"missed_bytes", "count"},
+	{"history", "string"},
+	{"orig_pkts", "count"},
+	{"orig_ip_bytes", "count"},
+	{"resp_pkts", "count"},
+	{"resp_ip_bytes", "count"},
+	{"tunnel_parents", "set[string]"},
+}
+
+// tcpFlagBits are the bits of TCP flags, when flows have them as a number
+var tcpFlagBits = map[string]int64{
+	"FIN":     0x1,
+	"SYN":     0x2,
+	"RST":     0x4,
+	"PSH":     0x8,
+	"ACK":     0x10,
+	"URG":     0x20,
+	"ECE":     0x40,
+	"CWR":     0x80,
+	"SYN_ACK": 0x100,
+	"FIN_ACK": 0x200,
+	"RST_ACK": 0x400,
+}
+
+var zeekEscape = strings.NewReplacer("\\", `\x5c`, "\t", `\x09`, "\n", `\x0a`)
+
+// ZeekConnHeader returns the header block of a Zeek conn.log opened at the given time
+func ZeekConnHeader(open time.Time) string {
+	names := make([]string, 0, len(zeekConnFields))
+	types := make([]string, 0, len(zeekConnFields))
+	for _, f := range zeekConnFields {
+		names = append(names, f[0])
+		types = append(types, f[1])
+	}
+	return `#separator \x09` + "\n" +
+		"#set_separator\t,\n" +
+		"#empty_field\t(empty)\n" +
+		"#unset_field\t" + zeekUnset + "\n" +
+		"#path\tconn\n" +
+		"#open\t" + open.UTC().Format(zeekTimeFormat) + "\n" +
+		"#fields\t" + strings.Join(names, "\t") + "\n" +
+		"#types\t" + strings.Join(types, "\t") + "\n"
+}
+
+// ZeekConnFooter returns the line closing a Zeek conn.log
+func ZeekConnFooter(closed time.Time) string {
+	return "#close\t" + closed.UTC().Format(zeekTimeFormat) + "\n"
+}
+
+// ZeekConnRow maps a flow to the fields of Zeek conn.log. Flows have IP-level counters: payload bytes are unset.
+// Conversation tracking records, which have counters for both directions, get a connection state.
+func ZeekConnRow(r Record) []string {
+	proto := zeekProto(r)
+	origP, respP := r["SrcPort"], r["DstPort"]
+	if proto == "icmp" {
+		// as Zeek does, ICMP type and code stand for ports
+		origP, respP = r["IcmpType"], r["IcmpCode"]
+	}
+	start, hasStart := number(r["TimeFlowStartMs"])
+	end, hasEnd := number(r["TimeFlowEndMs"])
+	ts, duration := zeekUnset, zeekUnset
+	if hasStart {
+		ts = strconv.FormatFloat(start/1000, 'f', 6, 64)
+		if hasEnd {
+			duration = strconv.FormatFloat((end-start)/1000, 'f', 6, 64)
+		}
+	}
+	origBytes, origPkts := firstOf(r, "Bytes_AB", "Bytes"), firstOf(r, "Packets_AB", "Packets")
+	respBytes, respPkts := r["Bytes_BA"], r["Packets_BA"]
+
+	return []string{
+		ts,
+		zeekUID(r),
+		zeekString(r["SrcAddr"]),
+		zeekCount(origP),
+		zeekString(r["DstAddr"]),
+		zeekCount(respP),
+		proto,
+		zeekUnset,
+		duration,
+		zeekUnset,
+		zeekUnset,
+		zeekConnState(r, proto),
+		zeekUnset,
+		zeekUnset,
+		"0",
+		zeekUnset,
+		zeekCount(origPkts),
+		zeekCount(origBytes),
+		zeekCount(respPkts),
+		zeekCount(respBytes),
+		zeekUnset,
+	}
+}
+
+func zeekProto(r Record) string {
+	proto, _ := number(r["Proto"])
+	switch proto {
+	case 6:
+		return "tcp"
+	case 17:
+		return "udp"
+	case 1, 58:
+		return "icmp"
+	default:
+		return "unknown_transport"
+	}
+}
+
+// zeekUID identifies connections: records of a same conversation share their uid
+func zeekUID(r Record) string {
+	key := fmt.Sprint(r["_HashId"])
+	if _, ok := r["_HashId"]; !ok {
+		key = fmt.Sprint(r["SrcAddr"], r["SrcPort"], r["DstAddr"], r["DstPort"], r["Proto"], r["TimeFlowStartMs"], r["TimeFlowEndMs"], r["FlowDirection"])
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "C" + base62(binary.BigEndian.Uint64(sum[:8])) + base62(binary.BigEndian.Uint64(sum[8:16]))
+}
+
+// zeekConnState approximates Zeek connection states from conversation tracking records. As TCP flags of both directions
+// are merged in conversations, resets can't be attributed to a side: they are reported as RSTO. Flow logs, which only
+// see one direction, have no state.
+func zeekConnState(r Record, proto string) string {
+	recordType, _ := r["_RecordType"].(string)
+	if recordType == "" || recordType == recordTypeFlowLog {
+		return zeekUnset
+	}
+	reply := false
+	if pkts, ok := number(r["Packets_BA"]); ok && pkts > 0 {
+		reply = true
+	}
+	if proto != "tcp" {
+		if reply {
+			return "SF"
+		}
+		return "S0"
+	}
+	flags := tcpFlags(r["Flags"])
+	synAck := flags["SYN_ACK"]
+	acked := synAck || flags["ACK"]
+	switch {
+	case !flags["SYN"] && !synAck:
+		return "OTH"
+	case flags["RST"] || flags["RST_ACK"]:
+		if !acked {
+			return "REJ"
+		}
+		return "RSTO"
+	case !acked && !reply:
+		return "S0"
+	case (flags["FIN"] || flags["FIN_ACK"]) && recordType == recordTypeEnd:
+		return "SF"
+	default:
+		return "S1"
+	}
+}
+
+// tcpFlags returns the set of TCP flags, from either a list of names or a number
+func tcpFlags(v any) map[string]bool {
+	flags := map[string]bool{}
+	switch f := v.(type) {
+	case []any:
+		for _, name := range f {
+			if s, ok := name.(string); ok {
+				flags[s] = true
+			}
+		}
+	default:
+		if n, ok := number(v); ok {
+			for name, bit := range tcpFlagBits {
+				flags[name] = int64(n)&bit != 0
+			}
+		}
+	}
+	return flags
+}
+
+func firstOf(r Record, names ...string) any {
+	for _, name := range names {
+		if v, ok := r[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func zeekCount(v any) string {
+	if n, ok := number(v); ok {
+		return strconv.FormatInt(int64(n), 10)
+	}
+	return zeekUnset
+}
+
+func zeekString(v any) string {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return zeekUnset
+	}
+	return zeekEscape.Replace(s)
+}
+
+func base62(n uint64) string {
+	const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	if n == 0 {
+		return "0"
+	}
+	var b []byte
+	for n > 0 {
+		b = append(b, digits[n%62])
+		n /= 62
+	}
+	slices.Reverse(b)
+	return string(b)
+}
@@ -0,0 +1,76 @@
+package records
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, line string) Record {
+	var r Record
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&r))
+	return r
+}
+
+func TestZeekConnHeader(t *testing.T) {
+	header := ZeekConnHeader(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	lines := strings.Split(strings.TrimSuffix(header, "\n"), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, `#separator \x09`, lines[0])
+	assert.Equal(t, "#open\t2024-01-02-03-04-05", lines[5])
+	fields := strings.Split(lines[6], "\t")
+	types := strings.Split(lines[7], "\t")
+	assert.Equal(t, "#fields", fields[0])
+	assert.Equal(t, "id.orig_h", fields[3])
+	assert.Len(t, types, len(fields))
+	assert.Len(t, ZeekConnRow(Record{}), len(fields)-1)
+}
+
+func TestZeekConnRow_FlowLog(t *testing.T) {
+	r := decode(t, `{"SrcAddr":"10.0.0.1","SrcPort":51000,"DstAddr":"10.0.0.2","DstPort":443,"Proto":6,"Bytes":1500,"Packets":3,
+		"TimeFlowStartMs":1700000000123,"TimeFlowEndMs":1700000002623,"Flags":["SYN","ACK"]}`)
+	row := ZeekConnRow(r)
+	assert.Equal(t, "1700000000.123000", row[0])
+	assert.Regexp(t, `^C[0-9A-Za-z]+$`, row[1])
+	assert.Equal(t, []string{"10.0.0.1", "51000", "10.0.0.2", "443", "tcp", "-", "2.500000", "-", "-", "-", "-", "-", "0", "-", "3", "1500", "-", "-", "-"}, row[2:])
+
+	// ICMP type and code stand for ports
+	r = decode(t, `{"SrcAddr":"10.0.0.1","DstAddr":"10.0.0.2","Proto":1,"IcmpType":8,"IcmpCode":0}`)
+	row = ZeekConnRow(r)
+	assert.Equal(t, []string{"-", "10.0.0.1", "8", "10.0.0.2", "0", "icmp"}, append([]string{row[0]}, row[2:7]...))
+}
+
+func TestZeekConnRow_Conversations(t *testing.T) {
+	conn := func(recordType, flags, replies string) Record {
+		return decode(t, `{"_RecordType":"`+recordType+`","_HashId":"abc","Proto":6,"Flags":`+flags+`,
+			"Bytes_AB":100,"Bytes_BA":200,"Packets_AB":2,"Packets_BA":`+replies+`}`)
+	}
+	state := func(r Record) string { return ZeekConnRow(r)[11] }
+
+	assert.Equal(t, "SF", state(conn("endConnection", `["SYN","SYN_ACK","ACK","FIN"]`, "1")))
+	assert.Equal(t, "S1", state(conn("heartbeat", `["SYN","SYN_ACK","ACK"]`, "1")))
+	assert.Equal(t, "S1", state(conn("endConnection", `["SYN","SYN_ACK","ACK"]`, "1")))
+	assert.Equal(t, "S0", state(conn("endConnection", `["SYN"]`, "0")))
+	assert.Equal(t, "REJ", state(conn("endConnection", `["SYN","RST"]`, "1")))
+	assert.Equal(t, "RSTO", state(conn("endConnection", `["SYN","SYN_ACK","ACK","RST"]`, "1")))
+	assert.Equal(t, "OTH", state(conn("endConnection", `["ACK","PSH"]`, "1")))
+	// flags as a number: SYN | ACK | FIN
+	assert.Equal(t, "SF", state(conn("endConnection", `19`, "1")))
+
+	row := ZeekConnRow(conn("endConnection", `[]`, "1"))
+	assert.Equal(t, []string{"2", "100", "1", "200"}, row[16:20])
+	// records of a same conversation share their uid
+	assert.Equal(t, row[1], ZeekConnRow(conn("newConnection", `["SYN"]`, "0"))[1])
+
+	// non-TCP conversations
+	udp := decode(t, `{"_RecordType":"endConnection","Proto":17,"Packets_AB":1,"Packets_BA":1}`)
+	assert.Equal(t, "SF", state(udp))
+	udp["Packets_BA"] = json.Number("0")
+	assert.Equal(t, "S0", state(udp))
+}
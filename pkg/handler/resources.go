@@ -19,10 +19,14 @@ import (
 func (h *Handlers) GetClusters(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := r.URL.Query()
-		namespace := params.Get(namespaceKey)
+		namespace, nerr := getNamespace(params)
+		if nerr != nil {
+			apierrors.Write(w, http.StatusBadRequest, nerr)
+			return
+		}
 		isDev := namespace != ""
 
-		clients, err := newClients(h.Cfg, r.Header, false, namespace)
+		clients, err := newClients(h.Cfg, r.Header, false, namespace, nil)
 		if err != nil {
 			err.Write(w, http.StatusInternalServerError)
 			return
@@ -34,7 +38,7 @@ func (h *Handlers) GetClusters(ctx context.Context) func(w http.ResponseWriter,
 		}()
 
 		// Fetch and merge values for K8S_ClusterName
-		values, code, err := h.getLabelValues(ctx, clients, fields.Cluster, isDev)
+		values, code, err := h.getLabelValues(r.Context(), clients, fields.Cluster, isDev)
 		if err != nil {
 			err.Write(w, code)
 			return
@@ -48,10 +52,14 @@ func (h *Handlers) GetClusters(ctx context.Context) func(w http.ResponseWriter,
 func (h *Handlers) GetUDNs(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := r.URL.Query()
-		namespace := params.Get(namespaceKey)
+		namespace, nerr := getNamespace(params)
+		if nerr != nil {
+			apierrors.Write(w, http.StatusBadRequest, nerr)
+			return
+		}
 		isDev := namespace != ""
 
-		clients, err := newClients(h.Cfg, r.Header, false, namespace)
+		clients, err := newClients(h.Cfg, r.Header, false, namespace, nil)
 		if err != nil {
 			err.Write(w, http.StatusInternalServerError)
 			return
@@ -63,7 +71,7 @@ func (h *Handlers) GetUDNs(ctx context.Context) func(w http.ResponseWriter, r *h
 		}()
 
 		// Fetch and merge values for K8S_ClusterName
-		values, code, err := h.getLabelValues(ctx, clients, fields.UDN, isDev)
+		values, code, err := h.getLabelValues(r.Context(), clients, fields.UDN, isDev)
 		if err != nil {
 			err.Write(w, code)
 			return
@@ -77,10 +85,14 @@ func (h *Handlers) GetUDNs(ctx context.Context) func(w http.ResponseWriter, r *h
 func (h *Handlers) GetZones(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := r.URL.Query()
-		namespace := params.Get(namespaceKey)
+		namespace, nerr := getNamespace(params)
+		if nerr != nil {
+			apierrors.Write(w, http.StatusBadRequest, nerr)
+			return
+		}
 		isDev := namespace != ""
 
-		clients, err := newClients(h.Cfg, r.Header, false, namespace)
+		clients, err := newClients(h.Cfg, r.Header, false, namespace, nil)
 		if err != nil {
 			err.Write(w, http.StatusInternalServerError)
 			return
@@ -91,23 +103,12 @@ func (h *Handlers) GetZones(ctx context.Context) func(w http.ResponseWriter, r *
 			metrics.ObserveHTTPCall("GetZones", code, startTime)
 		}()
 
-		// Initialize values explicitly to avoid null json when empty
-		values := []string{}
-
 		// Fetch and merge values for SrcK8S_Zone and DstK8S_Zone
-		values1, code, err := h.getLabelValues(ctx, clients, fields.SrcZone, isDev)
+		values, code, err := h.getSrcDstLabelValues(r.Context(), clients, fields.SrcZone, fields.DstZone, isDev)
 		if err != nil {
 			err.Write(w, code)
 			return
 		}
-		values = append(values, values1...)
-
-		values2, code, err := h.getLabelValues(ctx, clients, fields.DstZone, isDev)
-		if err != nil {
-			err.Write(w, code)
-			return
-		}
-		values = append(values, values2...)
 
 		code = http.StatusOK
 		writeJSON(w, code, utils.NonEmpty(utils.Dedup(values)))
@@ -115,32 +116,43 @@ func (h *Handlers) GetZones(ctx context.Context) func(w http.ResponseWriter, r *
 }
 
 func (h *Handlers) getNamespacesValues(ctx context.Context, clients clients, isDev bool) ([]string, int, apierrors.StructuredError) {
-	// Initialize values explicitly to avoid null json when empty
-	values := []string{}
-
 	// Fetch and merge values for SrcK8S_Namespace and DstK8S_Namespace
-	values1, code, err := h.getLabelValues(ctx, clients, fields.SrcNamespace, isDev)
-	if err != nil {
-		return []string{}, code, err
-	}
-	values = append(values, values1...)
+	return h.getSrcDstLabelValues(ctx, clients, fields.SrcNamespace, fields.DstNamespace, isDev)
+}
 
-	values2, code, err := h.getLabelValues(ctx, clients, fields.DstNamespace, isDev)
-	if err != nil {
-		return []string{}, code, err
+// getSrcDstLabelValues fetches values of the source and destination labels concurrently, and merges them
+func (h *Handlers) getSrcDstLabelValues(ctx context.Context, clients clients, srcLabel, dstLabel string, isDev bool) ([]string, int, apierrors.StructuredError) {
+	var srcValues, dstValues []string
+	var srcCode, dstCode int
+	var srcErr, dstErr apierrors.StructuredError
+	parallel(
+		func() { srcValues, srcCode, srcErr = h.getLabelValues(ctx, clients, srcLabel, isDev) },
+		func() { dstValues, dstCode, dstErr = h.getLabelValues(ctx, clients, dstLabel, isDev) },
+	)
+	if srcErr != nil {
+		return []string{}, srcCode, srcErr
 	}
-	values = append(values, values2...)
-
+	if dstErr != nil {
+		return []string{}, dstCode, dstErr
+	}
+	// Initialize values explicitly to avoid null json when empty
+	values := []string{}
+	values = append(values, srcValues...)
+	values = append(values, dstValues...)
 	return values, http.StatusOK, nil
 }
 
 func (h *Handlers) GetNamespaces(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := r.URL.Query()
-		namespace := params.Get(namespaceKey)
+		namespace, nerr := getNamespace(params)
+		if nerr != nil {
+			apierrors.Write(w, http.StatusBadRequest, nerr)
+			return
+		}
 		isDev := namespace != ""
 
-		clients, err := newClients(h.Cfg, r.Header, false, namespace)
+		clients, err := newClients(h.Cfg, r.Header, false, namespace, nil)
 		if err != nil {
 			err.Write(w, http.StatusInternalServerError)
 			return
@@ -151,7 +163,7 @@ func (h *Handlers) GetNamespaces(ctx context.Context) func(w http.ResponseWriter
 			metrics.ObserveHTTPCall("GetNamespaces", code, startTime)
 		}()
 
-		values, code, err := h.getNamespacesValues(ctx, clients, isDev)
+		values, code, err := h.getNamespacesValues(r.Context(), clients, isDev)
 		if err != nil {
 			err.Write(w, code)
 			return
@@ -164,7 +176,14 @@ func (h *Handlers) getLabelValues(ctx context.Context, cl clients, label string,
 	if h.PromInventory != nil && h.PromInventory.LabelExists(label) {
 		client := cl.getPromClient(isDev)
 		if client != nil {
-			resp, code, err := prometheus.GetLabelValues(ctx, client, label, nil)
+			var resp []string
+			var code int
+			var err error
+			if perr := cl.promPool.run(ctx, cl.user, func() {
+				resp, code, err = prometheus.GetLabelValues(ctx, client, label, nil)
+			}); perr != nil {
+				return nil, http.StatusServiceUnavailable, apierrors.NewPromClientError(perr)
+			}
 			if err != nil {
 				if code == http.StatusUnauthorized || code == http.StatusForbidden {
 					// In case this was a prometheus 401 / 403 error, the query is repeated with Loki
@@ -181,11 +200,9 @@ func (h *Handlers) getLabelValues(ctx context.Context, cl clients, label string,
 		}
 	}
 	if cl.loki != nil {
-		resp, code, err := getLokiLabelValues(h.Cfg.Loki.URL, cl.loki, label)
-		if err != nil {
-			return nil, code, err
-		}
-		return resp, code, nil
+		return cl.runLoki(ctx, func() ([]string, int, apierrors.StructuredError) {
+			return getLokiLabelValues(h.Cfg.Loki.URL, cl.loki, label)
+		})
 	}
 	// Loki disabled AND label not managed in metrics => send an error
 	return nil, http.StatusBadRequest, apierrors.NewPromMissingLabels([]string{label})
@@ -194,10 +211,14 @@ func (h *Handlers) getLabelValues(ctx context.Context, cl clients, label string,
 func (h *Handlers) GetNames(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := r.URL.Query()
-		namespace := params.Get(namespaceKey)
+		namespace, nerr := getNamespace(params)
+		if nerr != nil {
+			apierrors.Write(w, http.StatusBadRequest, nerr)
+			return
+		}
 		kind := params.Get("kind")
 
-		clients, err := newClients(h.Cfg, r.Header, false, namespace)
+		clients, err := newClients(h.Cfg, r.Header, false, namespace, nil)
 		if err != nil {
 			err.Write(w, http.StatusInternalServerError)
 			return
@@ -208,23 +229,34 @@ func (h *Handlers) GetNames(ctx context.Context) func(w http.ResponseWriter, r *
 			metrics.ObserveHTTPCall("GetNames", code, startTime)
 		}()
 
-		// Initialize names explicitly to avoid null json when empty
-		names := []string{}
-
-		// TODO: parallelize
-		names1, code, err := h.getNamesForPrefix(ctx, clients, fields.Src, kind, namespace)
-		if err != nil {
-			err.Write(w, code)
+		// queries are bound to the request, to stop waiting for a slot when the client disconnects
+		queryCtx := r.Context()
+		var srcNames, dstNames []string
+		var srcCode, dstCode int
+		var srcErr, dstErr apierrors.StructuredError
+		parallel(
+			func() {
+				srcNames, srcCode, srcErr = h.getNamesForPrefix(queryCtx, clients, fields.Src, kind, namespace)
+			},
+			func() {
+				dstNames, dstCode, dstErr = h.getNamesForPrefix(queryCtx, clients, fields.Dst, kind, namespace)
+			},
+		)
+		if srcErr != nil {
+			code = srcCode
+			srcErr.Write(w, code)
 			return
 		}
-		names = append(names, names1...)
-
-		names2, code, err := h.getNamesForPrefix(ctx, clients, fields.Dst, kind, namespace)
-		if err != nil {
-			err.Write(w, code)
+		if dstErr != nil {
+			code = dstCode
+			dstErr.Write(w, code)
 			return
 		}
-		names = append(names, names2...)
+
+		// Initialize names explicitly to avoid null json when empty
+		names := []string{}
+		names = append(names, srcNames...)
+		names = append(names, dstNames...)
 
 		code = http.StatusOK
 		writeJSON(w, code, utils.NonEmpty(utils.Dedup(names)))
@@ -248,13 +280,22 @@ func (h *Handlers) getNamesForPrefix(ctx context.Context, cl clients, prefix, ki
 	if h.Cfg.IsPromEnabled() && h.PromInventory.LabelExists(searchField) {
 		// Label match query (any metric)
 		q := prometheus.QueryFilters("", filts)
-		values, code, err := prometheus.GetLabelValues(ctx, cl.promAdmin, searchField, []string{q})
+		var values []string
+		var code int
+		var err error
+		if perr := cl.promPool.run(ctx, cl.user, func() {
+			values, code, err = prometheus.GetLabelValues(ctx, cl.promAdmin, searchField, []string{q})
+		}); perr != nil {
+			return nil, http.StatusServiceUnavailable, apierrors.NewPromClientError(perr)
+		}
 		if err != nil {
 			return values, code, apierrors.NewPromClientError(err)
 		}
 		return values, code, nil
 	}
-	return getLokiNamesForPrefix(&h.Cfg.Loki, cl.loki, filts, searchField)
+	return cl.runLoki(ctx, func() ([]string, int, apierrors.StructuredError) {
+		return getLokiNamesForPrefix(&h.Cfg.Loki, cl.loki, filts, searchField)
+	})
 }
 
 func exact(str string) string {
@@ -0,0 +1,360 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/alertingmock"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/fields"
+	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
+	"github.com/prometheus/client_golang/api"
+	pmod "github.com/prometheus/common/model"
+)
+
+const (
+	silenceIDKey       = "id"
+	maxSilenceBodySize = 1 << 20
+)
+
+var (
+	// Labels holding the namespace of netobserv alerts
+	silenceNamespaceLabels = []string{"namespace", "exported_namespace", fields.SrcNamespace, fields.DstNamespace}
+	silenceIDRegexp        = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+)
+
+// silencesSource abstracts Alertmanager, so that alertingmock can be used instead
+type silencesSource interface {
+	list(ctx context.Context) ([]model.Silence, int, error)
+	get(ctx context.Context, id string) (model.Silence, int, error)
+	create(ctx context.Context, s *model.Silence) (string, int, error)
+	expire(ctx context.Context, id string) (int, error)
+}
+
+type alertManagerSilences struct {
+	client api.Client
+}
+
+func (s *alertManagerSilences) list(ctx context.Context) ([]model.Silence, int, error) {
+	return prometheus.ListSilences(ctx, s.client, nil)
+}
+
+func (s *alertManagerSilences) get(ctx context.Context, id string) (model.Silence, int, error) {
+	return prometheus.GetSilence(ctx, s.client, id)
+}
+
+func (s *alertManagerSilences) create(ctx context.Context, silence *model.Silence) (string, int, error) {
+	return prometheus.CreateSilence(ctx, s.client, silence)
+}
+
+func (s *alertManagerSilences) expire(ctx context.Context, id string) (int, error) {
+	return prometheus.ExpireSilence(ctx, s.client, id)
+}
+
+type mockSilences struct{}
+
+func (s *mockSilences) list(_ context.Context) ([]model.Silence, int, error) {
+	return alertingmock.ListSilences(), http.StatusOK, nil
+}
+
+func (s *mockSilences) get(_ context.Context, id string) (model.Silence, int, error) {
+	silence, ok := alertingmock.GetSilence(id)
+	if !ok {
+		return silence, http.StatusNotFound, fmt.Errorf("silence %s not found", id)
+	}
+	return silence, http.StatusOK, nil
+}
+
+func (s *mockSilences) create(_ context.Context, silence *model.Silence) (string, int, error) {
+	return alertingmock.CreateSilence(*silence), http.StatusOK, nil
+}
+
+func (s *mockSilences) expire(_ context.Context, id string) (int, error) {
+	if !alertingmock.ExpireSilence(id) {
+		return http.StatusNotFound, fmt.Errorf("silence %s not found", id)
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Handlers) newSilencesSource(requestHeader http.Header) (silencesSource, int, error) {
+	if h.useAlertingMocks() {
+		return &mockSilences{}, http.StatusOK, nil
+	}
+	if h.Cfg.Prometheus.AlertManager.URL == "" {
+		return nil, http.StatusBadRequest, errors.New("silences require Alertmanager to be configured")
+	}
+	client, err := prometheus.NewAlertManagerClient(&h.Cfg.Prometheus, requestHeader)
+	if err != nil {
+		return nil, http.StatusInternalServerError, apierrors.NewPromClientError(err)
+	}
+	return &alertManagerSilences{client: client}, http.StatusOK, nil
+}
+
+// namespaceAccess checks and caches namespace access for the duration of a request; admins can access all namespaces
+type namespaceAccess struct {
+	ctx     context.Context
+	h       *Handlers
+	header  http.Header
+	isAdmin bool
+	checked map[string]error
+}
+
+func (h *Handlers) newNamespaceAccess(ctx context.Context, header http.Header) *namespaceAccess {
+	return &namespaceAccess{
+		ctx:     ctx,
+		h:       h,
+		header:  header,
+		isAdmin: h.AuthChecker.CheckAdmin(ctx, header) == nil,
+		checked: map[string]error{},
+	}
+}
+
+// checkSilence verifies that a non-admin user can access every namespace targeted by the silence
+func (a *namespaceAccess) checkSilence(s *model.Silence) error {
+	if a.isAdmin {
+		return nil
+	}
+	namespaces, err := silenceNamespaces(s)
+	if err != nil {
+		return err
+	}
+	if len(namespaces) == 0 {
+		return fmt.Errorf("silences must be restricted to namespaces using one of these labels: %v", silenceNamespaceLabels)
+	}
+	for _, ns := range namespaces {
+		err, ok := a.checked[ns]
+		if !ok {
+			err = a.h.AuthChecker.CheckNamespace(a.ctx, a.header, ns)
+			a.checked[ns] = err
+		}
+		if err != nil {
+			return fmt.Errorf("namespace %s is not accessible: %w", ns, err)
+		}
+	}
+	return nil
+}
+
+// silenceNamespaces returns the namespaces targeted by the silence; only equality matchers can be checked
+func silenceNamespaces(s *model.Silence) ([]string, error) {
+	var namespaces []string
+	for i := range s.Matchers {
+		m := &s.Matchers[i]
+		if !slices.Contains(silenceNamespaceLabels, m.Name) {
+			continue
+		}
+		if !m.IsEqualityMatch() {
+			return nil, fmt.Errorf("matcher on %s must be an equality matcher", m.Name)
+		}
+		namespaces = append(namespaces, m.Value)
+	}
+	return namespaces, nil
+}
+
+// isNetobservSilence tells whether the silence is scoped to netobserv alerts:
+// health alerts, netobserv-owned alerts, or alerts from the configured alert namespaces
+func (h *Handlers) isNetobservSilence(s *model.Silence) bool {
+	for i := range s.Matchers {
+		m := &s.Matchers[i]
+		if !m.IsEqualityMatch() {
+			continue
+		}
+		switch m.Name {
+		case netobsLabel:
+			if m.Value == "true" {
+				return true
+			}
+		case "app":
+			if m.Value == "netobserv" {
+				return true
+			}
+		case "namespace":
+			if slices.Contains(h.Cfg.Frontend.AlertNamespaces, m.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h *Handlers) validateSilence(s *model.Silence, now time.Time) error {
+	if s.ID != "" {
+		return errors.New("updating a silence is not supported, expire it and create a new one")
+	}
+	if len(s.Matchers) == 0 {
+		return errors.New("at least one matcher is required")
+	}
+	for i := range s.Matchers {
+		m := &s.Matchers[i]
+		if !pmod.LabelName(m.Name).IsValidLegacy() {
+			return fmt.Errorf("invalid matcher label name: '%s'", m.Name)
+		}
+		if m.Value == "" {
+			return fmt.Errorf("matcher on %s has an empty value", m.Name)
+		}
+		if m.IsRegex {
+			if _, err := regexp.Compile("^(?:" + m.Value + ")$"); err != nil {
+				return fmt.Errorf("invalid regex in matcher on %s: %w", m.Name, err)
+			}
+		}
+	}
+	if !h.isNetobservSilence(s) {
+		return fmt.Errorf("silence must target netobserv alerts, using one of these matchers: netobserv=\"true\", app=\"netobserv\", namespace=<one of %v>", h.Cfg.Frontend.AlertNamespaces)
+	}
+	if s.Comment == "" {
+		return errors.New("comment is required")
+	}
+	if s.StartsAt.IsZero() {
+		s.StartsAt = now
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return errors.New("silence must end after it starts")
+	}
+	if !s.EndsAt.After(now) {
+		return errors.New("silence must end in the future")
+	}
+	return nil
+}
+
+func (h *Handlers) GetSilences(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("GetSilences", code, startTime)
+		}()
+
+		src, code, err := h.newSilencesSource(r.Header)
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		all, code, err := src.list(ctx)
+		if err != nil {
+			apierrors.Write(w, code, apierrors.NewPromClientError(err))
+			return
+		}
+
+		access := h.newNamespaceAccess(ctx, r.Header)
+		res := []model.Silence{}
+		for i := range all {
+			if h.isNetobservSilence(&all[i]) && access.checkSilence(&all[i]) == nil {
+				res = append(res, all[i])
+			}
+		}
+		code = http.StatusOK
+		writeJSON(w, code, res)
+	}
+}
+
+func (h *Handlers) CreateSilence(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("CreateSilence", code, startTime)
+		}()
+
+		var silence model.Silence
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxSilenceBodySize)).Decode(&silence); err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, fmt.Errorf("cannot decode silence: %w", err))
+			return
+		}
+		if err := h.validateSilence(&silence, time.Now()); err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+		if err := h.newNamespaceAccess(ctx, r.Header).checkSilence(&silence); err != nil {
+			code = http.StatusForbidden
+			apierrors.Write(w, code, err)
+			return
+		}
+
+		// Record the authenticated user as creator; when auth is disabled, keep the provided value
+		username, err := h.AuthChecker.GetUsername(ctx, r.Header)
+		if err != nil {
+			code = http.StatusUnauthorized
+			apierrors.Write(w, code, err)
+			return
+		}
+		if username != "" {
+			silence.CreatedBy = username
+		} else if silence.CreatedBy == "" {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, errors.New("createdBy is required"))
+			return
+		}
+		silence.Status = nil
+		silence.UpdatedAt = nil
+
+		src, code, err := h.newSilencesSource(r.Header)
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		id, code, err := src.create(ctx, &silence)
+		if err != nil {
+			apierrors.Write(w, code, apierrors.NewPromClientError(err))
+			return
+		}
+		hlog.Infof("Silence %s created by %s", id, silence.CreatedBy)
+		code = http.StatusOK
+		writeJSON(w, code, map[string]string{"silenceID": id})
+	}
+}
+
+func (h *Handlers) ExpireSilence(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("ExpireSilence", code, startTime)
+		}()
+
+		id := mux.Vars(r)[silenceIDKey]
+		if !silenceIDRegexp.MatchString(id) {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, fmt.Errorf("invalid silence id: '%s'", id))
+			return
+		}
+
+		src, code, err := h.newSilencesSource(r.Header)
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		silence, code, err := src.get(ctx, id)
+		if err != nil {
+			apierrors.Write(w, code, apierrors.NewPromClientError(err))
+			return
+		}
+		if !h.isNetobservSilence(&silence) {
+			code = http.StatusForbidden
+			apierrors.Write(w, code, fmt.Errorf("silence %s does not target netobserv alerts", id))
+			return
+		}
+		if err := h.newNamespaceAccess(ctx, r.Header).checkSilence(&silence); err != nil {
+			code = http.StatusForbidden
+			apierrors.Write(w, code, err)
+			return
+		}
+		code, err = src.expire(ctx, id)
+		if err != nil {
+			apierrors.Write(w, code, apierrors.NewPromClientError(err))
+			return
+		}
+		code = http.StatusOK
+		writeJSON(w, code, map[string]string{"silenceID": id})
+	}
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeChecker struct {
+	admin      bool
+	username   string
+	namespaces []string
+}
+
+func (c *fakeChecker) CheckAuth(_ context.Context, _ http.Header) error {
+	return nil
+}
+
+func (c *fakeChecker) CheckAdmin(_ context.Context, _ http.Header) error {
+	if c.admin {
+		return nil
+	}
+	return errors.New("user not an admin")
+}
+
+func (c *fakeChecker) CheckNamespace(_ context.Context, _ http.Header, namespace string) error {
+	for _, ns := range c.namespaces {
+		if ns == namespace {
+			return nil
+		}
+	}
+	return errors.New("access denied")
+}
+
+func (c *fakeChecker) GetUsername(_ context.Context, _ http.Header) (string, error) {
+	return c.username, nil
+}
+
+func silenceHandlers(checker *fakeChecker) *Handlers {
+	return &Handlers{
+		Cfg: &config.Config{
+			Loki:     config.Loki{UseMocks: true},
+			Frontend: config.Frontend{AlertNamespaces: []string{"netobserv"}},
+		},
+		AuthChecker: checker,
+	}
+}
+
+func silenceRouter(h *Handlers) *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/silences", h.GetSilences(context.TODO())).Methods(http.MethodGet)
+	r.HandleFunc("/silences", h.CreateSilence(context.TODO())).Methods(http.MethodPost)
+	r.HandleFunc("/silences/{id}", h.ExpireSilence(context.TODO())).Methods(http.MethodDelete)
+	return r
+}
+
+func TestValidateSilence(t *testing.T) {
+	h := silenceHandlers(&fakeChecker{admin: true})
+	now := time.Now()
+	valid := func() model.Silence {
+		return model.Silence{
+			Matchers: []model.SilenceMatcher{{Name: "netobserv", Value: "true"}, {Name: "alertname", Value: "Foo.*", IsRegex: true}},
+			EndsAt:   now.Add(time.Hour),
+			Comment:  "maintenance",
+		}
+	}
+
+	s := valid()
+	require.NoError(t, h.validateSilence(&s, now))
+	assert.Equal(t, now, s.StartsAt)
+
+	s = valid()
+	s.Matchers = []model.SilenceMatcher{{Name: "namespace", Value: "netobserv"}}
+	require.NoError(t, h.validateSilence(&s, now))
+
+	s = valid()
+	s.Matchers = []model.SilenceMatcher{{Name: "namespace", Value: "other"}}
+	require.ErrorContains(t, h.validateSilence(&s, now), "must target netobserv alerts")
+
+	s = valid()
+	s.Matchers = append(s.Matchers, model.SilenceMatcher{Name: "bad-name", Value: "a"})
+	require.ErrorContains(t, h.validateSilence(&s, now), "invalid matcher label name")
+
+	s = valid()
+	s.Matchers[1].Value = "(unclosed"
+	require.ErrorContains(t, h.validateSilence(&s, now), "invalid regex")
+
+	s = valid()
+	s.Comment = ""
+	require.ErrorContains(t, h.validateSilence(&s, now), "comment is required")
+
+	s = valid()
+	s.EndsAt = now.Add(-time.Minute)
+	s.StartsAt = now.Add(-time.Hour)
+	require.ErrorContains(t, h.validateSilence(&s, now), "must end in the future")
+
+	s = valid()
+	s.ID = "abc"
+	require.ErrorContains(t, h.validateSilence(&s, now), "not supported")
+}
+
+func TestSilencesLifecycle_NonAdmin(t *testing.T) {
+	h := silenceHandlers(&fakeChecker{username: "dev1", namespaces: []string{"ns1"}})
+	r := silenceRouter(h)
+	endsAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	// Not restricted to a namespace: forbidden
+	body := `{"matchers":[{"name":"netobserv","value":"true","isRegex":false}],"endsAt":"` + endsAt + `","comment":"test"}`
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/silences", strings.NewReader(body)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Non-accessible namespace: forbidden
+	body = `{"matchers":[{"name":"netobserv","value":"true","isRegex":false},{"name":"SrcK8S_Namespace","value":"ns2","isRegex":false}],"endsAt":"` + endsAt + `","comment":"test"}`
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/silences", strings.NewReader(body)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Regex on namespace: forbidden
+	body = `{"matchers":[{"name":"netobserv","value":"true","isRegex":false},{"name":"SrcK8S_Namespace","value":"ns.*","isRegex":true}],"endsAt":"` + endsAt + `","comment":"test"}`
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/silences", strings.NewReader(body)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Accessible namespace: created, with creator recorded
+	body = `{"matchers":[{"name":"netobserv","value":"true","isRegex":false},{"name":"SrcK8S_Namespace","value":"ns1","isRegex":false}],"endsAt":"` + endsAt + `","comment":"test","createdBy":"someone-else"}`
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/silences", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var created map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	id := created["silenceID"]
+	require.NotEmpty(t, id)
+
+	// Listed
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/silences", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []model.Silence
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	var found *model.Silence
+	for i := range listed {
+		if listed[i].ID == id {
+			found = &listed[i]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, "dev1", found.CreatedBy)
+	assert.Equal(t, "active", found.Status.State)
+
+	// Not listed for another user
+	other := silenceRouter(silenceHandlers(&fakeChecker{username: "dev2", namespaces: []string{"ns2"}}))
+	rec = httptest.NewRecorder()
+	other.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/silences", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), id)
+
+	// Cannot be expired by another user
+	rec = httptest.NewRecorder()
+	other.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/silences/"+id, nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Expired by its creator
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/silences/"+id, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Unknown silence
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/silences/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
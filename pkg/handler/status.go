@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 	"strings"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
 )
 
 type Status struct {
@@ -22,7 +24,11 @@ type DatasourceStatus struct {
 func (h *Handlers) Status(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := r.URL.Query()
-		namespace := params.Get(namespaceKey)
+		namespace, nerr := getNamespace(params)
+		if nerr != nil {
+			apierrors.Write(w, http.StatusBadRequest, nerr)
+			return
+		}
 		isDev := namespace != ""
 
 		status := Status{
@@ -30,6 +30,8 @@ const (
 	groupsKey       = "groups"
 	rateIntervalKey = "rateInterval"
 	stepKey         = "step"
+	snapshotKey     = "snapshot"
+	estimateKey     = "estimate"
 
 	defaultRateInterval = "1m"
 	defaultStep         = "30s"
@@ -39,9 +41,18 @@ const (
 func (h *Handlers) GetTopology(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := r.URL.Query()
-		namespace := params.Get(namespaceKey)
+		namespace, err := getNamespace(params)
+		if err != nil {
+			apierrors.Write(w, http.StatusBadRequest, err)
+			return
+		}
 
-		clients, sterr := newClients(h.Cfg, r.Header, false, namespace)
+		tenants, err := getTenants(&h.Cfg.Loki, params)
+		if err != nil {
+			apierrors.Write(w, http.StatusBadRequest, err)
+			return
+		}
+		clients, sterr := newClients(h.Cfg, r.Header, false, namespace, tenants)
 		if sterr != nil {
 			sterr.Write(w, http.StatusInternalServerError)
 			return
@@ -59,19 +70,9 @@ func (h *Handlers) GetTopology(ctx context.Context) func(w http.ResponseWriter,
 			return
 		}
 
-		flows, code, err := h.getTopologyFlows(ctx, clients, params, ds)
-		var promClErr *apierrors.PromClientError
-		if err != nil &&
-			ds == constants.DataSourceAuto &&
-			h.Cfg.IsLokiEnabled() &&
-			(code == http.StatusForbidden || code == http.StatusUnauthorized) &&
-			errors.As(err, &promClErr) {
-			// In case this was a prometheus 401 / 403 error, the query is repeated with Loki
-			// This is because multi-tenancy is currently not managed for prom datasource, hence such queries have to go with Loki
-			// Unfortunately we don't know a safe and generic way to pre-flight check if the user will be authorized
-			hlog.Info("Retrying with Loki...")
-			flows, code, err = h.getTopologyFlows(ctx, clients, params, constants.DataSourceLoki)
-		}
+		queryCtx, cancel := h.topologyContext(r)
+		defer cancel()
+		flows, code, err := h.getTopologyWithFallback(queryCtx, clients, params, ds, h.isAdmin(ctx, r.Header))
 		if err != nil {
 			apierrors.Write(w, code, err)
 			return
@@ -82,6 +83,30 @@ func (h *Handlers) GetTopology(ctx context.Context) func(w http.ResponseWriter,
 	}
 }
 
+// topologyContext bounds topology queries, including degraded retries, by the request and the server write timeout,
+// beyond which responses can't be written
+func (h *Handlers) topologyContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(r.Context(), h.Cfg.WriteTimeout())
+}
+
+// getTopologyWithFallback runs topology queries, and repeats them with Loki when Prometheus denies access
+func (h *Handlers) getTopologyWithFallback(ctx context.Context, cl clients, params url.Values, ds constants.DataSource, isAdmin bool) (*model.AggregatedQueryResponse, int, error) {
+	flows, code, err := h.getTopologyFlows(ctx, cl, params, ds, isAdmin)
+	var promClErr *apierrors.PromClientError
+	if err != nil &&
+		ds == constants.DataSourceAuto &&
+		h.Cfg.IsLokiEnabled() &&
+		(code == http.StatusForbidden || code == http.StatusUnauthorized) &&
+		errors.As(err, &promClErr) {
+		// In case this was a prometheus 401 / 403 error, the query is repeated with Loki
+		// This is because multi-tenancy is currently not managed for prom datasource, hence such queries have to go with Loki
+		// Unfortunately we don't know a safe and generic way to pre-flight check if the user will be authorized
+		hlog.Info("Retrying with Loki...")
+		return h.getTopologyFlows(ctx, cl, params, constants.DataSourceLoki, isAdmin)
+	}
+	return flows, code, err
+}
+
 func (h *Handlers) extractTopologyQueryParams(params url.Values, ds constants.DataSource) (*loki.TopologyInput, filters.MultiQueries, v1.Range, int, error) {
 	in := loki.TopologyInput{DataSource: ds}
 	qr := v1.Range{}
@@ -126,7 +151,12 @@ func (h *Handlers) extractTopologyQueryParams(params url.Values, ds constants.Da
 		return nil, nil, qr, reqLimit, err
 	}
 	in.Groups = params.Get(groupsKey)
-	namespace := params.Get(namespaceKey)
+	in.Snapshot = params.Get(snapshotKey) == "true"
+	in.Estimate = params.Get(estimateKey) == "true"
+	namespace, err := getNamespace(params)
+	if err != nil {
+		return nil, nil, qr, reqLimit, err
+	}
 	rawFilters := params.Get(filtersKey)
 	filterGroups, err := filters.Parse(rawFilters)
 	if err != nil {
@@ -134,9 +164,14 @@ func (h *Handlers) extractTopologyQueryParams(params url.Values, ds constants.Da
 	}
 
 	if shouldMergeReporters(in.DataField) {
+		expandNamespace := namespace
+		if h.Cfg.Loki.UseNamespaceParam(namespace) {
+			// managed from the Loki gateway
+			expandNamespace = ""
+		}
 		filterGroups = expandQueries(
 			filterGroups,
-			namespace,
+			expandNamespace,
 			func(filters filters.SingleQuery) bool {
 				// Do not expand if this is managed from prometheus
 				sr, _ := getEligiblePromMetric(h.Cfg.Frontend.GetAggregateKeyLabels(), h.PromInventory, filters, &in, namespace != "")
@@ -148,7 +183,7 @@ func (h *Handlers) extractTopologyQueryParams(params url.Values, ds constants.Da
 	return &in, filterGroups, qr, reqLimit, err
 }
 
-func (h *Handlers) getTopologyFlows(ctx context.Context, cl clients, params url.Values, ds constants.DataSource) (*model.AggregatedQueryResponse, int, error) {
+func (h *Handlers) getTopologyFlows(ctx context.Context, cl clients, params url.Values, ds constants.DataSource, isAdmin bool) (*model.AggregatedQueryResponse, int, error) {
 	hlog.Debugf("GetTopology query params: %s", params)
 
 	dataSources := make(map[constants.DataSource]bool)
@@ -161,27 +196,114 @@ func (h *Handlers) getTopologyFlows(ctx context.Context, cl clients, params url.
 		return nil, http.StatusBadRequest, err
 	}
 	isDev := params.Get(namespaceKey) != ""
-	merger := loki.NewMatrixMerger(reqLimit)
-	if len(filterGroups) > 1 {
-		// match any, and multiple filters => run in parallel then aggregate
-		var lokiQ []string
-		var promQ []*prometheus.Query
-		for _, filters := range filterGroups {
-			lq, pq, code, err := buildTopologyQuery(h.Cfg, h.PromInventory, filters, in, &qr, isDev)
+	if code, err := h.enforceTopologyGuardrails(cl, filterGroups, in, &qr, reqLimit, isAdmin, isDev); err != nil {
+		return nil, code, err
+	}
+	var merger loki.Merger
+	if in.Snapshot {
+		merger = loki.NewVectorMerger(reqLimit)
+	} else {
+		merger = loki.NewMatrixMerger(reqLimit)
+	}
+	var degradation *model.Degradation
+	var volumeQ []string
+	if in.Estimate {
+		volumeQ, err = buildVolumeQueries(h.Cfg, h.PromInventory, filterGroups, in, isDev)
+		if err != nil {
+			hlog.Debugf("Loki volume API not used; reason: %s.", err)
+		}
+	}
+	if len(volumeQ) > 0 {
+		// estimate from the Loki volume API, in parallel for each filter group
+		dataSources[constants.DataSourceLoki] = true
+		code, err := cl.fetchParallel(ctx, volumeQ, nil, merger, isDev)
+		if err != nil {
+			return nil, code, err
+		}
+	} else {
+		code, err := h.fetchTopology(ctx, cl, filterGroups, in, &qr, isDev, merger, dataSources)
+		if err != nil {
+			reason := lokiLimitReason(err)
+			if reason == "" {
+				return nil, code, err
+			}
+			degradedMerger, d, derr := h.fetchDegradedTopology(ctx, cl, filterGroups, in, &qr, isDev, reason, dataSources)
+			if derr != nil {
+				hlog.Infof("Degraded topology queries failed: %v", derr)
+				return nil, code, err
+			}
+			merger = degradedMerger
+			degradation = d
+		}
+	}
+
+	qresp := merger.Get()
+	if len(volumeQ) > 0 {
+		loki.VolumesPerSecond(in, qr.Step, qresp.Result)
+		qresp.Stats.Estimate = true
+		qresp.Stats.Approximations = loki.VolumeApproximations(in)
+	}
+	qresp.Stats.Degradation = degradation
+	qresp.Stats.DataSources = []constants.DataSource{}
+	for str, ok := range dataSources {
+		if ok {
+			qresp.Stats.DataSources = append(qresp.Stats.DataSources, str)
+		}
+	}
+	qresp.UnixTimestamp = time.Now().Unix()
+	hlog.Tracef("GetTopology response: %v", qresp)
+	return qresp, http.StatusOK, nil
+}
+
+// enforceTopologyGuardrails checks guardrails for all filter groups; only those not managed from Prometheus count in the estimated cost
+func (h *Handlers) enforceTopologyGuardrails(cl clients, filterGroups filters.MultiQueries, in *loki.TopologyInput, qr *v1.Range, reqLimit int, isAdmin, isDev bool) (int, error) {
+	bounds := queryBounds{start: qr.Start, end: qr.End, limit: reqLimit, queries: max(len(filterGroups), 1)}
+	if !in.Snapshot {
+		bounds.step = qr.Step
+	}
+	var lokiQ []*loki.FlowQueryBuilder
+	if h.Cfg.IsLokiEnabled() && in.DataSource != constants.DataSourceProm && h.Cfg.Guardrails.For(isAdmin).MaxCost > 0 {
+		groups := filterGroups
+		if len(groups) == 0 {
+			groups = filters.MultiQueries{filters.SingleQuery{}}
+		}
+		for _, group := range groups {
+			if search, _ := getEligiblePromMetric(h.Cfg.Frontend.GetAggregateKeyLabels(), h.PromInventory, group, in, isDev); search != nil && len(search.Found) > 0 {
+				continue
+			}
+			qb, err := loki.NewTopologyQuery(&h.Cfg.Loki, h.Cfg.Frontend.GetAggregateKeyLabels(), in)
 			if err != nil {
-				return nil, code, errors.New("Can't build query: " + err.Error())
+				return http.StatusBadRequest, err
 			}
-			if pq != nil {
-				promQ = append(promQ, pq)
-				dataSources[constants.DataSourceProm] = true
-			} else {
-				lokiQ = append(lokiQ, lq)
-				dataSources[constants.DataSourceLoki] = true
+			if err := qb.Filters(group); err != nil {
+				return http.StatusBadRequest, err
 			}
+			lokiQ = append(lokiQ, qb.FlowQueryBuilder)
+		}
+	}
+	return h.enforceGuardrails(cl.loki, isAdmin, &bounds, lokiQ)
+}
+
+// fetchTopology builds and runs topology queries for every filter group, adding results to the merger
+func (h *Handlers) fetchTopology(
+	ctx context.Context,
+	cl clients,
+	filterGroups filters.MultiQueries,
+	in *loki.TopologyInput,
+	qr *v1.Range,
+	isDev bool,
+	merger loki.Merger,
+	dataSources map[constants.DataSource]bool,
+) (int, error) {
+	if len(filterGroups) > 1 {
+		// match any, and multiple filters => run in parallel then aggregate
+		lokiQ, promQ, code, err := h.buildTopologyQueries(filterGroups, in, qr, isDev, dataSources)
+		if err != nil {
+			return code, err
 		}
-		code, err := cl.fetchParallel(ctx, lokiQ, promQ, merger, isDev)
+		code, err = cl.fetchParallel(ctx, lokiQ, promQ, merger, isDev)
 		if err != nil {
-			return nil, code, err
+			return code, err
 		}
 	} else {
 		// else, run all at once
@@ -189,9 +311,9 @@ func (h *Handlers) getTopologyFlows(ctx context.Context, cl clients, params url.
 		if len(filterGroups) > 0 {
 			filters = filterGroups[0]
 		}
-		lokiQ, promQ, code, err := buildTopologyQuery(h.Cfg, h.PromInventory, filters, in, &qr, isDev)
+		lokiQ, promQ, code, err := buildTopologyQuery(h.Cfg, h.PromInventory, filters, in, qr, isDev)
 		if err != nil {
-			return nil, code, err
+			return code, err
 		}
 		if len(lokiQ) > 0 {
 			dataSources[constants.DataSourceLoki] = true
@@ -201,20 +323,39 @@ func (h *Handlers) getTopologyFlows(ctx context.Context, cl clients, params url.
 		}
 		code, err = cl.fetchSingle(ctx, lokiQ, promQ, merger, isDev)
 		if err != nil {
-			return nil, code, err
+			return code, err
 		}
 	}
+	return http.StatusOK, nil
+}
 
-	qresp := merger.Get()
-	qresp.Stats.DataSources = []constants.DataSource{}
-	for str, ok := range dataSources {
-		if ok {
-			qresp.Stats.DataSources = append(qresp.Stats.DataSources, str)
+// buildTopologyQueries builds topology queries for every filter group, to be run in parallel
+func (h *Handlers) buildTopologyQueries(
+	filterGroups filters.MultiQueries,
+	in *loki.TopologyInput,
+	qr *v1.Range,
+	isDev bool,
+	dataSources map[constants.DataSource]bool,
+) ([]string, []*prometheus.Query, int, error) {
+	if len(filterGroups) == 0 {
+		filterGroups = filters.MultiQueries{nil}
+	}
+	var lokiQ []string
+	var promQ []*prometheus.Query
+	for _, filters := range filterGroups {
+		lq, pq, code, err := buildTopologyQuery(h.Cfg, h.PromInventory, filters, in, qr, isDev)
+		if err != nil {
+			return nil, nil, code, errors.New("Can't build query: " + err.Error())
+		}
+		if pq != nil {
+			promQ = append(promQ, pq)
+			dataSources[constants.DataSourceProm] = true
+		} else {
+			lokiQ = append(lokiQ, lq)
+			dataSources[constants.DataSourceLoki] = true
 		}
 	}
-	qresp.UnixTimestamp = time.Now().Unix()
-	hlog.Tracef("GetTopology response: %v", qresp)
-	return qresp, http.StatusOK, nil
+	return lokiQ, promQ, http.StatusOK, nil
 }
 
 func shouldMergeReporters(metricType string) bool {
@@ -255,7 +396,7 @@ func expandQueries(queries filters.MultiQueries, namespace string, isForProm fun
 
 	// Then, expand for namespace
 	if namespace != "" {
-		// TODO: this should actually be managed from the loki gateway, with "namespace" query param
+		// without gateway, restrict to the namespace on both source and destination sides
 		expanded = expanded.Distribute(
 			[]filters.SingleQuery{
 				{filters.NewRegexMatch(fields.SrcNamespace, `"`+namespace+`"`)},
@@ -310,6 +451,36 @@ func buildTopologyQuery(
 	return EncodeQuery(qb.Build()), nil, http.StatusOK, nil
 }
 
+// buildVolumeQueries builds Loki volume API queries for every filter group, or returns an error when any of them is not eligible:
+// Prometheus is preferred when it can serve the query, and the volume API only works with Loki labels
+func buildVolumeQueries(
+	cfg *config.Config,
+	promInventory *prometheus.Inventory,
+	filterGroups filters.MultiQueries,
+	in *loki.TopologyInput,
+	isDev bool,
+) ([]string, error) {
+	if !cfg.IsLokiEnabled() || in.DataSource == constants.DataSourceProm {
+		return nil, errors.New("Loki is not used as datasource")
+	}
+	if len(filterGroups) == 0 {
+		filterGroups = filters.MultiQueries{nil}
+	}
+	var queries []string
+	for _, group := range filterGroups {
+		search, _ := getEligiblePromMetric(cfg.Frontend.GetAggregateKeyLabels(), promInventory, group, in, isDev)
+		if search != nil && len(search.Found) > 0 {
+			return nil, errors.New("query can be served by Prometheus")
+		}
+		qb, err := loki.NewVolumeQuery(&cfg.Loki, cfg.Frontend.GetAggregateKeyLabels(), in, group)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, EncodeQuery(qb.Build()))
+	}
+	return queries, nil
+}
+
 func getEligiblePromMetric(kl map[string][]string, promInventory *prometheus.Inventory, filters filters.SingleQuery, in *loki.TopologyInput, isDev bool) (*prometheus.SearchResult, string) {
 	if in.DataSource != constants.DataSourceAuto && in.DataSource != constants.DataSourceProm {
 		return nil, ""
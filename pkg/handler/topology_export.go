@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/metricsexport"
+	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
+	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+
+	pmodel "github.com/prometheus/common/model"
+)
+
+const (
+	exportOpenMetricsFormat = "openmetrics"
+	exportGraphMLFormat     = "graphml"
+	exportDOTFormat         = "dot"
+	exportTotalsKey         = "totals"
+)
+
+// topologyExportTypes are the file extensions and content types of metrics export formats
+var topologyExportTypes = map[string][2]string{
+	exportCSVFormat:         {"csv", "text/csv"},
+	exportOpenMetricsFormat: {"txt", "application/openmetrics-text; version=1.0.0; charset=utf-8"},
+	exportGraphMLFormat:     {"graphml", "application/graphml+xml"},
+	exportDOTFormat:         {"dot", "text/vnd.graphviz"},
+}
+
+// ExportTopology exports the same metrics as GetTopology, as CSV, OpenMetrics text, or as a graph of scope nodes
+func (h *Handlers) ExportTopology(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		var code int
+		startTime := time.Now()
+		defer func() {
+			metrics.ObserveHTTPCall("ExportTopology", code, startTime)
+		}()
+
+		namespace, err := getNamespace(params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+
+		format := params.Get(exportFormatKey)
+		types, ok := topologyExportTypes[format]
+		if !ok {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, fmt.Errorf("export format %q is not valid", format))
+			return
+		}
+		tenants, err := getTenants(&h.Cfg.Loki, params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+		clients, sterr := newClients(h.Cfg, r.Header, false, namespace, tenants)
+		if sterr != nil {
+			code = http.StatusInternalServerError
+			sterr.Write(w, code)
+			return
+		}
+		ds, err := getDatasource(params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+		opts, err := h.getTopologyExportOptions(params)
+		if err != nil {
+			code = http.StatusBadRequest
+			apierrors.Write(w, code, err)
+			return
+		}
+
+		queryCtx, cancel := h.topologyContext(r)
+		defer cancel()
+		flows, code, err := h.getTopologyWithFallback(queryCtx, clients, params, ds, h.isAdmin(ctx, r.Header))
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		if d := flows.Stats.Degradation; d != nil && d.Step != "" {
+			// values are spaced by the coarser step of degraded queries
+			if step, err := pmodel.ParseDuration(d.Step); err == nil {
+				opts.Step = time.Duration(step)
+			}
+		}
+		// topology results are bounded by the query limit: render them entirely, so that errors get a proper status
+		var buf bytes.Buffer
+		code, err = writeTopology(&buf, flows, format, params.Get(exportTotalsKey) == "true", opts)
+		if err != nil {
+			apierrors.Write(w, code, err)
+			return
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=topology-%s.%s", time.Now().Format("2006-01-02-15-04"), types[0]))
+		w.Header().Set("Content-Type", types[1])
+		w.WriteHeader(code)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			hlog.Errorf("Error while exporting topology: %v", err)
+		}
+	}
+}
+
+func (h *Handlers) getTopologyExportOptions(params url.Values) (*metricsexport.Options, error) {
+	metricType := getMetricType(params)
+	function, err := getMetricFunction(params)
+	if err != nil {
+		return nil, err
+	}
+	aggregate, err := getAggregate(params)
+	if err != nil {
+		return nil, err
+	}
+	_, step, err := getStep(params)
+	if err != nil {
+		return nil, err
+	}
+	labels, _ := loki.GetLabelsAndFilter(h.Cfg.Frontend.GetAggregateKeyLabels(), aggregate, params.Get(groupsKey))
+	name := metricsexport.MetricName(metricType, string(function))
+	help := fmt.Sprintf("%s of %s by %s", function, metricType, aggregate)
+	if function == constants.MetricFunctionRate && params.Get(snapshotKey) == "true" {
+		// snapshot rates are totals over the time range, named after the metric type alone
+		name = metricsexport.MetricName(metricType, "")
+		help = fmt.Sprintf("total of %s by %s over the time range", metricType, aggregate)
+	}
+	return &metricsexport.Options{
+		Name:       name,
+		Help:       help,
+		Rate:       function == constants.MetricFunctionRate,
+		Cumulative: function == constants.MetricFunctionRate || function == constants.MetricFunctionCount || function == constants.MetricFunctionSum,
+		Step:       step,
+		Labels:     labels,
+	}, nil
+}
+
+func writeTopology(buf *bytes.Buffer, qr *model.AggregatedQueryResponse, format string, totals bool, opts *metricsexport.Options) (int, error) {
+	series, err := metricsexport.GetSeries(qr.Result)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	switch format {
+	case exportCSVFormat:
+		if totals {
+			err = metricsexport.WriteTotalsCSV(buf, series, opts)
+		} else {
+			err = metricsexport.WriteCSV(buf, series)
+		}
+	case exportOpenMetricsFormat:
+		err = metricsexport.WriteOpenMetrics(buf, series, opts)
+	case exportGraphMLFormat, exportDOTFormat:
+		graph, gerr := metricsexport.NewGraph(series, opts)
+		if gerr != nil {
+			return http.StatusBadRequest, gerr
+		}
+		if format == exportGraphMLFormat {
+			err = graph.WriteGraphML(buf)
+		} else {
+			err = graph.WriteDOT(buf)
+		}
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	pmodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func topologyExportHandlers() *Handlers {
+	return &Handlers{Cfg: &config.Config{Frontend: config.Frontend{Scopes: []config.Scope{
+		{ID: "cluster", Labels: []string{"K8S_ClusterName"}},
+		{ID: "namespace", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace"}},
+	}}}}
+}
+
+func TestGetTopologyExportOptions(t *testing.T) {
+	h := topologyExportHandlers()
+	opts, err := h.getTopologyExportOptions(url.Values{"aggregateBy": {"namespace"}, "groups": {"clusters"}, "type": {"PktDropPackets"}, "function": {"rate"}, "step": {"1m"}})
+	require.NoError(t, err)
+	assert.Equal(t, "netobserv_pkt_drop_packets_rate", opts.Name)
+	assert.Equal(t, "rate of PktDropPackets by namespace", opts.Help)
+	assert.Equal(t, []string{"SrcK8S_Namespace", "DstK8S_Namespace", "K8S_ClusterName"}, opts.Labels)
+	assert.True(t, opts.Rate)
+	assert.True(t, opts.Cumulative)
+	assert.Equal(t, time.Minute, opts.Step)
+
+	// snapshot rates are totals over the time range
+	opts, err = h.getTopologyExportOptions(url.Values{"aggregateBy": {"namespace"}, "type": {"Bytes"}, "function": {"rate"}, "snapshot": {"true"}})
+	require.NoError(t, err)
+	assert.Equal(t, "netobserv_bytes", opts.Name)
+	assert.Equal(t, "total of Bytes by namespace over the time range", opts.Help)
+	assert.True(t, opts.Rate)
+
+	opts, err = h.getTopologyExportOptions(url.Values{"aggregateBy": {"namespace"}, "type": {"DnsLatencyMs"}, "function": {"p90"}})
+	require.NoError(t, err)
+	assert.False(t, opts.Rate)
+	assert.False(t, opts.Cumulative)
+
+	_, err = h.getTopologyExportOptions(url.Values{})
+	assert.EqualError(t, err, "aggregateBy parameter is required")
+}
+
+func TestWriteTopology(t *testing.T) {
+	h := topologyExportHandlers()
+	qr := &model.AggregatedQueryResponse{Result: model.Vector{
+		{Metric: pmodel.Metric{"SrcK8S_Namespace": "ns1", "DstK8S_Namespace": "ns2"}, Value: 2, Timestamp: 1700000000000},
+	}}
+	opts, err := h.getTopologyExportOptions(url.Values{"aggregateBy": {"namespace"}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	code, err := writeTopology(&buf, qr, exportCSVFormat, true, opts)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "DstK8S_Namespace,SrcK8S_Namespace,Avg,Min,Max,Latest,Total\nns2,ns1,2,2,2,2,2\n", buf.String())
+
+	buf.Reset()
+	_, err = writeTopology(&buf, qr, exportDOTFormat, false, opts)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `n0 -> n1 [weight=2, label="2", value=2, total=2];`)
+
+	// graphs need endpoint scopes
+	opts, err = h.getTopologyExportOptions(url.Values{"aggregateBy": {"app"}})
+	require.NoError(t, err)
+	code, err = writeTopology(&buf, qr, exportGraphMLFormat, false, opts)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Error(t, err)
+}
+
+func TestExportTopology_InvalidFormat(t *testing.T) {
+	h := topologyExportHandlers()
+	w := httptest.NewRecorder()
+	h.ExportTopology(t.Context())(w, httptest.NewRequest(http.MethodGet, "/flow/metrics/export?aggregateBy=namespace&format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `export format \"xml\" is not valid`)
+}
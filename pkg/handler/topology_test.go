@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"net/url"
 	"testing"
 
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
 	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
+	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSplitForReportersMerge_NoSplit(t *testing.T) {
@@ -116,3 +121,76 @@ func TestExpand_ComplexQuery(t *testing.T) {
 		filters.NewRegexMatch("key2", "d"),
 	}, res[10])
 }
+
+func TestBuildVolumeQueries(t *testing.T) {
+	cfg := config.Config{
+		Loki: config.Loki{URL: "http://loki", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace", "FlowDirection", "DstK8S_Type"}},
+		Prometheus: config.Prometheus{Metrics: []config.MetricInfo{
+			{Enabled: true, Name: "netobserv_workload_ingress_bytes_total", Type: "counter", ValueField: "Bytes", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace", "SrcK8S_OwnerName", "DstK8S_OwnerName"}},
+		}},
+		Frontend: config.Frontend{Scopes: []config.Scope{
+			{ID: "namespace", Labels: []string{"SrcK8S_Namespace", "DstK8S_Namespace"}},
+			{ID: "host", Labels: []string{"SrcK8S_HostName", "DstK8S_HostName"}},
+		}},
+	}
+	in := loki.TopologyInput{
+		Start:          "1000",
+		Top:            "50",
+		Step:           "30s",
+		DataField:      constants.MetricTypeFlows,
+		MetricFunction: constants.MetricFunctionCount,
+		DataSource:     constants.DataSourceAuto,
+		PacketLoss:     constants.PacketLossAll,
+		Aggregate:      "namespace",
+		Estimate:       true,
+	}
+	mq := filters.MultiQueries{
+		{filters.NewEqualMatch("SrcK8S_Namespace", `"a"`)},
+		{filters.NewEqualMatch("DstK8S_Namespace", `"b"`)},
+	}
+
+	// Flows are not in Prometheus: estimate from Loki
+	queries, err := buildVolumeQueries(&cfg, prometheus.NewInventory(&cfg.Prometheus), mq, &in, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`http://loki/loki/api/v1/index/volume_range?query={app=%22netobserv-flowcollector%22,SrcK8S_Namespace=%22a%22}&start=1000&limit=50&targetLabels=SrcK8S_Namespace,DstK8S_Namespace&aggregateBy=series&step=30s`,
+		`http://loki/loki/api/v1/index/volume_range?query={app=%22netobserv-flowcollector%22,DstK8S_Namespace=%22b%22}&start=1000&limit=50&targetLabels=SrcK8S_Namespace,DstK8S_Namespace&aggregateBy=series&step=30s`,
+	}, queries)
+
+	// Not a Loki label
+	in.Aggregate = "host"
+	_, err = buildVolumeQueries(&cfg, prometheus.NewInventory(&cfg.Prometheus), mq, &in, false)
+	require.ErrorContains(t, err, "not based on a Loki label")
+
+	// Bytes can be served by Prometheus
+	in.Aggregate = "namespace"
+	in.DataField = constants.MetricTypeBytes
+	in.MetricFunction = constants.MetricFunctionRate
+	_, err = buildVolumeQueries(&cfg, prometheus.NewInventory(&cfg.Prometheus), mq, &in, false)
+	require.ErrorContains(t, err, "served by Prometheus")
+
+	// Bytes aren't related to log volumes
+	_, err = buildVolumeQueries(&cfg, nil, mq, &in, false)
+	require.ErrorContains(t, err, "rate of Bytes can't be estimated from log volumes")
+
+	// Loki disabled
+	cfg.Loki.URL = ""
+	_, err = buildVolumeQueries(&cfg, nil, mq, &in, false)
+	require.ErrorContains(t, err, "Loki is not used")
+}
+
+func TestExtractTopologyQueryParams_NamespaceParam(t *testing.T) {
+	h := Handlers{Cfg: &config.Config{Loki: config.Loki{URL: "http://loki"}}}
+	params := url.Values{"aggregateBy": {"namespace"}, "type": {"Bytes"}, "namespace": {"ns1"}}
+
+	// Expanded for reporters and namespaces
+	_, groups, _, _, err := h.extractTopologyQueryParams(params, constants.DataSourceLoki)
+	require.NoError(t, err)
+	assert.Len(t, groups, 4)
+
+	// Namespace is managed from the gateway
+	h.Cfg.Loki.NamespaceParam = true
+	_, groups, _, _, err = h.extractTopologyQueryParams(params, constants.DataSourceLoki)
+	require.NoError(t, err)
+	assert.Len(t, groups, 2)
+}
@@ -4,9 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 )
 
@@ -63,6 +68,61 @@ func getLimit(params url.Values) (string, int, error) {
 	return limit, reqLimit, nil
 }
 
+// getExportPaging returns the page size of paged exports, defaulting to the configured one,
+// and their maximum number of rows, which can't exceed the configured one
+func getExportPaging(cfg *config.Export, params url.Values, limit int) (int, int, error) {
+	pageSize := cfg.PageSize
+	if limit > 0 {
+		pageSize = limit
+	}
+	if pageSize <= 0 {
+		return 0, 0, errors.New("a limit is required for paged exports")
+	}
+	maxRows := cfg.MaxRows
+	if str := params.Get(exportMaxRowsKey); len(str) > 0 {
+		m, err := strconv.Atoi(str)
+		if err != nil || m <= 0 {
+			return 0, 0, fmt.Errorf("invalid maxRows: %s", str)
+		}
+		if cfg.MaxRows > 0 && m > cfg.MaxRows {
+			return 0, 0, fmt.Errorf("maxRows of %d exceeds the maximum of %d", m, cfg.MaxRows)
+		}
+		maxRows = m
+	}
+	if maxRows <= 0 {
+		return 0, 0, errors.New("a maximum number of rows is required for paged exports")
+	}
+	return pageSize, maxRows, nil
+}
+
+// getTenants returns the Loki tenants to query, among the configured ones; all of them by default
+func getTenants(cfg *config.Loki, params url.Values) ([]string, error) {
+	configured := cfg.GetTenantIDs()
+	tenants := params.Get(tenantsKey)
+	if tenants == "" {
+		return configured, nil
+	}
+	selected := strings.Split(tenants, ",")
+	for _, t := range selected {
+		if !slices.Contains(configured, t) {
+			return nil, fmt.Errorf("invalid tenant: %s", t)
+		}
+	}
+	return selected, nil
+}
+
+// getNamespace returns the namespace restricting queries, which must be a DNS-1123 label as it's sent to the Loki gateway
+func getNamespace(params url.Values) (string, error) {
+	namespace := params.Get(namespaceKey)
+	if namespace == "" {
+		return "", nil
+	}
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return "", fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, "; "))
+	}
+	return namespace, nil
+}
+
 func getRecordType(params url.Values) (constants.RecordType, error) {
 	rt := params.Get(recordTypeKey)
 	if rt == "" {
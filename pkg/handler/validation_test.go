@@ -6,8 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetStartTime(t *testing.T) {
@@ -114,6 +116,21 @@ func TestGetPacketLoss(t *testing.T) {
 	assert.Equal(t, constants.DefaultPacketLoss, pl)
 }
 
+func TestGetNamespace(t *testing.T) {
+	ns, err := getNamespace(url.Values{namespaceKey: {"my-ns"}})
+	require.NoError(t, err)
+	assert.Equal(t, "my-ns", ns)
+
+	ns, err = getNamespace(url.Values{})
+	require.NoError(t, err)
+	assert.Empty(t, ns)
+
+	for _, invalid := range []string{"foo&namespace=kube-system", "Foo", "-foo", "foo bar"} {
+		_, err = getNamespace(url.Values{namespaceKey: {invalid}})
+		assert.ErrorContains(t, err, "invalid namespace", invalid)
+	}
+}
+
 func TestGetRateInterval(t *testing.T) {
 	// Valid
 	params := url.Values{
@@ -163,3 +180,27 @@ func TestGetStep(t *testing.T) {
 	assert.Equal(t, defaultStep, step)
 	assert.Equal(t, defaultStepDuration, sd)
 }
+
+func TestGetExportPaging(t *testing.T) {
+	cfg := config.Export{PageSize: 1000, MaxRows: 5000}
+
+	// Defaults
+	pageSize, maxRows, err := getExportPaging(&cfg, url.Values{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, pageSize)
+	assert.Equal(t, 5000, maxRows)
+
+	// From params
+	pageSize, maxRows, err = getExportPaging(&cfg, url.Values{exportMaxRowsKey: []string{"200"}}, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, pageSize)
+	assert.Equal(t, 200, maxRows)
+
+	// Invalid
+	_, _, err = getExportPaging(&cfg, url.Values{exportMaxRowsKey: []string{"6000"}}, 0)
+	assert.EqualError(t, err, "maxRows of 6000 exceeds the maximum of 5000")
+	_, _, err = getExportPaging(&cfg, url.Values{exportMaxRowsKey: []string{"-1"}}, 0)
+	assert.EqualError(t, err, "invalid maxRows: -1")
+	_, _, err = getExportPaging(&config.Export{}, url.Values{}, 0)
+	assert.EqualError(t, err, "a limit is required for paged exports")
+}
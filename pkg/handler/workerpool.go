@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/kubernetes/auth"
+)
+
+// workerPool bounds the number of tasks running at once. When all slots are taken, waiting tasks are granted slots
+// in turn for each user, so that a user running many queries doesn't delay the queries of others.
+// A nil pool doesn't bound concurrency.
+type workerPool struct {
+	mu    sync.Mutex
+	free  int
+	queue map[string][]chan struct{}
+	// users having waiting tasks, in round-robin order
+	users []string
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size <= 0 {
+		return nil
+	}
+	return &workerPool{free: size, queue: map[string][]chan struct{}{}}
+}
+
+// run runs f once a slot is available, unless the context is done first
+func (p *workerPool) run(ctx context.Context, user string, f func()) error {
+	if p == nil {
+		f()
+		return nil
+	}
+	if err := p.acquire(ctx, user); err != nil {
+		return err
+	}
+	defer p.release()
+	f()
+	return nil
+}
+
+func (p *workerPool) acquire(ctx context.Context, user string) error {
+	p.mu.Lock()
+	if p.free > 0 {
+		p.free--
+		p.mu.Unlock()
+		return nil
+	}
+	granted := make(chan struct{})
+	if _, waiting := p.queue[user]; !waiting {
+		p.users = append(p.users, user)
+	}
+	p.queue[user] = append(p.queue[user], granted)
+	p.mu.Unlock()
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.dequeue(user, granted) {
+			return ctx.Err()
+		}
+		// granted meanwhile: give the slot back
+		p.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+func (p *workerPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.releaseLocked()
+}
+
+// releaseLocked hands the slot over to the next user waiting, if any
+func (p *workerPool) releaseLocked() {
+	if len(p.users) == 0 {
+		p.free++
+		return
+	}
+	user := p.users[0]
+	p.users = p.users[1:]
+	waiting := p.queue[user]
+	next := waiting[0]
+	if len(waiting) > 1 {
+		p.queue[user] = waiting[1:]
+		p.users = append(p.users, user)
+	} else {
+		delete(p.queue, user)
+	}
+	close(next)
+}
+
+// dequeue removes a waiting task, returning false if it isn't waiting anymore
+func (p *workerPool) dequeue(user string, granted chan struct{}) bool {
+	waiting := p.queue[user]
+	for i := range waiting {
+		if waiting[i] != granted {
+			continue
+		}
+		if len(waiting) > 1 {
+			p.queue[user] = append(waiting[:i:i], waiting[i+1:]...)
+			return true
+		}
+		delete(p.queue, user)
+		for j := range p.users {
+			if p.users[j] == user {
+				p.users = append(p.users[:j:j], p.users[j+1:]...)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+var (
+	poolsOnce sync.Once
+	lokiPool  *workerPool
+	promPool  *workerPool
+)
+
+// datasourcePools returns the pools shared by all API requests, bounding concurrent queries to each datasource
+func datasourcePools(cfg *config.Concurrency) (*workerPool, *workerPool) {
+	poolsOnce.Do(func() {
+		lokiPool = newWorkerPool(cfg.Loki)
+		promPool = newWorkerPool(cfg.Prometheus)
+	})
+	return lokiPool, promPool
+}
+
+// userKey identifies the user of a request for fair scheduling, from a hash of its token
+func userKey(requestHeader http.Header) string {
+	if requestHeader == nil {
+		return ""
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(requestHeader.Get(auth.AuthHeader)))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// parallel runs functions concurrently and waits for all of them
+func parallel(fns ...func()) {
+	var wg sync.WaitGroup
+	wg.Add(len(fns))
+	for _, f := range fns {
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+	"github.com/netobserv/network-observability-console-plugin/pkg/httpclient/httpclienttest"
+	"github.com/netobserv/network-observability-console-plugin/pkg/loki"
+)
+
+func TestWorkerPool_Bounded(t *testing.T) {
+	p := newWorkerPool(2)
+	var running, maxRunning atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.run(context.Background(), "user", func() {
+				n := running.Add(1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				running.Add(-1)
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxRunning.Load())
+	assert.Equal(t, 2, p.free)
+	assert.Empty(t, p.queue)
+	assert.Empty(t, p.users)
+}
+
+func TestWorkerPool_Fair(t *testing.T) {
+	p := newWorkerPool(1)
+	require.NoError(t, p.acquire(context.Background(), "busy"))
+
+	// "busy" queues several tasks before "other" queues one
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(user string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = p.run(context.Background(), user, func() {
+				mu.Lock()
+				order = append(order, user)
+				mu.Unlock()
+			})
+		}()
+	}
+	for i := range 3 {
+		enqueue("busy")
+		waitQueued(t, p, i+1)
+	}
+	enqueue("other")
+	waitQueued(t, p, 4)
+
+	p.release()
+	wg.Wait()
+	assert.Equal(t, []string{"busy", "other", "busy", "busy"}, order)
+}
+
+// waitQueued waits until the given number of tasks are waiting for a slot
+func waitQueued(t *testing.T, p *workerPool, n int) {
+	require.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		queued := 0
+		for _, q := range p.queue {
+			queued += len(q)
+		}
+		return queued == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestWorkerPool_Cancel(t *testing.T) {
+	p := newWorkerPool(1)
+	require.NoError(t, p.acquire(context.Background(), "a"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- p.run(ctx, "b", func() { t.Error("should not run") })
+	}()
+	waitQueued(t, p, 1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Empty(t, p.queue)
+	assert.Empty(t, p.users)
+
+	p.release()
+	assert.Equal(t, 1, p.free)
+}
+
+func TestWorkerPool_Nil(t *testing.T) {
+	var p *workerPool
+	ran := false
+	require.NoError(t, p.run(context.Background(), "", func() { ran = true }))
+	assert.True(t, ran)
+	assert.Nil(t, newWorkerPool(0))
+}
+
+func TestClients_PoolCanceled(t *testing.T) {
+	lokiClientMock := new(httpclienttest.HTTPClientMock)
+	p := newWorkerPool(1)
+	require.NoError(t, p.acquire(context.Background(), "a"))
+	cl := clients{loki: lokiClientMock, lokiPool: p, user: "b"}
+
+	// requests whose client went away give up waiting for a slot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	code, err := cl.fetchParallel(ctx, []string{"q1", "q2"}, nil, loki.NewStreamMerger(10), false)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.ErrorContains(t, err, context.Canceled.Error())
+	_, code, err = cl.runLoki(ctx, func() ([]string, int, apierrors.StructuredError) { return nil, http.StatusOK, nil })
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.ErrorContains(t, err, context.Canceled.Error())
+	lokiClientMock.AssertNotCalled(t, "Get")
+}
+
+func TestClients_SingleQueryWaitsForSlot(t *testing.T) {
+	lokiClientMock := new(httpclienttest.HTTPClientMock)
+	lokiClientMock.On("Get", mock.Anything).Return([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`), http.StatusOK, nil)
+	p := newWorkerPool(1)
+	require.NoError(t, p.acquire(context.Background(), "a"))
+	cl := clients{loki: lokiClientMock, lokiPool: p, user: "b"}
+
+	done := make(chan int)
+	go func() {
+		code, err := cl.fetchSingle(context.Background(), "q", nil, loki.NewStreamMerger(10), false)
+		assert.NoError(t, err)
+		done <- code
+	}()
+	waitQueued(t, p, 1)
+	lokiClientMock.AssertNotCalled(t, "Get", mock.Anything)
+
+	p.release()
+	assert.Equal(t, http.StatusOK, <-done)
+	lokiClientMock.AssertNumberOfCalls(t, "Get", 1)
+	assert.Equal(t, 1, p.free)
+}
@@ -3,6 +3,7 @@ package httpclient
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
@@ -16,20 +17,68 @@ type Caller interface {
 	Get(url string) ([]byte, int, error)
 }
 
+// StreamCaller is implemented by callers able to return the response body as a reader, to decode it incrementally.
+// The body must be closed by the caller.
+type StreamCaller interface {
+	GetStream(url string) (io.ReadCloser, int, error)
+}
+
+// ResponseTooLargeError is returned when reading a response body exceeding the maximum size
+type ResponseTooLargeError struct {
+	MaxSize int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response exceeds the maximum size of %d bytes", e.MaxSize)
+}
+
+// limitedReadCloser fails with ResponseTooLargeError, rather than truncating, when reading more than the maximum size
+type limitedReadCloser struct {
+	io.ReadCloser
+	remaining int64
+	maxSize   int64
+}
+
+// LimitReadCloser limits the size of a response body; no limit is applied when maxSize is zero or negative
+func LimitReadCloser(rc io.ReadCloser, maxSize int64) io.ReadCloser {
+	if maxSize <= 0 {
+		return rc
+	}
+	return &limitedReadCloser{ReadCloser: rc, remaining: maxSize, maxSize: maxSize}
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &ResponseTooLargeError{MaxSize: l.maxSize}
+	}
+	// read one more byte than allowed to detect overflows
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), &ResponseTooLargeError{MaxSize: l.maxSize}
+	}
+	return n, err
+}
+
 type httpClient struct {
 	Caller
 	client  http.Client
 	headers map[string][]string
+	maxSize int64
 }
 
 var slog = logrus.WithField("module", "server")
 
-func NewClientWrapper(timeout time.Duration, overrideHeaders map[string][]string, skipTLS bool, capath string, userCertPath string, userKeyPath string) Caller {
+func NewClientWrapper(timeout time.Duration, overrideHeaders map[string][]string, skipTLS bool, capath string, userCertPath string, userKeyPath string, maxResponseSize int64) Caller {
 	// TODO: use same prom RoundTripper helper insead of this client wrapper for Loki
-	tr := NewTransport(timeout, skipTLS, capath, userCertPath, userKeyPath)
+	tr := SharedTransport(TransportKey{Timeout: timeout, SkipTLS: skipTLS, CAPath: capath, UserCertPath: userCertPath, UserKeyPath: userKeyPath})
 	return &httpClient{
 		client:  http.Client{Transport: tr, Timeout: timeout},
 		headers: overrideHeaders,
+		maxSize: maxResponseSize,
 	}
 }
 
@@ -70,6 +119,16 @@ func NewTransport(timeout time.Duration, skipTLS bool, capath string, userCertPa
 }
 
 func (hc *httpClient) Get(url string) ([]byte, int, error) {
+	body, code, err := hc.GetStream(url)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	return b, code, err
+}
+
+func (hc *httpClient) GetStream(url string) (io.ReadCloser, int, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, 0, err
@@ -82,7 +141,5 @@ func (hc *httpClient) Get(url string) ([]byte, int, error) {
 	if err != nil {
 		return nil, 0, err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	return body, resp.StatusCode, err
+	return LimitReadCloser(resp.Body, hc.maxSize), resp.StatusCode, nil
 }
@@ -0,0 +1,162 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
+)
+
+// TransportKey identifies transports that can be shared, ie. having the same timeout and TLS settings
+type TransportKey struct {
+	Timeout      time.Duration
+	SkipTLS      bool
+	CAPath       string
+	UserCertPath string
+	UserKeyPath  string
+}
+
+func (k *TransportKey) files() []string {
+	if k.SkipTLS {
+		return nil
+	}
+	return []string{k.CAPath, k.UserCertPath, k.UserKeyPath}
+}
+
+// fileVersion identifies the version of a file on disk, to detect changes
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) fileVersion {
+	if path == "" {
+		return fileVersion{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileVersion{}
+	}
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}
+}
+
+func statFiles(paths []string) []fileVersion {
+	versions := make([]fileVersion, len(paths))
+	for i, p := range paths {
+		versions[i] = statFile(p)
+	}
+	return versions
+}
+
+func (v fileVersion) equal(other fileVersion) bool {
+	return v.modTime.Equal(other.modTime) && v.size == other.size
+}
+
+func sameVersions(a, b []fileVersion) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+type pooledTransport struct {
+	transport *http.Transport
+	versions  []fileVersion
+}
+
+// transportPool holds transports shared by all clients, so that connections are kept alive and TLS sessions reused across API requests
+type transportPool struct {
+	mu         sync.Mutex
+	transports map[TransportKey]*pooledTransport
+}
+
+var sharedTransports = transportPool{transports: map[TransportKey]*pooledTransport{}}
+
+// SharedTransport returns the transport shared for the given settings. It is created on first use,
+// and recreated when the CA or user certificate files change on disk.
+func SharedTransport(key TransportKey) *http.Transport {
+	return sharedTransports.get(key)
+}
+
+func (p *transportPool) get(key TransportKey) *http.Transport {
+	versions := statFiles(key.files())
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pooled, ok := p.transports[key]
+	if ok && sameVersions(pooled.versions, versions) {
+		metrics.ObserveTransportLookup(metrics.TransportHit)
+		return pooled.transport
+	}
+	if ok {
+		slog.Infof("TLS files changed, refreshing transport for %s", key.CAPath)
+		pooled.transport.CloseIdleConnections()
+		metrics.ObserveTransportLookup(metrics.TransportRefreshed)
+	} else {
+		metrics.ObserveTransportLookup(metrics.TransportCreated)
+	}
+	transport := NewTransport(key.Timeout, key.SkipTLS, key.CAPath, key.UserCertPath, key.UserKeyPath)
+	transport.DialContext = countConnections(transport.DialContext)
+	p.transports[key] = &pooledTransport{transport: transport, versions: versions}
+	metrics.SetTransportPoolSize(len(p.transports))
+	return transport
+}
+
+type dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// countConnections tracks open connections in metrics
+func countConnections(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		metrics.AddTransportConnections(1)
+		return &countedConn{Conn: conn}, nil
+	}
+}
+
+type countedConn struct {
+	net.Conn
+	closeOnce sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.closeOnce.Do(func() { metrics.AddTransportConnections(-1) })
+	return c.Conn.Close()
+}
+
+type cachedToken struct {
+	token   string
+	version fileVersion
+}
+
+var (
+	tokensMu sync.Mutex
+	tokens   = map[string]cachedToken{}
+)
+
+// ReadTokenFile reads a token file, only hitting the disk again when it changes, eg. after a token rotation
+func ReadTokenFile(path string) (string, error) {
+	version := statFile(path)
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+	if cached, ok := tokens[path]; ok && !version.modTime.IsZero() && cached.version.equal(version) {
+		return cached.token, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		delete(tokens, path)
+		return "", err
+	}
+	tokens[path] = cachedToken{token: string(b), version: version}
+	return string(b), nil
+}
@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedTransport(t *testing.T) {
+	pool := transportPool{transports: map[TransportKey]*pooledTransport{}}
+	caPath := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caPath, []byte("not a cert"), 0600))
+	key := TransportKey{Timeout: time.Second, CAPath: caPath}
+
+	tr := pool.get(key)
+	assert.Same(t, tr, pool.get(key))
+
+	// Different TLS settings use another transport
+	other := pool.get(TransportKey{Timeout: time.Second, SkipTLS: true})
+	assert.NotSame(t, tr, other)
+	assert.Len(t, pool.transports, 2)
+
+	// CA file changed on disk
+	require.NoError(t, os.WriteFile(caPath, []byte("another cert"), 0600))
+	refreshed := pool.get(key)
+	assert.NotSame(t, tr, refreshed)
+	assert.Same(t, refreshed, pool.get(key))
+	assert.Len(t, pool.transports, 2)
+}
+
+func TestReadTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("token-1"), 0600))
+	token, err := ReadTokenFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// Rotated
+	require.NoError(t, os.WriteFile(path, []byte("token-22"), 0600))
+	token, err = ReadTokenFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "token-22", token)
+
+	require.NoError(t, os.Remove(path))
+	_, err = ReadTokenFile(path)
+	assert.Error(t, err)
+}
@@ -28,6 +28,8 @@ const (
 type Checker interface {
 	CheckAuth(ctx context.Context, header http.Header) error
 	CheckAdmin(ctx context.Context, header http.Header) error
+	CheckNamespace(ctx context.Context, header http.Header, namespace string) error
+	GetUsername(ctx context.Context, header http.Header) (string, error)
 }
 
 func NewChecker(typez CheckType, apiProvider client.APIProvider) (Checker, error) {
@@ -59,6 +61,15 @@ func (b *NoopChecker) CheckAdmin(_ context.Context, _ http.Header) error {
 	return nil
 }
 
+func (b *NoopChecker) CheckNamespace(_ context.Context, _ http.Header, _ string) error {
+	hlog.Debug("noop auth checker: ignore auth")
+	return nil
+}
+
+func (b *NoopChecker) GetUsername(_ context.Context, _ http.Header) (string, error) {
+	return "", nil
+}
+
 type DenyAllChecker struct {
 	Checker
 }
@@ -73,6 +84,15 @@ func (b *DenyAllChecker) CheckAdmin(_ context.Context, _ http.Header) error {
 	return errors.New("deny all auth mode selected")
 }
 
+func (b *DenyAllChecker) CheckNamespace(_ context.Context, _ http.Header, _ string) error {
+	hlog.Debug("deny all auth checker: deny auth")
+	return errors.New("deny all auth mode selected")
+}
+
+func (b *DenyAllChecker) GetUsername(_ context.Context, _ http.Header) (string, error) {
+	return "", errors.New("deny all auth mode selected")
+}
+
 func GetUserToken(header http.Header) (string, error) {
 	authValue := header.Get(AuthHeader)
 	if authValue != "" {
@@ -161,3 +181,44 @@ func (c *BearerTokenChecker) CheckAdmin(ctx context.Context, header http.Header)
 	hlog.Debug("Checking admin: passed")
 	return nil
 }
+
+func (c *BearerTokenChecker) CheckNamespace(ctx context.Context, header http.Header, namespace string) error {
+	hlog.Debugf("Checking namespace access: %s", namespace)
+	token, err := GetUserToken(header)
+	if err != nil {
+		return err
+	}
+	client, err := c.apiProvider()
+	if err != nil {
+		return err
+	}
+	if err = client.CheckNamespaceAccess(ctx, token, namespace); err != nil {
+		return err
+	}
+
+	hlog.Debug("Checking namespace access: passed")
+	return nil
+}
+
+func (c *BearerTokenChecker) GetUsername(ctx context.Context, header http.Header) (string, error) {
+	token, err := GetUserToken(header)
+	if err != nil {
+		return "", err
+	}
+	client, err := c.apiProvider()
+	if err != nil {
+		return "", err
+	}
+	rvw, err := client.CreateTokenReview(ctx, &authv1.TokenReview{
+		Spec: authv1.TokenReviewSpec{
+			Token: token,
+		},
+	}, &metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	if !rvw.Status.Authenticated {
+		return "", errors.New("user not authenticated")
+	}
+	return rvw.Status.User.Username, nil
+}
@@ -184,6 +184,11 @@ func (m *AuthCheckMock) CheckAdmin(ctx context.Context, token string) error {
 	return args.Error(0)
 }
 
+func (m *AuthCheckMock) CheckNamespaceAccess(ctx context.Context, token, namespace string) error {
+	args := m.Called(ctx, token, namespace)
+	return args.Error(0)
+}
+
 func (m *AuthCheckMock) mockError() {
 	m.On("CreateTokenReview", mock.Anything, mock.Anything, mock.Anything).Return(&authv1.TokenReview{}, errors.New(fakeError))
 }
@@ -209,6 +214,28 @@ func (m *AuthCheckMock) mockAdmin() {
 	m.On("CheckAdmin", mock.Anything, mock.Anything).Return(nil)
 }
 
+func TestCheckNamespaceAndUsername(t *testing.T) {
+	m := AuthCheckMock{}
+	m.mockNormalUser()
+	m.On("CheckNamespaceAccess", mock.Anything, "abcdef", "ns1").Return(nil)
+	m.On("CheckNamespaceAccess", mock.Anything, "abcdef", "ns2").Return(errors.New("access denied to namespace ns2"))
+	checker := setupChecker(CheckAuthenticated, &m)
+	header := http.Header{"Authorization": []string{"Bearer abcdef"}}
+
+	require.NoError(t, checker.CheckNamespace(context.TODO(), header, "ns1"))
+	err := checker.CheckNamespace(context.TODO(), header, "ns2")
+	require.Error(t, err)
+	require.Equal(t, "access denied to namespace ns2", err.Error())
+
+	username, err := checker.GetUsername(context.TODO(), header)
+	require.NoError(t, err)
+	require.Equal(t, "user1", username)
+
+	_, err = checker.GetUsername(context.TODO(), http.Header{})
+	require.Error(t, err)
+	require.Equal(t, "missing Authorization header", err.Error())
+}
+
 func mockedTokenReviewStatus(isAuth bool) authv1.TokenReviewStatus {
 	st := authv1.TokenReviewStatus{
 		Authenticated: isAuth,
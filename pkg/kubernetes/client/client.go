@@ -2,8 +2,10 @@ package client
 
 import (
 	"context"
+	"fmt"
 
 	authv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -13,6 +15,7 @@ import (
 type KubeAPI interface {
 	CreateTokenReview(ctx context.Context, tr *authv1.TokenReview, opts *metav1.CreateOptions) (*authv1.TokenReview, error)
 	CheckAdmin(ctx context.Context, token string) error
+	CheckNamespaceAccess(ctx context.Context, token, namespace string) error
 }
 
 type APIProvider func() (KubeAPI, error)
@@ -42,6 +45,37 @@ func (c *InCluster) CheckAdmin(ctx context.Context, token string) error {
 	return err
 }
 
+// CheckNamespaceAccess checks that the token owner can get pods in the namespace, similarly to the tenancy checks done for metrics
+func (c *InCluster) CheckNamespaceAccess(ctx context.Context, token, namespace string) error {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return err
+	}
+	config.BearerToken = token
+	config.BearerTokenFile = ""
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authzv1.SelfSubjectAccessReview{
+		Spec: authzv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authzv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Resource:  "pods",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("access denied to namespace %s", namespace)
+	}
+	return nil
+}
+
 func NewInCluster() (KubeAPI, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
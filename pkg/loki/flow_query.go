@@ -17,7 +17,9 @@ const (
 	startParam      = "start"
 	endParam        = "end"
 	limitParam      = "limit"
+	timeParam       = "time"
 	queryRangePath  = "/loki/api/v1/query_range?query="
+	queryPath       = "/loki/api/v1/query?query="
 	jsonOrJoiner    = "+or+"
 	emptyMatch      = `""`
 )
@@ -27,13 +29,14 @@ var filterRegexpValidation = regexp.MustCompile(`^[\w-_.,\"*:/]*$`)
 
 // FlowQueryBuilder stores a state to build a LogQL query
 type FlowQueryBuilder struct {
-	config       *config.Loki
-	startTime    string
-	endTime      string
-	limit        string
-	labelFilters []filters.LabelFilter
-	lineFilters  []filters.LineFilter
-	jsonFilters  [][]filters.LabelFilter
+	config          *config.Loki
+	startTime       string
+	endTime         string
+	limit           string
+	labelFilters    []filters.LabelFilter
+	metadataFilters [][]filters.LabelFilter
+	lineFilters     []filters.LineFilter
+	jsonFilters     [][]filters.LabelFilter
 }
 
 func NewFlowQueryBuilder(cfg *config.Loki, start, end, limit string,
@@ -56,7 +59,23 @@ func NewFlowQueryBuilder(cfg *config.Loki, start, end, limit string,
 	}
 
 	lineFilters := []filters.LineFilter{}
-	if packetLoss == constants.PacketLossDropped {
+	metadataFilters := [][]filters.LabelFilter{}
+	if cfg.IsStructuredMetadata(fields.PktDropPackets) {
+		// same as below, without matching the JSON payload
+		switch packetLoss {
+		case constants.PacketLossDropped:
+			if cfg.IsStructuredMetadata(fields.Packets) {
+				metadataFilters = append(metadataFilters, []filters.LabelFilter{filters.StringEqualLabelFilter(fields.Packets, "")})
+			} else {
+				lineFilters = append(lineFilters, filters.NotContainsKeyLineFilter(fields.Packets))
+			}
+			metadataFilters = append(metadataFilters, []filters.LabelFilter{filters.MoreThanNumberLabelFilter(fields.PktDropPackets, "1")})
+		case constants.PacketLossHasDrop:
+			metadataFilters = append(metadataFilters, []filters.LabelFilter{filters.MoreThanNumberLabelFilter(fields.PktDropPackets, "1")})
+		case constants.PacketLossSent:
+			metadataFilters = append(metadataFilters, []filters.LabelFilter{filters.StringEqualLabelFilter(fields.PktDropPackets, "")})
+		}
+	} else if packetLoss == constants.PacketLossDropped {
 		// match records that doesn't contains "Packets" field and 1+ packets dropped
 		// as FLP will ensure the filtering
 		lineFilters = append(lineFilters,
@@ -77,12 +96,13 @@ func NewFlowQueryBuilder(cfg *config.Loki, start, end, limit string,
 	}
 
 	return &FlowQueryBuilder{
-		config:       cfg,
-		startTime:    start,
-		endTime:      end,
-		limit:        limit,
-		labelFilters: labelFilters,
-		lineFilters:  lineFilters,
+		config:          cfg,
+		startTime:       start,
+		endTime:         end,
+		limit:           limit,
+		labelFilters:    labelFilters,
+		metadataFilters: metadataFilters,
+		lineFilters:     lineFilters,
 	}
 }
 
@@ -90,6 +110,15 @@ func NewFlowQueryBuilderWithDefaults(cfg *config.Loki) *FlowQueryBuilder {
 	return NewFlowQueryBuilder(cfg, "", "", "", constants.RecordTypeLog, constants.PacketLossAll)
 }
 
+// WithPage returns a copy of the builder querying another time range, with another limit
+func (q *FlowQueryBuilder) WithPage(start, end, limit string) *FlowQueryBuilder {
+	page := *q
+	page.startTime = start
+	page.endTime = end
+	page.limit = limit
+	return &page
+}
+
 func (q *FlowQueryBuilder) Filters(queryFilters filters.SingleQuery) error {
 	for _, filter := range queryFilters {
 		if err := q.addFilter(filter); err != nil {
@@ -111,8 +140,10 @@ func (q *FlowQueryBuilder) addFilter(filter filters.Match) error {
 		if lf, ok := filter.ToLabelFilter(); ok {
 			q.labelFilters = append(q.labelFilters, lf)
 		}
+	} else if q.config.IsStructuredMetadata(filter.Key) {
+		q.addMetadataFilters(filter, values)
 	} else if q.config.IsIP(filter.Key) {
-		q.addIPFilters(filter.Key, values, filter.Not)
+		q.jsonFilters = append(q.jsonFilters, ipFilters(filter.Key, values, filter.Not)...)
 	} else {
 		q.addLineFilters(filter, values)
 	}
@@ -147,20 +178,30 @@ func (q *FlowQueryBuilder) addLineFilters(filter filters.Match, values []string)
 	}
 }
 
-// addIPFilters assumes that we are searching for that IP addresses as part
-// of the log line (not in the stream selector labels)
-func (q *FlowQueryBuilder) addIPFilters(key string, values []string, not bool) {
+// addMetadataFilters filters on structured metadata with label filter expressions, which don't require parsing JSON
+func (q *FlowQueryBuilder) addMetadataFilters(filter filters.Match, values []string) {
+	if q.config.IsIP(filter.Key) {
+		q.metadataFilters = append(q.metadataFilters, ipFilters(filter.Key, values, filter.Not)...)
+	} else if lf, ok := filter.ToLabelFilter(); ok {
+		q.metadataFilters = append(q.metadataFilters, []filters.LabelFilter{lf})
+	}
+}
+
+// ipFilters assumes that we are searching for that IP addresses as part
+// of the log line or structured metadata (not in the stream selector labels)
+func ipFilters(key string, values []string, not bool) [][]filters.LabelFilter {
+	var res [][]filters.LabelFilter
 	if not {
 		// NOT IP filters means we don't want any of the values, ie. IP!=A AND IP!=B instead of IP!=A OR IP!=B
 		for _, value := range values {
 			// empty exact matches should be treated as attribute filters looking for empty IP
 			if value == emptyMatch {
-				q.jsonFilters = append(q.jsonFilters, []filters.LabelFilter{filters.NotStringLabelFilter(key, "")})
+				res = append(res, []filters.LabelFilter{filters.NotStringLabelFilter(key, "")})
 			} else {
-				q.jsonFilters = append(q.jsonFilters, []filters.LabelFilter{filters.NotIPLabelFilter(key, value)})
+				res = append(res, []filters.LabelFilter{filters.NotIPLabelFilter(key, value)})
 			}
 		}
-		return
+		return res
 	}
 	// Positive case
 	filtersPerKey := make([]filters.LabelFilter, 0, len(values))
@@ -172,7 +213,7 @@ func (q *FlowQueryBuilder) addIPFilters(key string, values []string, not bool) {
 			filtersPerKey = append(filtersPerKey, filters.IPLabelFilter(key, value))
 		}
 	}
-	q.jsonFilters = append(q.jsonFilters, filtersPerKey)
+	return append(res, filtersPerKey)
 }
 
 func (q *FlowQueryBuilder) createStringBuilderURL() *strings.Builder {
@@ -182,6 +223,13 @@ func (q *FlowQueryBuilder) createStringBuilderURL() *strings.Builder {
 	return &sb
 }
 
+func (q *FlowQueryBuilder) createInstantStringBuilderURL() *strings.Builder {
+	sb := strings.Builder{}
+	sb.WriteString(strings.TrimRight(q.config.URL, "/"))
+	sb.WriteString(queryPath)
+	return &sb
+}
+
 func (q *FlowQueryBuilder) appendLabels(sb *strings.Builder) {
 	sb.WriteString("{")
 	for i, ss := range q.labelFilters {
@@ -193,6 +241,10 @@ func (q *FlowQueryBuilder) appendLabels(sb *strings.Builder) {
 	sb.WriteByte('}')
 }
 
+func (q *FlowQueryBuilder) appendMetadataFilters(sb *strings.Builder) {
+	appendLabelFilterGroups(sb, q.metadataFilters)
+}
+
 func (q *FlowQueryBuilder) appendLineFilters(sb *strings.Builder) {
 	for _, lf := range q.lineFilters {
 		lf.WriteInto(sb)
@@ -246,14 +298,19 @@ func (q *FlowQueryBuilder) appendRTTFilter(sb *strings.Builder) {
 func (q *FlowQueryBuilder) appendJSON(sb *strings.Builder, forceAppend bool) {
 	if forceAppend || len(q.jsonFilters) > 0 {
 		sb.WriteString("|json")
-		for _, lfPerKey := range q.jsonFilters {
-			sb.WriteByte('|')
-			for i, lf := range lfPerKey {
-				if i > 0 {
-					sb.WriteString(jsonOrJoiner)
-				}
-				lf.WriteInto(sb)
+		appendLabelFilterGroups(sb, q.jsonFilters)
+	}
+}
+
+// appendLabelFilterGroups writes label filter expressions, where filters of a same group are joined with OR
+func appendLabelFilterGroups(sb *strings.Builder, groups [][]filters.LabelFilter) {
+	for _, lfPerKey := range groups {
+		sb.WriteByte('|')
+		for i, lf := range lfPerKey {
+			if i > 0 {
+				sb.WriteString(jsonOrJoiner)
 			}
+			lf.WriteInto(sb)
 		}
 	}
 }
@@ -270,9 +327,20 @@ func (q *FlowQueryBuilder) appendQueryParams(sb *strings.Builder) {
 	}
 }
 
+// appendInstantQueryParams sets the evaluation time at the end of the range, defaulting to now
+func (q *FlowQueryBuilder) appendInstantQueryParams(sb *strings.Builder) {
+	if len(q.endTime) > 0 {
+		appendQueryParam(sb, timeParam, q.endTime)
+	}
+	if len(q.limit) > 0 {
+		appendQueryParam(sb, limitParam, q.limit)
+	}
+}
+
 func (q *FlowQueryBuilder) Build() string {
 	sb := q.createStringBuilderURL()
 	q.appendLabels(sb)
+	q.appendMetadataFilters(sb)
 	q.appendLineFilters(sb)
 	q.appendJSON(sb, false)
 	q.appendQueryParams(sb)
@@ -0,0 +1,23 @@
+package loki
+
+import "strings"
+
+const indexStatsPath = "/loki/api/v1/index/stats?query="
+
+// BuildIndexStats builds a query for the Loki index stats API, which returns the number of streams, chunks, entries and bytes
+// matched by the stream selector, computed from the index only. Line and JSON filters are ignored, making it an upper bound.
+func (q *FlowQueryBuilder) BuildIndexStats() string {
+	// Build index stats query like:
+	// /loki/api/v1/index/stats?query={<label filters>}&start=<start>&end=<end>
+	sb := strings.Builder{}
+	sb.WriteString(strings.TrimRight(q.config.URL, "/"))
+	sb.WriteString(indexStatsPath)
+	q.appendLabels(&sb)
+	if len(q.startTime) > 0 {
+		appendQueryParam(&sb, startParam, q.startTime)
+	}
+	if len(q.endTime) > 0 {
+		appendQueryParam(&sb, endParam, q.endTime)
+	}
+	return sb.String()
+}
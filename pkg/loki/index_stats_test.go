@@ -0,0 +1,25 @@
+package loki
+
+import (
+	"testing"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildIndexStats(t *testing.T) {
+	q := NewFlowQueryBuilder(&lokiConfig, "1000", "4600", "50", constants.RecordTypeLog, constants.PacketLossAll)
+	err := q.Filters(filters.SingleQuery{
+		filters.NewEqualMatch("SrcK8S_Namespace", `"ns1"`),
+		// line filters are ignored
+		filters.NewEqualMatch("SrcPort", "80"),
+	})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/index/stats?query={app=\"netobserv-flowcollector\",SrcK8S_Namespace=\"ns1\"}&start=1000&end=4600",
+		q.BuildIndexStats(),
+	)
+}
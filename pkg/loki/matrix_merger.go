@@ -14,7 +14,7 @@ type MatrixMerger struct {
 	Merger
 	index        map[string]indexedSampleStream
 	merged       model.Matrix
-	stats        []interface{}
+	stats        []model.QueryStats
 	numQueries   int
 	reqLimit     int
 	limitReached bool
@@ -25,7 +25,7 @@ func NewMatrixMerger(reqLimit int) *MatrixMerger {
 		reqLimit: reqLimit,
 		index:    map[string]indexedSampleStream{},
 		merged:   model.Matrix{},
-		stats:    []interface{}{},
+		stats:    []model.QueryStats{},
 	}
 }
 
@@ -42,7 +42,9 @@ func (m *MatrixMerger) Add(from model.QueryResponseData) (model.ResultValue, err
 	}
 
 	m.numQueries++
-	m.stats = append(m.stats, from.Stats)
+	if from.Stats != nil {
+		m.stats = append(m.stats, *from.Stats)
+	}
 	// In Matrix results, the limit stands for the "topk" value, which relates to the number of streams
 	//	(ie LabelSet cardinality)
 	if len(matrix) >= m.reqLimit {
@@ -94,6 +96,7 @@ func (m *MatrixMerger) Get() *model.AggregatedQueryResponse {
 			NumQueries:   m.numQueries,
 			LimitReached: m.limitReached,
 			QueriesStats: m.stats,
+			Totals:       model.TotalCosts(m.stats),
 		},
 	}
 }
@@ -8,3 +8,11 @@ type Merger interface {
 	Add(from model.QueryResponseData) (model.ResultValue, error)
 	Get() *model.AggregatedQueryResponse
 }
+
+// StreamingMerger is implemented by mergers able to merge a query result one stream at a time, as it is decoded
+type StreamingMerger interface {
+	Merger
+	AddStream(stream model.Stream)
+	// EndQuery completes the merge of a query result, given its stats and number of entries
+	EndQuery(stats *model.QueryStats, entries int)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/netobserv/network-observability-console-plugin/pkg/config"
 	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -82,3 +83,45 @@ func TestFlowQuery_AddRecordTypeLabelFilter(t *testing.T) {
 	urlQuery := query.Build()
 	assert.Equal(t, `/loki/api/v1/query_range?query={app="netobserv-flowcollector",_RecordType="flowLog",foo="bar",flis="flas"}`, urlQuery)
 }
+
+func TestFlowQuery_AddStructuredMetadataFilters(t *testing.T) {
+	cfg := config.Loki{
+		URL:                "/",
+		Labels:             []string{"foo"},
+		StructuredMetadata: []string{"SrcPort", "SrcAddr", "SrcK8S_Name"},
+		FieldsType:         map[string]string{"SrcPort": "number"},
+		FieldsFormat:       map[string]string{"SrcAddr": "IP"},
+	}
+	query := NewFlowQueryBuilderWithDefaults(&cfg)
+	err := query.Filters(filters.SingleQuery{
+		filters.NewRegexMatch("foo", `"bar"`),
+		filters.NewEqualMatch("SrcPort", "80,443"),
+		filters.NewEqualMatch("SrcAddr", "10.0.0.0/8"),
+		filters.NewRegexMatch("SrcK8S_Name", "web"),
+		filters.NewRegexMatch("DstK8S_Name", `"db"`),
+	})
+	require.NoError(t, err)
+	urlQuery := query.Build()
+	assert.Equal(t, `/loki/api/v1/query_range?query={app="netobserv-flowcollector",foo="bar"}`+
+		`|SrcPort=~"80|443"|SrcAddr=ip("10.0.0.0/8")|SrcK8S_Name=~"(?i).*web.*"`+
+		`|~`+backtick(`DstK8S_Name":"db"`), urlQuery)
+}
+
+func TestFlowQuery_PacketLossStructuredMetadata(t *testing.T) {
+	cfg := config.Loki{URL: "/", StructuredMetadata: []string{"PktDropPackets"}}
+	query := NewFlowQueryBuilder(&cfg, "", "", "", constants.RecordTypeLog, constants.PacketLossDropped)
+	assert.Equal(t, `/loki/api/v1/query_range?query={app="netobserv-flowcollector"}|PktDropPackets>=1!~`+backtick(`"Packets"`), query.Build())
+
+	query = NewFlowQueryBuilder(&cfg, "", "", "", constants.RecordTypeLog, constants.PacketLossSent)
+	assert.Equal(t, `/loki/api/v1/query_range?query={app="netobserv-flowcollector"}|PktDropPackets=""`, query.Build())
+}
+
+func TestFlowQuery_WithPage(t *testing.T) {
+	cfg := config.Loki{URL: "/", Labels: []string{"foo"}}
+	query := NewFlowQueryBuilder(&cfg, "100", "200", "50", constants.RecordTypeLog, constants.PacketLossAll)
+	require.NoError(t, query.addFilter(filters.NewRegexMatch("foo", `"bar"`)))
+	page := query.WithPage("100000000000", "150000000001", "10")
+	assert.Equal(t, `/loki/api/v1/query_range?query={app="netobserv-flowcollector",foo="bar"}&start=100000000000&end=150000000001&limit=10`, page.Build())
+	// the original builder is unchanged
+	assert.Equal(t, `/loki/api/v1/query_range?query={app="netobserv-flowcollector",foo="bar"}&start=100&end=200&limit=50`, query.Build())
+}
@@ -12,7 +12,7 @@ type StreamMerger struct {
 	Merger
 	index        map[string]indexedStream
 	merged       model.Streams
-	stats        []interface{}
+	stats        []model.QueryStats
 	numQueries   int
 	reqLimit     int
 	totalEntries int
@@ -25,7 +25,7 @@ func NewStreamMerger(reqLimit int) *StreamMerger {
 		reqLimit: reqLimit,
 		index:    map[string]indexedStream{},
 		merged:   model.Streams{},
-		stats:    []interface{}{},
+		stats:    []model.QueryStats{},
 	}
 }
 
@@ -61,49 +61,59 @@ func (m *StreamMerger) Add(from model.QueryResponseData) (model.ResultValue, err
 		return nil, fmt.Errorf("loki returned an unexpected type for StreamMerger: %T", from)
 	}
 
-	m.numQueries++
-	m.stats = append(m.stats, from.Stats)
 	totalEntries := 0
 	for _, stream := range streams {
-		lkey := uniqueStream(&stream)
-		idxStream, streamExists := m.index[lkey]
-		if !streamExists {
-			// Stream doesn't exist => create new index
-			idxStream = indexedStream{
-				stream:  stream,
-				entries: map[string]interface{}{},
-				index:   len(m.index),
-			}
+		m.AddStream(stream)
+		totalEntries += len(stream.Entries)
+	}
+	m.EndQuery(from.Stats, totalEntries)
+	return m.merged, nil
+}
+
+func (m *StreamMerger) AddStream(stream model.Stream) {
+	lkey := uniqueStream(&stream)
+	idxStream, streamExists := m.index[lkey]
+	if !streamExists {
+		// Stream doesn't exist => create new index
+		idxStream = indexedStream{
+			stream:  stream,
+			entries: map[string]interface{}{},
+			index:   len(m.index),
 		}
-		// Merge content (entries)
-		for _, e := range stream.Entries {
-			totalEntries++
-			ekey := uniqueEntry(&e)
-			if _, entryExists := idxStream.entries[ekey]; !entryExists {
-				// Add entry to the existing stream, and mark it as existing in idxStream.entries
-				idxStream.entries[ekey] = nil
-				if streamExists {
-					idxStream.stream.Entries = append(idxStream.stream.Entries, e)
-				}
-			} else {
-				// Else: entry found => ignore duplicate
-				m.duplicates++
+	}
+	// Merge content (entries)
+	for _, e := range stream.Entries {
+		ekey := uniqueEntry(&e)
+		if _, entryExists := idxStream.entries[ekey]; !entryExists {
+			// Add entry to the existing stream, and mark it as existing in idxStream.entries
+			idxStream.entries[ekey] = nil
+			if streamExists {
+				idxStream.stream.Entries = append(idxStream.stream.Entries, e)
 			}
-		}
-		// Add or overwrite index
-		m.index[lkey] = idxStream
-		if !streamExists {
-			// Stream doesn't exist => append it
-			m.merged = append(m.merged, idxStream.stream)
 		} else {
-			m.merged[idxStream.index] = idxStream.stream
+			// Else: entry found => ignore duplicate
+			m.duplicates++
 		}
 	}
-	if totalEntries >= m.reqLimit {
+	// Add or overwrite index
+	m.index[lkey] = idxStream
+	if !streamExists {
+		// Stream doesn't exist => append it
+		m.merged = append(m.merged, idxStream.stream)
+	} else {
+		m.merged[idxStream.index] = idxStream.stream
+	}
+}
+
+func (m *StreamMerger) EndQuery(stats *model.QueryStats, entries int) {
+	m.numQueries++
+	if stats != nil {
+		m.stats = append(m.stats, *stats)
+	}
+	if entries >= m.reqLimit {
 		m.limitReached = true
 	}
-	m.totalEntries += totalEntries
-	return m.merged, nil
+	m.totalEntries += entries
 }
 
 func (m *StreamMerger) Get() *model.AggregatedQueryResponse {
@@ -116,6 +126,7 @@ func (m *StreamMerger) Get() *model.AggregatedQueryResponse {
 			TotalEntries: m.totalEntries,
 			Duplicates:   m.duplicates,
 			QueriesStats: m.stats,
+			Totals:       model.TotalCosts(m.stats),
 		},
 	}
 }
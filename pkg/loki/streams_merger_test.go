@@ -226,3 +226,31 @@ func TestStreamsMerge_SameStream(t *testing.T) {
 	assert.Equal(t, 0, result.Stats.Duplicates)
 	assert.Equal(t, 2, result.Stats.NumQueries)
 }
+
+func TestStreamMerger_Incremental(t *testing.T) {
+	now := time.Now()
+	s1 := model.Stream{
+		Labels:  map[string]string{"foo": "bar"},
+		Entries: []model.Entry{{Timestamp: now, Line: "{key: value1}"}},
+	}
+	s2 := model.Stream{
+		Labels:  map[string]string{"foo": "baz"},
+		Entries: []model.Entry{{Timestamp: now, Line: "{key: value2}"}},
+	}
+	var merger StreamingMerger = NewStreamMerger(2)
+
+	// Streams of parallel queries can be merged in any order
+	merger.AddStream(s1)
+	merger.AddStream(s1)
+	merger.AddStream(s2)
+	merger.EndQuery(&model.QueryStats{}, 1)
+	merger.EndQuery(nil, 2)
+	result := merger.Get()
+
+	assert.Len(t, result.Result, 2)
+	assert.Equal(t, 3, result.Stats.TotalEntries)
+	assert.Equal(t, 1, result.Stats.Duplicates)
+	assert.Equal(t, 2, result.Stats.NumQueries)
+	assert.True(t, result.Stats.LimitReached)
+	assert.Len(t, result.Stats.QueriesStats, 1)
+}
@@ -1,12 +1,16 @@
 package loki
 
 import (
+	"errors"
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/netobserv/network-observability-console-plugin/pkg/config"
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+	pmodel "github.com/prometheus/common/model"
 )
 
 const (
@@ -26,12 +30,18 @@ type TopologyInput struct {
 	PacketLoss     constants.PacketLoss
 	Aggregate      string
 	Groups         string
+	// Snapshot requests a single value per series, computed over the whole time range, instead of a time series
+	Snapshot bool
+	// Estimate allows using the Loki volume API, which is faster but only approximates traffic from ingested log volumes
+	Estimate bool
 }
 
 type TopologyQueryBuilder struct {
 	*FlowQueryBuilder
 	topology           *TopologyInput
 	aggregateKeyLabels map[string][]string
+	// window is the whole time range, set in snapshot mode
+	window string
 }
 
 func NewTopologyQuery(cfg *config.Loki, kl map[string][]string, in *TopologyInput) (*TopologyQueryBuilder, error) {
@@ -42,14 +52,44 @@ func NewTopologyQuery(cfg *config.Loki, kl map[string][]string, in *TopologyInpu
 		rt = "flowLog"
 	}
 
+	var window string
+	if in.Snapshot {
+		var err error
+		if window, err = snapshotWindow(in.Start, in.End); err != nil {
+			return nil, err
+		}
+	}
+
 	fqb := NewFlowQueryBuilder(cfg, in.Start, in.End, in.Top, rt, in.PacketLoss)
 	return &TopologyQueryBuilder{
 		FlowQueryBuilder:   fqb,
 		topology:           in,
 		aggregateKeyLabels: kl,
+		window:             window,
 	}, nil
 }
 
+// snapshotWindow returns the range duration, used as the range selector of instant queries
+func snapshotWindow(start, end string) (string, error) {
+	if start == "" {
+		return "", errors.New("snapshot mode requires a start time or a time range")
+	}
+	s, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("could not parse start time: %w", err)
+	}
+	e := time.Now().Unix()
+	if end != "" {
+		if e, err = strconv.ParseInt(end, 10, 64); err != nil {
+			return "", fmt.Errorf("could not parse end time: %w", err)
+		}
+	}
+	if e <= s {
+		return "", errors.New("snapshot mode requires the end time to be after the start time")
+	}
+	return pmodel.Duration(time.Duration(e-s) * time.Second).String(), nil
+}
+
 func GetLabelsAndFilter(kl map[string][]string, aggregate, groups string) ([]string, string) {
 	var fields []string
 	var filter string
@@ -127,6 +167,13 @@ func (q *TopologyQueryBuilder) Build() string {
 	dataField := getField(q.topology.DataField)
 	factor := getFactor(q.topology.DataField)
 	function, quantile := GetFunctionWithQuantile(q.topology.MetricFunction)
+	if q.topology.Snapshot && function == "rate" {
+		// snapshots are totals over the whole range, like the Prometheus increase
+		function = "sum_over_time"
+		if dataField == "" {
+			function = "count_over_time"
+		}
+	}
 
 	sumBy := function == "rate" || function == "count_over_time" || function == "sum_over_time"
 	// Build topology query like:
@@ -141,7 +188,13 @@ func (q *TopologyQueryBuilder) Build() string {
 	//			)
 	//		)
 	//		&<query params>&step=<step>
-	sb := q.createStringBuilderURL()
+	// In snapshot mode, this is an instant query where the interval is the whole time range
+	var sb *strings.Builder
+	if q.topology.Snapshot {
+		sb = q.createInstantStringBuilderURL()
+	} else {
+		sb = q.createStringBuilderURL()
+	}
 	if function == "min_over_time" {
 		sb.WriteString("bottomk")
 	} else {
@@ -165,10 +218,17 @@ func (q *TopologyQueryBuilder) Build() string {
 		sb.WriteRune(',')
 	}
 	q.appendLabels(sb)
+	q.appendMetadataFilters(sb)
 	q.appendLineFilters(sb)
 
 	if len(extraFilter) > 0 {
-		q.appendFilter(sb, extraFilter)
+		if q.config.IsStructuredMetadata(extraFilter) {
+			sb.WriteString("|")
+			sb.WriteString(extraFilter)
+			sb.WriteString(`!=""`)
+		} else {
+			q.appendFilter(sb, extraFilter)
+		}
 	}
 
 	if dataField == constants.MetricTypeDNSLatency {
@@ -179,16 +239,19 @@ func (q *TopologyQueryBuilder) Build() string {
 		q.appendRTTFilter(sb)
 	}
 
-	q.appendJSON(sb, true)
+	q.appendJSON(sb, q.needsJSON(append(slices.Clone(labels), dataField)))
 	if len(dataField) > 0 {
 		sb.WriteString("|unwrap ")
 		sb.WriteString(dataField)
 		sb.WriteString(`|__error__=""`)
 	}
 	sb.WriteRune('[')
-	if function != "rate" {
+	switch {
+	case q.topology.Snapshot:
+		sb.WriteString(q.window)
+	case function != "rate":
 		sb.WriteString(q.topology.Step)
-	} else {
+	default:
 		sb.WriteString(q.topology.RateInterval)
 	}
 	sb.WriteString("])")
@@ -205,9 +268,29 @@ func (q *TopologyQueryBuilder) Build() string {
 	}
 	sb.WriteRune(')')
 
-	q.appendQueryParams(sb)
-	sb.WriteString("&step=")
-	sb.WriteString(q.topology.Step)
+	if q.topology.Snapshot {
+		q.appendInstantQueryParams(sb)
+	} else {
+		q.appendQueryParams(sb)
+		sb.WriteString("&step=")
+		sb.WriteString(q.topology.Step)
+	}
 
 	return sb.String()
 }
+
+// needsJSON tells whether JSON must be parsed to get aggregations and the unwrapped field;
+// parsing is skipped only when structured metadata is involved and all of them are labels or structured metadata
+func (q *TopologyQueryBuilder) needsJSON(names []string) bool {
+	hasMetadata := false
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if !q.config.IsLabelOrMetadata(name) {
+			return true
+		}
+		hasMetadata = hasMetadata || q.config.IsStructuredMetadata(name)
+	}
+	return !hasMetadata
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -123,3 +124,104 @@ func TestBuildTopologyQuery_CustomLabelAggregate(t *testing.T) {
 		result,
 	)
 }
+
+func TestBuildTopologyQuery_Snapshot(t *testing.T) {
+	in := TopologyInput{
+		Start:          "1700000000",
+		End:            "1700000900",
+		Top:            "50",
+		RateInterval:   "2m",
+		Step:           "10s",
+		DataField:      "Bytes",
+		MetricFunction: constants.MetricFunctionSum,
+		RecordType:     constants.RecordTypeLog,
+		DataSource:     constants.DataSourceAuto,
+		Aggregate:      "namespace",
+		Snapshot:       true,
+	}
+	q, err := NewTopologyQuery(&lokiConfig, aggregateKeyLabels, &in)
+	require.NoError(t, err)
+	result := q.Build()
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/query?query="+
+			"topk(50,sum by(SrcK8S_Namespace,DstK8S_Namespace)(sum_over_time({app=\"netobserv-flowcollector\"}|json|unwrap Bytes|__error__=\"\"[15m])))&time=1700000900&limit=50",
+		result,
+	)
+
+	in.DataField = "Flows"
+	in.MetricFunction = constants.MetricFunctionCount
+	q, err = NewTopologyQuery(&lokiConfig, aggregateKeyLabels, &in)
+	require.NoError(t, err)
+	result = q.Build()
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/query?query="+
+			"topk(50,sum by(SrcK8S_Namespace,DstK8S_Namespace)(count_over_time({app=\"netobserv-flowcollector\"}|json[15m])))&time=1700000900&limit=50",
+		result,
+	)
+
+	// Rates are totals too
+	in.DataField = "Bytes"
+	in.MetricFunction = constants.MetricFunctionRate
+	q, err = NewTopologyQuery(&lokiConfig, aggregateKeyLabels, &in)
+	require.NoError(t, err)
+	result = q.Build()
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/query?query="+
+			"topk(50,sum by(SrcK8S_Namespace,DstK8S_Namespace)(sum_over_time({app=\"netobserv-flowcollector\"}|json|unwrap Bytes|__error__=\"\"[15m])))&time=1700000900&limit=50",
+		result,
+	)
+
+	in.DataField = "Flows"
+	q, err = NewTopologyQuery(&lokiConfig, aggregateKeyLabels, &in)
+	require.NoError(t, err)
+	result = q.Build()
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/query?query="+
+			"topk(50,sum by(SrcK8S_Namespace,DstK8S_Namespace)(count_over_time({app=\"netobserv-flowcollector\"}|json[15m])))&time=1700000900&limit=50",
+		result,
+	)
+
+	// Missing start
+	in.Start = ""
+	_, err = NewTopologyQuery(&lokiConfig, aggregateKeyLabels, &in)
+	require.Error(t, err)
+}
+
+func TestBuildTopologyQuery_StructuredMetadata(t *testing.T) {
+	cfg := config.Loki{
+		URL:                "http://loki",
+		Labels:             []string{"SrcK8S_Namespace", "DstK8S_Namespace"},
+		StructuredMetadata: []string{"SrcK8S_Name", "DstK8S_Name", "Bytes", "SrcPort"},
+	}
+	in := TopologyInput{
+		Start:          "1700000000",
+		End:            "1700000900",
+		Top:            "50",
+		RateInterval:   "2m",
+		Step:           "10s",
+		DataField:      "Bytes",
+		MetricFunction: constants.MetricFunctionRate,
+		RecordType:     constants.RecordTypeLog,
+		DataSource:     constants.DataSourceAuto,
+		Aggregate:      "SrcK8S_Name",
+	}
+	q, err := NewTopologyQuery(&cfg, aggregateKeyLabels, &in)
+	require.NoError(t, err)
+	require.NoError(t, q.Filters(filters.SingleQuery{filters.NewEqualMatch("SrcPort", `"80"`)}))
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/query_range?query="+
+			`topk(50,sum by(SrcK8S_Name)(rate({app="netobserv-flowcollector"}|SrcPort="80"|SrcK8S_Name!=""|unwrap Bytes|__error__=""[2m])))&start=1700000000&end=1700000900&limit=50&step=10s`,
+		q.Build(),
+	)
+
+	// Aggregating on a field that is not in structured metadata requires parsing JSON
+	in.Aggregate = "SrcK8S_OwnerName"
+	q, err = NewTopologyQuery(&cfg, aggregateKeyLabels, &in)
+	require.NoError(t, err)
+	assert.Contains(t, q.Build(), "|~`\"SrcK8S_OwnerName\"`|json|unwrap Bytes")
+}
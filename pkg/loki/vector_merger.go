@@ -0,0 +1,71 @@
+package loki
+
+import (
+	"fmt"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+)
+
+// VectorMerger stores a state to build unique Vector from multiple ones
+type VectorMerger struct {
+	Merger
+	index        map[string]int
+	merged       model.Vector
+	stats        []model.QueryStats
+	numQueries   int
+	reqLimit     int
+	limitReached bool
+}
+
+func NewVectorMerger(reqLimit int) *VectorMerger {
+	return &VectorMerger{
+		reqLimit: reqLimit,
+		index:    map[string]int{},
+		merged:   model.Vector{},
+		stats:    []model.QueryStats{},
+	}
+}
+
+func (m *VectorMerger) Add(from model.QueryResponseData) (model.ResultValue, error) {
+	vector, ok := from.Result.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type for VectorMerger: %T", from.Result)
+	}
+
+	m.numQueries++
+	if from.Stats != nil {
+		m.stats = append(m.stats, *from.Stats)
+	}
+	// Like for Matrix, the limit stands for the "topk" value, which relates to the number of samples
+	if len(vector) >= m.reqLimit {
+		m.limitReached = true
+	}
+	for _, sample := range vector {
+		skey := sample.Metric.String()
+		if idx, exists := m.index[skey]; exists {
+			// Sum values of the same series, as done for matrices at a same timestamp
+			m.merged[idx].Value += sample.Value
+			if sample.Timestamp.After(m.merged[idx].Timestamp) {
+				m.merged[idx].Timestamp = sample.Timestamp
+			}
+		} else {
+			m.index[skey] = len(m.merged)
+			sample.Metric = sample.Metric.Clone()
+			m.merged = append(m.merged, sample)
+		}
+	}
+	return m.merged, nil
+}
+
+func (m *VectorMerger) Get() *model.AggregatedQueryResponse {
+	return &model.AggregatedQueryResponse{
+		ResultType: model.ResultTypeVector,
+		Result:     m.merged,
+		Stats: model.AggregatedStats{
+			NumQueries:   m.numQueries,
+			LimitReached: m.limitReached,
+			QueriesStats: m.stats,
+			Totals:       model.TotalCosts(m.stats),
+		},
+	}
+}
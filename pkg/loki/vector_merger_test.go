@@ -0,0 +1,49 @@
+package loki
+
+import (
+	"testing"
+
+	pmodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+)
+
+func TestVectorMerge(t *testing.T) {
+	now := pmodel.Now()
+	merger := NewVectorMerger(3)
+	_, err := merger.Add(qrData(model.Vector{
+		{Metric: pmodel.Metric{"foo": "bar"}, Value: 42, Timestamp: now},
+	}))
+	require.NoError(t, err)
+
+	// Same metric is summed, different metric is appended
+	_, err = merger.Add(qrData(model.Vector{
+		{Metric: pmodel.Metric{"foo": "bar", "foo2": "bar2"}, Value: 42, Timestamp: now},
+		{Metric: pmodel.Metric{"foo": "bar"}, Value: 12, Timestamp: now},
+	}))
+	require.NoError(t, err)
+
+	res := merger.Get()
+	assert.Equal(t, model.ResultType(model.ResultTypeVector), res.ResultType)
+	assert.Equal(t, 2, res.Stats.NumQueries)
+	assert.False(t, res.Stats.LimitReached)
+	result := res.Result.(model.Vector)
+	require.Len(t, result, 2)
+	assert.Equal(t, pmodel.SampleValue(54), result[0].Value)
+	assert.Equal(t, pmodel.SampleValue(42), result[1].Value)
+
+	// Wrong type
+	_, err = merger.Add(qrData(model.Matrix{}))
+	require.Error(t, err)
+
+	// Limit reached
+	_, err = merger.Add(qrData(model.Vector{
+		{Metric: pmodel.Metric{"a": "1"}, Value: 1, Timestamp: now},
+		{Metric: pmodel.Metric{"a": "2"}, Value: 1, Timestamp: now},
+		{Metric: pmodel.Metric{"a": "3"}, Value: 1, Timestamp: now},
+	}))
+	require.NoError(t, err)
+	assert.True(t, merger.Get().Stats.LimitReached)
+}
@@ -0,0 +1,126 @@
+package loki
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+	pmodel "github.com/prometheus/common/model"
+)
+
+const (
+	volumePath        = "/loki/api/v1/index/volume?query="
+	volumeRangePath   = "/loki/api/v1/index/volume_range?query="
+	targetLabelsParam = "targetLabels"
+	aggregateByParam  = "aggregateBy"
+	stepParam         = "step"
+)
+
+// volumeFunctions are the metric functions of flows that can be estimated from volumes. Volumes are the bytes of
+// ingested flow logs per label set, over each step, or over the whole range for snapshots: they approximate counts
+// of flows, and their rates once divided by the step duration. Other metric types aren't related to volumes.
+var volumeFunctions = []constants.MetricFunction{constants.MetricFunctionCount, constants.MetricFunctionRate}
+
+// VolumeQueryBuilder builds queries for the Loki volume API, which returns an estimate
+// of the ingested bytes per label set, computed from the index only.
+// It can only be used when aggregations and filters are all based on Loki labels.
+type VolumeQueryBuilder struct {
+	*FlowQueryBuilder
+	topology *TopologyInput
+	labels   []string
+}
+
+func NewVolumeQuery(cfg *config.Loki, kl map[string][]string, in *TopologyInput, queryFilters filters.SingleQuery) (*VolumeQueryBuilder, error) {
+	if in.DataField != constants.MetricTypeFlows || !slices.Contains(volumeFunctions, in.MetricFunction) {
+		return nil, fmt.Errorf("%s of %s can't be estimated from log volumes", in.MetricFunction, in.DataField)
+	}
+	labels, _ := GetLabelsAndFilter(kl, in.Aggregate, in.Groups)
+	for _, label := range labels {
+		if !cfg.IsLabel(label) {
+			return nil, fmt.Errorf("aggregation on %s is not based on a Loki label", label)
+		}
+	}
+	// Record type, packet loss and snapshot window are handled the same way as for topology queries;
+	// record type and packet loss must also end up as labels
+	tqb, err := NewTopologyQuery(cfg, kl, in)
+	if err != nil {
+		return nil, err
+	}
+	fqb := tqb.FlowQueryBuilder
+	if err := fqb.Filters(queryFilters); err != nil {
+		return nil, err
+	}
+	if len(fqb.metadataFilters) > 0 || len(fqb.lineFilters) > 0 || len(fqb.jsonFilters) > 0 {
+		return nil, fmt.Errorf("filters are not all based on Loki labels")
+	}
+
+	return &VolumeQueryBuilder{
+		FlowQueryBuilder: fqb,
+		topology:         in,
+		labels:           labels,
+	}, nil
+}
+
+func (q *VolumeQueryBuilder) Build() string {
+	top := q.topology.Top
+	if top == "" {
+		top = topologyDefaultLimit
+	}
+
+	// Build volume query like:
+	// /loki/api/v1/index/volume_range?query={<label filters>}
+	//		&start=<start>&end=<end>&limit=<k>&targetLabels=<aggregations>&aggregateBy=series&step=<step>
+	// In snapshot mode, /loki/api/v1/index/volume is used to get a single value for the whole range
+	sb := strings.Builder{}
+	sb.WriteString(strings.TrimRight(q.config.URL, "/"))
+	if q.topology.Snapshot {
+		sb.WriteString(volumePath)
+	} else {
+		sb.WriteString(volumeRangePath)
+	}
+	q.appendLabels(&sb)
+	if len(q.startTime) > 0 {
+		appendQueryParam(&sb, startParam, q.startTime)
+	}
+	if len(q.endTime) > 0 {
+		appendQueryParam(&sb, endParam, q.endTime)
+	}
+	appendQueryParam(&sb, limitParam, top)
+	appendQueryParam(&sb, targetLabelsParam, strings.Join(q.labels, ","))
+	appendQueryParam(&sb, aggregateByParam, "series")
+	if !q.topology.Snapshot {
+		appendQueryParam(&sb, stepParam, q.topology.Step)
+	}
+	return sb.String()
+}
+
+// VolumeApproximations describe how volumes approximate the requested metric, to be reported with results
+func VolumeApproximations(in *TopologyInput) []string {
+	approximations := []string{"flows are approximated by the bytes of ingested flow logs"}
+	if in.MetricFunction == constants.MetricFunctionRate {
+		if in.Snapshot {
+			approximations = append(approximations, "rates are totals over the time range")
+		} else {
+			approximations = append(approximations, "rates are averaged over each step")
+		}
+	}
+	return approximations
+}
+
+// VolumesPerSecond turns volumes over each step into per-second rates, for rate estimates of range queries
+func VolumesPerSecond(in *TopologyInput, step time.Duration, result model.ResultValue) {
+	matrix, ok := result.(model.Matrix)
+	if !ok || in.Snapshot || in.MetricFunction != constants.MetricFunctionRate || step <= 0 {
+		return
+	}
+	for i := range matrix {
+		for j := range matrix[i].Values {
+			matrix[i].Values[j].Value /= pmodel.SampleValue(step.Seconds())
+		}
+	}
+}
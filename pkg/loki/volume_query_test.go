@@ -0,0 +1,100 @@
+package loki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+	pmodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func volumeInput(aggregate string) TopologyInput {
+	return TopologyInput{
+		Start:          "1000",
+		End:            "4600",
+		Top:            "50",
+		RateInterval:   "2m",
+		Step:           "10s",
+		DataField:      constants.MetricTypeFlows,
+		MetricFunction: constants.MetricFunctionRate,
+		RecordType:     constants.RecordTypeLog,
+		DataSource:     constants.DataSourceAuto,
+		PacketLoss:     constants.PacketLossAll,
+		Aggregate:      aggregate,
+	}
+}
+
+func TestBuildVolumeQuery_Range(t *testing.T) {
+	in := volumeInput("namespace")
+	q, err := NewVolumeQuery(&lokiConfig, aggregateKeyLabels, &in, filters.SingleQuery{
+		filters.NewEqualMatch("SrcK8S_Namespace", `"ns1"`),
+	})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/index/volume_range?query="+
+			"{app=\"netobserv-flowcollector\",SrcK8S_Namespace=\"ns1\"}&start=1000&end=4600&limit=50&targetLabels=SrcK8S_Namespace,DstK8S_Namespace&aggregateBy=series&step=10s",
+		q.Build(),
+	)
+}
+
+func TestBuildVolumeQuery_Snapshot(t *testing.T) {
+	in := volumeInput("namespace")
+	in.Snapshot = true
+	q, err := NewVolumeQuery(&lokiConfig, aggregateKeyLabels, &in, nil)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"http://loki/loki/api/v1/index/volume?query="+
+			"{app=\"netobserv-flowcollector\"}&start=1000&end=4600&limit=50&targetLabels=SrcK8S_Namespace,DstK8S_Namespace&aggregateBy=series",
+		q.Build(),
+	)
+}
+
+func TestBuildVolumeQuery_NotEligible(t *testing.T) {
+	// Aggregation on non-label fields
+	in := volumeInput("resource")
+	_, err := NewVolumeQuery(&lokiConfig, aggregateKeyLabels, &in, nil)
+	require.ErrorContains(t, err, "not based on a Loki label")
+
+	// Filter on non-label field
+	in = volumeInput("namespace")
+	_, err = NewVolumeQuery(&lokiConfig, aggregateKeyLabels, &in, filters.SingleQuery{
+		filters.NewEqualMatch("SrcPort", "8080"),
+	})
+	require.ErrorContains(t, err, "filters are not all based on Loki labels")
+
+	// Metrics without a mapping from volumes
+	in = volumeInput("namespace")
+	in.DataField = constants.MetricTypeBytes
+	_, err = NewVolumeQuery(&lokiConfig, aggregateKeyLabels, &in, nil)
+	require.ErrorContains(t, err, "rate of Bytes can't be estimated from log volumes")
+	in = volumeInput("namespace")
+	in.MetricFunction = constants.MetricFunctionAvg
+	_, err = NewVolumeQuery(&lokiConfig, aggregateKeyLabels, &in, nil)
+	require.ErrorContains(t, err, "avg of Flows can't be estimated from log volumes")
+
+	// Packet loss requires line filters
+	in = volumeInput("namespace")
+	in.PacketLoss = constants.PacketLossDropped
+	_, err = NewVolumeQuery(&lokiConfig, aggregateKeyLabels, &in, nil)
+	require.ErrorContains(t, err, "filters are not all based on Loki labels")
+}
+
+func TestVolumesPerSecond(t *testing.T) {
+	in := volumeInput("namespace")
+	matrix := model.Matrix{{Values: []pmodel.SamplePair{{Timestamp: 1000, Value: 300}, {Timestamp: 2000, Value: 30}}}}
+	VolumesPerSecond(&in, 30*time.Second, matrix)
+	assert.Equal(t, []pmodel.SamplePair{{Timestamp: 1000, Value: 10}, {Timestamp: 2000, Value: 1}}, matrix[0].Values)
+	assert.Equal(t, []string{"flows are approximated by the bytes of ingested flow logs", "rates are averaged over each step"}, VolumeApproximations(&in))
+
+	// counts are volumes per step
+	in.MetricFunction = constants.MetricFunctionCount
+	VolumesPerSecond(&in, 30*time.Second, matrix)
+	assert.Equal(t, []pmodel.SamplePair{{Timestamp: 1000, Value: 10}, {Timestamp: 2000, Value: 1}}, matrix[0].Values)
+	assert.Equal(t, []string{"flows are approximated by the bytes of ingested flow logs"}, VolumeApproximations(&in))
+}
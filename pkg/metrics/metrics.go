@@ -4,12 +4,21 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 const prefix = "netobserv"
 
+// Results of transport pool lookups
+const (
+	TransportHit       = "hit"
+	TransportCreated   = "created"
+	TransportRefreshed = "refreshed"
+)
+
 var (
 	httpCallsDurationHisto = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    prefix + "_http_api_calls_duration",
@@ -26,6 +35,43 @@ var (
 		Help:    "Time measurements of calls to Prometheus",
 		Buckets: prometheus.DefBuckets,
 	}, []string{"code"})
+	queryExecTimeHisto = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prefix + "_query_exec_time",
+		Help:    "Execution time of queries, as reported by the datasource",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"datasource"})
+	queryQueueTimeHisto = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prefix + "_query_queue_time",
+		Help:    "Time spent by queries in the datasource queue",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"datasource"})
+	queryBytesProcessedHisto = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prefix + "_query_bytes_processed",
+		Help:    "Bytes processed by the datasource per query",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 12),
+	}, []string{"datasource"})
+	queryLinesProcessedHisto = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prefix + "_query_lines_processed",
+		Help:    "Lines processed by the datasource per query",
+		Buckets: prometheus.ExponentialBuckets(100, 4, 12),
+	}, []string{"datasource"})
+	queryCacheHitsHisto = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prefix + "_query_cache_hits",
+		Help:    "Cache hits in the datasource per query",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"datasource"})
+	transportLookupsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prefix + "_transport_pool_lookups_total",
+		Help: "Lookups of shared HTTP transports, by result: hit, created or refreshed after TLS files changed",
+	}, []string{"result"})
+	transportPoolSizeGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "_transport_pool_size",
+		Help: "Number of shared HTTP transports",
+	})
+	transportConnectionsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "_transport_open_connections",
+		Help: "Number of connections opened by shared HTTP transports",
+	})
 )
 
 func ObserveHTTPCall(handler string, code int, startTime time.Time) {
@@ -39,3 +85,29 @@ func ObserveLokiCall(code int, startTime time.Time) {
 func ObservePromCall(code int, startTime time.Time) {
 	promCallsDurationHisto.WithLabelValues(strconv.Itoa(code)).Observe(time.Since(startTime).Seconds())
 }
+
+// ObserveQueryStats observes the costs reported for a query. Prometheus only reports the execution time: other costs
+// are left out rather than observed as zeros.
+func ObserveQueryStats(stats *model.QueryStats) {
+	ds := string(stats.DataSource)
+	queryExecTimeHisto.WithLabelValues(ds).Observe(stats.ExecTime)
+	if stats.DataSource == constants.DataSourceProm {
+		return
+	}
+	queryQueueTimeHisto.WithLabelValues(ds).Observe(stats.QueueTime)
+	queryBytesProcessedHisto.WithLabelValues(ds).Observe(float64(stats.BytesProcessed))
+	queryLinesProcessedHisto.WithLabelValues(ds).Observe(float64(stats.LinesProcessed))
+	queryCacheHitsHisto.WithLabelValues(ds).Observe(float64(stats.CacheHits))
+}
+
+func ObserveTransportLookup(result string) {
+	transportLookupsCounter.WithLabelValues(result).Inc()
+}
+
+func SetTransportPoolSize(size int) {
+	transportPoolSizeGauge.Set(float64(size))
+}
+
+func AddTransportConnections(delta int) {
+	transportConnectionsGauge.Add(float64(delta))
+}
@@ -0,0 +1,89 @@
+package model
+
+import "time"
+
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+
+	AlertStateFiring    = "firing"
+	AlertStateRecording = "recording"
+)
+
+// TopologyAlerts represents the active alerts mapped onto topology nodes and edges
+type TopologyAlerts struct {
+	Global        []Alert      `json:"global"`
+	Nodes         []NodeAlerts `json:"nodes"`
+	Edges         []EdgeAlerts `json:"edges"`
+	UnixTimestamp int64        `json:"unixTimestamp"`
+}
+
+// Alert is a firing alert, or a recording rule metric above its thresholds
+type Alert struct {
+	Name     string            `json:"name"`
+	Severity string            `json:"severity"`
+	State    string            `json:"state"`
+	Value    float64           `json:"value"`
+	Summary  string            `json:"summary,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	ActiveAt *time.Time        `json:"activeAt,omitempty"`
+}
+
+// NodeAlerts holds the alerts of a topology node, identified by its scope and peer fields (without Src/Dst prefix)
+type NodeAlerts struct {
+	Scope    string            `json:"scope"`
+	Peer     map[string]string `json:"peer"`
+	Severity string            `json:"severity"`
+	Alerts   []Alert           `json:"alerts"`
+}
+
+// EdgeAlerts holds the alerts of a topology edge, identified by its scope and source / target peer fields
+type EdgeAlerts struct {
+	Scope    string            `json:"scope"`
+	Source   map[string]string `json:"source"`
+	Target   map[string]string `json:"target"`
+	Severity string            `json:"severity"`
+	Alerts   []Alert           `json:"alerts"`
+}
+
+// SeverityRank orders severities, higher being more severe
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// Silence follows the Alertmanager v2 API silence model
+type Silence struct {
+	ID        string           `json:"id,omitempty"`
+	Matchers  []SilenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+	Status    *SilenceStatus   `json:"status,omitempty"`
+	UpdatedAt *time.Time       `json:"updatedAt,omitempty"`
+}
+
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual *bool  `json:"isEqual,omitempty"`
+}
+
+type SilenceStatus struct {
+	State string `json:"state"`
+}
+
+// IsEqualityMatch tells whether the matcher selects a single value ("=" operator)
+func (m *SilenceMatcher) IsEqualityMatch() bool {
+	return !m.IsRegex && (m.IsEqual == nil || *m.IsEqual)
+}
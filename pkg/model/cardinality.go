@@ -0,0 +1,53 @@
+package model
+
+// CardinalityReport describes how configured labels affect Loki streams and Prometheus series
+type CardinalityReport struct {
+	Start      int64              `json:"start"`
+	End        int64              `json:"end"`
+	Threshold  int                `json:"threshold"`
+	Loki       *LokiCardinality   `json:"loki,omitempty"`
+	Prometheus *PromCardinality   `json:"prometheus,omitempty"`
+	Scopes     []ScopeCardinality `json:"scopes"`
+	Errors     []string           `json:"errors,omitempty"`
+}
+
+// LabelCardinality is the number of distinct values of a label
+type LabelCardinality struct {
+	Name   string `json:"name"`
+	Values int    `json:"values"`
+}
+
+// LokiCardinality describes netobserv streams, as defined by Loki.Labels
+type LokiCardinality struct {
+	Streams int                `json:"streams"`
+	Labels  []LabelCardinality `json:"labels"`
+	// MissingLabels are configured as Loki labels but not found in any stream
+	MissingLabels []string `json:"missingLabels,omitempty"`
+}
+
+// PromCardinality describes the series of enabled netobserv metrics
+type PromCardinality struct {
+	HeadSeries int                 `json:"headSeries"`
+	Metrics    []MetricCardinality `json:"metrics"`
+	// TopLabels are the labels with most values in the whole TSDB, not only netobserv metrics
+	TopLabels []LabelCardinality `json:"topLabels"`
+}
+
+// MetricCardinality is the number of series of a metric, and the number of values per label
+type MetricCardinality struct {
+	Name   string             `json:"name"`
+	Series int                `json:"series"`
+	Labels []LabelCardinality `json:"labels"`
+}
+
+// ScopeCardinality is the cardinality of a label used in a Frontend scope
+type ScopeCardinality struct {
+	Scope           string   `json:"scope"`
+	Label           string   `json:"label"`
+	LokiLabel       bool     `json:"lokiLabel"`
+	LokiValues      int      `json:"lokiValues"`
+	PromMetrics     []string `json:"promMetrics,omitempty"`
+	PromValues      int      `json:"promValues"`
+	Unavailable     bool     `json:"unavailable,omitempty"`
+	HighCardinality bool     `json:"highCardinality,omitempty"`
+}
@@ -0,0 +1,24 @@
+package model
+
+// Envelope is the body of JSON responses of the v2 API
+type Envelope struct {
+	Data     any             `json:"data,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Stats    any             `json:"stats,omitempty"`
+	Errors   []EnvelopeError `json:"errors,omitempty"`
+}
+
+// EnvelopeError is an error of a v2 API response, identified by its code
+type EnvelopeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details are the fields of structured errors, such as missing labels or guardrail hints
+	Details any `json:"details,omitempty"`
+}
+
+// QueryResult is the data of v2 API responses to flows and metrics queries, whose stats are in the envelope
+type QueryResult struct {
+	ResultType    ResultType  `json:"resultType"`
+	Result        ResultValue `json:"result"`
+	UnixTimestamp int64       `json:"unixTimestamp"`
+}
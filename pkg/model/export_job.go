@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type ExportJobState string
+
+const (
+	ExportJobRunning  ExportJobState = "running"
+	ExportJobDone     ExportJobState = "done"
+	ExportJobFailed   ExportJobState = "failed"
+	ExportJobCanceled ExportJobState = "canceled"
+)
+
+// ExportJob describes an asynchronous export and its progress
+type ExportJob struct {
+	ID    string         `json:"id"`
+	State ExportJobState `json:"state"`
+	// Rows is the number of flows fetched so far, up to MaxRows
+	Rows    int `json:"rows"`
+	MaxRows int `json:"maxRows"`
+	// Bytes is the size of the spooled result
+	Bytes int64 `json:"bytes"`
+	// Pages is the number of pages of flows fetched from Loki
+	Pages       int        `json:"pages"`
+	Truncated   bool       `json:"truncated"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// ExpiresAt is when the result of a completed job is deleted
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
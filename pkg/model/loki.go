@@ -40,8 +40,33 @@ type AggregatedStats struct {
 	TotalEntries int                    `json:"totalEntries"`
 	Duplicates   int                    `json:"duplicates"`
 	LimitReached bool                   `json:"limitReached"`
-	QueriesStats []interface{}          `json:"queriesStats"`
+	QueriesStats []QueryStats           `json:"queriesStats"`
 	DataSources  []constants.DataSource `json:"dataSources"`
+	// Totals sums the costs of all queries
+	Totals *QueryCosts `json:"totals,omitempty"`
+	// Estimate is set when results come from the Loki volume API, which approximates traffic from ingested log volumes
+	Estimate bool `json:"estimate,omitempty"`
+	// Approximations describe how estimated results approximate the requested metric
+	Approximations []string `json:"approximations,omitempty"`
+	// Degradation is set when queries had to be made cheaper to get results
+	Degradation *Degradation `json:"degradation,omitempty"`
+}
+
+// Degradation reasons
+const (
+	DegradationSeriesLimit = "seriesLimit"
+	DegradationTimeout     = "timeout"
+)
+
+// Degradation describes how queries were degraded after being rejected by Loki for its limits
+type Degradation struct {
+	Reason string `json:"reason"`
+	// Applied lists the degradations, in the order they were applied; they are cumulative
+	Applied []string `json:"applied"`
+	Step    string   `json:"step,omitempty"`
+	Top     string   `json:"top,omitempty"`
+	Shards  int      `json:"shards,omitempty"`
+	Start   string   `json:"start,omitempty"`
 }
 
 // ResultType holds the type of the result
@@ -64,7 +89,7 @@ type ResultValue interface {
 type QueryResponseData struct {
 	ResultType ResultType  `json:"resultType"`
 	Result     ResultValue `json:"result"`
-	Stats      interface{} `json:"-"`
+	Stats      *QueryStats `json:"-"`
 }
 
 // Type implements the promql.Value interface
@@ -119,11 +144,11 @@ func (q *AggregatedQueryResponse) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func unmarshalQueryResponseData(data []byte) (ResultType, ResultValue, interface{}, error) {
+func unmarshalQueryResponseData(data []byte) (ResultType, ResultValue, *QueryStats, error) {
 	unmarshal := struct {
 		Type   ResultType      `json:"resultType"`
 		Result json.RawMessage `json:"result"`
-		Stats  interface{}     `json:"stats"`
+		Stats  json.RawMessage `json:"stats"`
 	}{}
 
 	err := json.Unmarshal(data, &unmarshal)
@@ -131,42 +156,55 @@ func unmarshalQueryResponseData(data []byte) (ResultType, ResultValue, interface
 		return "", nil, nil, err
 	}
 
-	var value ResultValue
+	value, err := unmarshalResult(unmarshal.Type, unmarshal.Result)
+	if err != nil {
+		return "", nil, nil, err
+	}
 
-	// unmarshal results
-	switch unmarshal.Type {
+	// Stats are informative only: do not fail the query if they can't be parsed
+	stats, _ := parseLokiStats(unmarshal.Stats)
+
+	return unmarshal.Type, value, stats, nil
+}
+
+func unmarshalResult(t ResultType, raw []byte) (ResultValue, error) {
+	var value ResultValue
+	var err error
+	switch t {
 	case ResultTypeStream:
 		var s Streams
-		err = json.Unmarshal(unmarshal.Result, &s)
-		for _, mapping := range flowLineMappings {
-			for i := range s {
-				for ii := range s[i].Entries {
-					s[i].Entries[ii].Line = mapping(s[i].Entries[ii].Line)
-				}
-			}
+		err = json.Unmarshal(raw, &s)
+		for i := range s {
+			mapFlowLines(&s[i])
 		}
 		value = s
 	case ResultTypeMatrix:
 		var m Matrix
-		err = json.Unmarshal(unmarshal.Result, &m)
+		err = json.Unmarshal(raw, &m)
 		value = m
 	case ResultTypeVector:
 		var v Vector
-		err = json.Unmarshal(unmarshal.Result, &v)
+		err = json.Unmarshal(raw, &v)
 		value = v
 	case ResultTypeScalar:
 		var v Scalar
-		err = json.Unmarshal(unmarshal.Result, &v)
+		err = json.Unmarshal(raw, &v)
 		value = v
 	default:
-		return "", nil, nil, fmt.Errorf("unknown type: %s", unmarshal.Type)
+		return nil, fmt.Errorf("unknown type: %s", t)
 	}
-
 	if err != nil {
-		return "", nil, nil, err
+		return nil, err
 	}
+	return value, nil
+}
 
-	return unmarshal.Type, value, unmarshal.Stats, nil
+func mapFlowLines(s *Stream) {
+	for _, mapping := range flowLineMappings {
+		for i := range s.Entries {
+			s.Entries[i].Line = mapping(s.Entries[i].Line)
+		}
+	}
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -220,8 +258,49 @@ type Vector []model.Sample
 // Matrix is a slice of SampleStreams
 type Matrix []model.SampleStream
 
+// AddLabel sets a label on every stream or series of the result
+func (d *QueryResponseData) AddLabel(name, value string) {
+	switch r := d.Result.(type) {
+	case Streams:
+		for i := range r {
+			if r[i].Labels == nil {
+				r[i].Labels = map[string]string{}
+			}
+			r[i].Labels[name] = value
+		}
+	case Matrix:
+		for i := range r {
+			if r[i].Metric == nil {
+				r[i].Metric = model.Metric{}
+			}
+			r[i].Metric[model.LabelName(name)] = model.LabelValue(value)
+		}
+	case Vector:
+		for i := range r {
+			if r[i].Metric == nil {
+				r[i].Metric = model.Metric{}
+			}
+			r[i].Metric[model.LabelName(name)] = model.LabelValue(value)
+		}
+	}
+}
+
 // LabelValuesResponse represents the http json response to a query for label values
 type LabelValuesResponse struct {
 	Status string   `json:"status"`
 	Data   []string `json:"data"`
 }
+
+// SeriesResponse represents the http json response to a query for series, ie. the label sets of streams
+type SeriesResponse struct {
+	Status string              `json:"status"`
+	Data   []map[string]string `json:"data"`
+}
+
+// IndexStats represents the http json response of the Loki index stats API
+type IndexStats struct {
+	Streams int64 `json:"streams"`
+	Chunks  int64 `json:"chunks"`
+	Entries int64 `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"errors"
+	"io"
+
+	json "github.com/json-iterator/go"
+)
+
+const decoderBufferSize = 32 * 1024
+
+// DecodeQueryResponse decodes a Loki query response from a reader, without buffering the whole body.
+// Streams are decoded one at a time, with flow line mappings applied, and passed to onStream rather than kept in the returned
+// response, whose result is then empty. Other result types are decoded as a whole.
+func DecodeQueryResponse(r io.Reader, onStream func(Stream) error) (*QueryResponse, error) {
+	iter := json.Parse(json.ConfigDefault, r, decoderBufferSize)
+	qr := QueryResponse{}
+	for field := iter.ReadObject(); field != "" && iter.Error == nil; field = iter.ReadObject() {
+		switch field {
+		case "status":
+			qr.Status = iter.ReadString()
+		case "data":
+			if err := decodeQueryResponseData(iter, &qr.Data, onStream); err != nil {
+				return nil, err
+			}
+		default:
+			iter.Skip()
+		}
+	}
+	if iter.Error != nil && !errors.Is(iter.Error, io.EOF) {
+		return nil, iter.Error
+	}
+	return &qr, nil
+}
+
+func decodeQueryResponseData(iter *json.Iterator, d *QueryResponseData, onStream func(Stream) error) error {
+	var raw, rawStats []byte
+	for field := iter.ReadObject(); field != ""; field = iter.ReadObject() {
+		switch field {
+		case "resultType":
+			d.ResultType = ResultType(iter.ReadString())
+		case "result":
+			if d.ResultType == ResultTypeStream {
+				if err := decodeStreams(iter, onStream); err != nil {
+					return err
+				}
+				d.Result = Streams{}
+			} else {
+				// Loki writes the result type first: buffer the result otherwise, until the type is known
+				raw = iter.SkipAndReturnBytes()
+			}
+		case "stats":
+			rawStats = iter.SkipAndReturnBytes()
+		default:
+			iter.Skip()
+		}
+		if iter.Error != nil {
+			return iter.Error
+		}
+	}
+	if iter.Error != nil {
+		return iter.Error
+	}
+	if raw != nil {
+		value, err := unmarshalResult(d.ResultType, raw)
+		if err != nil {
+			return err
+		}
+		if streams, ok := value.(Streams); ok {
+			for _, s := range streams {
+				if err := onStream(s); err != nil {
+					return err
+				}
+			}
+			value = Streams{}
+		}
+		d.Result = value
+	}
+	// Stats are informative only: do not fail the query if they can't be parsed
+	d.Stats, _ = parseLokiStats(rawStats)
+	return nil
+}
+
+func decodeStreams(iter *json.Iterator, onStream func(Stream) error) error {
+	for iter.ReadArray() {
+		var s Stream
+		iter.ReadVal(&s)
+		if iter.Error != nil {
+			return iter.Error
+		}
+		mapFlowLines(&s)
+		if err := onStream(s); err != nil {
+			return err
+		}
+	}
+	return iter.Error
+}
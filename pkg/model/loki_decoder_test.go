@@ -0,0 +1,78 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeQueryResponse_Streams(t *testing.T) {
+	js := `{"status":"success","data":{"resultType":"streams","result":[
+		{"stream":{"app":"a"},"values":[["1000","line1"],["2000","line2"]]},
+		{"stream":{"app":"b"},"values":[["3000","line3"]]}
+	],"stats":{"summary":{"totalBytesProcessed":100,"totalLinesProcessed":3}}}}`
+	var streams []Stream
+	qr, err := DecodeQueryResponse(strings.NewReader(js), func(s Stream) error {
+		streams = append(streams, s)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", qr.Status)
+	assert.Equal(t, ResultType(ResultTypeStream), qr.Data.ResultType)
+	assert.Empty(t, qr.Data.Result)
+	require.NotNil(t, qr.Data.Stats)
+	assert.Equal(t, int64(100), qr.Data.Stats.BytesProcessed)
+
+	require.Len(t, streams, 2)
+	assert.Equal(t, map[string]string{"app": "a"}, streams[0].Labels)
+	assert.Equal(t, []Entry{{Timestamp: time.Unix(0, 1000), Line: "line1"}, {Timestamp: time.Unix(0, 2000), Line: "line2"}}, streams[0].Entries)
+	assert.Equal(t, "line3", streams[1].Entries[0].Line)
+}
+
+func TestDecodeQueryResponse_ResultBeforeType(t *testing.T) {
+	js := `{"data":{"result":[{"stream":{"app":"a"},"values":[["1000","line1"]]}],"resultType":"streams"},"status":"success"}`
+	var streams []Stream
+	qr, err := DecodeQueryResponse(strings.NewReader(js), func(s Stream) error {
+		streams = append(streams, s)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", qr.Status)
+	assert.Empty(t, qr.Data.Result)
+	require.Len(t, streams, 1)
+	assert.Equal(t, "line1", streams[0].Entries[0].Line)
+}
+
+func TestDecodeQueryResponse_Matrix(t *testing.T) {
+	js := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"app":"a"},"values":[[1000,"1"]]}]}}`
+	qr, err := DecodeQueryResponse(strings.NewReader(js), func(Stream) error {
+		return errors.New("unexpected stream")
+	})
+	require.NoError(t, err)
+	require.IsType(t, Matrix{}, qr.Data.Result)
+	assert.Len(t, qr.Data.Result, 1)
+	assert.Nil(t, qr.Data.Stats)
+}
+
+func TestDecodeQueryResponse_Errors(t *testing.T) {
+	js := `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"a"},"values":[["1000","line1"]]},{"stream":{"app":"b"},"values":[["2000","line2"]]}]}}`
+	count := 0
+	stop := errors.New("stop")
+	_, err := DecodeQueryResponse(strings.NewReader(js), func(Stream) error {
+		count++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, count)
+
+	// Truncated
+	_, err = DecodeQueryResponse(strings.NewReader(js[:80]), func(Stream) error { return nil })
+	assert.Error(t, err)
+
+	_, err = DecodeQueryResponse(strings.NewReader(`{"data":{"resultType":"foo","result":[]}}`), func(Stream) error { return nil })
+	assert.EqualError(t, err, "unknown type: foo")
+}
@@ -167,21 +167,39 @@ func TestAggregatedQueryResponseMatrixUnmarshal(t *testing.T) {
 }
 
 func TestReencodeStats(t *testing.T) {
-	js := `{"status":"","data":{"resultType":"streams","result":[],"stats":{"ingester":{"foo":"bar"}}}}`
+	js := `{"status":"","data":{"resultType":"streams","result":[],"stats":{` +
+		`"summary":{"totalBytesProcessed":2048,"totalLinesProcessed":10,"execTime":0.5,"queueTime":0.1,"subqueries":2,"splits":3,"shards":4},` +
+		`"ingester":{"foo":"bar"},` +
+		`"cache":{"chunk":{"entriesFound":1,"entriesRequested":2},"result":{"entriesFound":3,"entriesRequested":4}}}}}`
 	var qr QueryResponse
 	err := json.Unmarshal([]byte(js), &qr)
 	require.NoError(t, err)
+	require.NotNil(t, qr.Data.Stats)
+	qr.Data.Stats.QueryHash = "abc"
 	agg := AggregatedQueryResponse{
 		ResultType: qr.Data.ResultType,
 		Result:     qr.Data.Result,
 		Stats: AggregatedStats{
 			NumQueries:   1,
 			LimitReached: false,
-			QueriesStats: []interface{}{qr.Data.Stats},
+			QueriesStats: []QueryStats{*qr.Data.Stats},
 			DataSources:  []constants.DataSource{constants.DataSourceAuto},
+			Totals:       TotalCosts([]QueryStats{*qr.Data.Stats, *qr.Data.Stats}),
 		},
 	}
 	reencoded, err := json.Marshal(agg)
 	require.NoError(t, err)
-	assert.Equal(t, `{"resultType":"streams","result":[],"stats":{"numQueries":1,"totalEntries":0,"duplicates":0,"limitReached":false,"queriesStats":[{"ingester":{"foo":"bar"}}],"dataSources":["auto"]},"unixTimestamp":0}`, string(reencoded))
+	assert.Equal(t, `{"resultType":"streams","result":[],"stats":{"numQueries":1,"totalEntries":0,"duplicates":0,"limitReached":false,`+
+		`"queriesStats":[{"dataSource":"loki","queryHash":"abc","bytesProcessed":2048,"linesProcessed":10,"execTime":0.5,"queueTime":0.1,"cacheHits":4,"cacheRequests":6,"subqueries":2,"splits":3,"shards":4}],`+
+		`"dataSources":["auto"],`+
+		`"totals":{"bytesProcessed":4096,"linesProcessed":20,"execTime":1,"queueTime":0.2,"cacheHits":8,"cacheRequests":12,"subqueries":4,"splits":6,"shards":8}},"unixTimestamp":0}`, string(reencoded))
+}
+
+func TestStatsNotFailingQuery(t *testing.T) {
+	js := `{"status":"","data":{"resultType":"streams","result":[],"stats":{"summary":"unexpected"}}}`
+	var qr QueryResponse
+	err := json.Unmarshal([]byte(js), &qr)
+	require.NoError(t, err)
+	assert.Nil(t, qr.Data.Stats)
+	assert.Equal(t, "dcb27518fed9d577", HashQuery("foo"))
 }
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	json "github.com/json-iterator/go"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
+)
+
+// QueryCosts are the resources consumed by one or more queries; times are in seconds
+type QueryCosts struct {
+	BytesProcessed int64   `json:"bytesProcessed"`
+	LinesProcessed int64   `json:"linesProcessed"`
+	ExecTime       float64 `json:"execTime"`
+	QueueTime      float64 `json:"queueTime"`
+	CacheHits      int64   `json:"cacheHits"`
+	CacheRequests  int64   `json:"cacheRequests"`
+	Subqueries     int64   `json:"subqueries"`
+	Splits         int64   `json:"splits"`
+	Shards         int64   `json:"shards"`
+}
+
+// QueryStats are the statistics of a single query executed on Loki or Prometheus
+type QueryStats struct {
+	DataSource constants.DataSource `json:"dataSource"`
+	QueryHash  string               `json:"queryHash"`
+	QueryCosts
+}
+
+// lokiStats is the subset of stats returned by Loki that we care about
+type lokiStats struct {
+	Summary struct {
+		TotalBytesProcessed int64   `json:"totalBytesProcessed"`
+		TotalLinesProcessed int64   `json:"totalLinesProcessed"`
+		ExecTime            float64 `json:"execTime"`
+		QueueTime           float64 `json:"queueTime"`
+		Subqueries          int64   `json:"subqueries"`
+		Splits              int64   `json:"splits"`
+		Shards              int64   `json:"shards"`
+	} `json:"summary"`
+	// Cache stats per cache type: chunk, index, result, etc.
+	Cache map[string]struct {
+		EntriesFound     int64 `json:"entriesFound"`
+		EntriesRequested int64 `json:"entriesRequested"`
+	} `json:"cache"`
+}
+
+// HashQuery returns a short hash identifying a query, to correlate stats without exposing the whole query
+func HashQuery(query string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(query))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func parseLokiStats(raw json.RawMessage) (*QueryStats, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var ls lokiStats
+	if err := json.Unmarshal(raw, &ls); err != nil {
+		return nil, err
+	}
+	qs := QueryStats{
+		DataSource: constants.DataSourceLoki,
+		QueryCosts: QueryCosts{
+			BytesProcessed: ls.Summary.TotalBytesProcessed,
+			LinesProcessed: ls.Summary.TotalLinesProcessed,
+			ExecTime:       ls.Summary.ExecTime,
+			QueueTime:      ls.Summary.QueueTime,
+			Subqueries:     ls.Summary.Subqueries,
+			Splits:         ls.Summary.Splits,
+			Shards:         ls.Summary.Shards,
+		},
+	}
+	for _, c := range ls.Cache {
+		qs.CacheHits += c.EntriesFound
+		qs.CacheRequests += c.EntriesRequested
+	}
+	return &qs, nil
+}
+
+// Add accumulates other costs into these ones
+func (c *QueryCosts) Add(other *QueryCosts) {
+	c.BytesProcessed += other.BytesProcessed
+	c.LinesProcessed += other.LinesProcessed
+	c.ExecTime += other.ExecTime
+	c.QueueTime += other.QueueTime
+	c.CacheHits += other.CacheHits
+	c.CacheRequests += other.CacheRequests
+	c.Subqueries += other.Subqueries
+	c.Splits += other.Splits
+	c.Shards += other.Shards
+}
+
+// TotalCosts sums the costs of all queries, or returns nil when there is no stats
+func TotalCosts(stats []QueryStats) *QueryCosts {
+	if len(stats) == 0 {
+		return nil
+	}
+	total := QueryCosts{}
+	for i := range stats {
+		total.Add(&stats[i].QueryCosts)
+	}
+	return &total
+}
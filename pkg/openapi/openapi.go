@@ -0,0 +1,249 @@
+// Package openapi serves the OpenAPI document of the plugin API, and validates requests against it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/handler/apierrors"
+)
+
+var hlog = logrus.WithField("module", "openapi")
+
+//go:embed openapi.yaml
+var spec []byte
+
+const parameterRefPrefix = "#/components/parameters/"
+
+type document struct {
+	Paths      map[string]pathItem `yaml:"paths"`
+	Components struct {
+		Parameters map[string]*Parameter `yaml:"parameters"`
+	} `yaml:"components"`
+}
+
+type pathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *operation   `yaml:"get"`
+	Post       *operation   `yaml:"post"`
+	Delete     *operation   `yaml:"delete"`
+}
+
+type operation struct {
+	Parameters []*Parameter `yaml:"parameters"`
+}
+
+// Parameter is a query or path parameter of an operation
+type Parameter struct {
+	Ref      string `yaml:"$ref"`
+	Name     string `yaml:"name"`
+	In       string `yaml:"in"`
+	Required bool   `yaml:"required"`
+	Schema   Schema `yaml:"schema"`
+}
+
+// Schema is the subset of JSON schemas used for parameters
+type Schema struct {
+	Type      string   `yaml:"type"`
+	Format    string   `yaml:"format"`
+	Enum      []string `yaml:"enum"`
+	Pattern   string   `yaml:"pattern"`
+	MaxLength *int     `yaml:"maxLength"`
+	Minimum   *float64 `yaml:"minimum"`
+	Items     *Schema  `yaml:"items"`
+	pattern   *regexp.Regexp
+}
+
+// Validator validates requests against the parameters of the OpenAPI document
+type Validator struct {
+	// operations are the parameters of operations, by method and path
+	operations map[string][]*Parameter
+}
+
+// NewValidator creates a validator from the OpenAPI document
+func NewValidator() (*Validator, error) {
+	var doc document
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	v := Validator{operations: map[string][]*Parameter{}}
+	for path, item := range doc.Paths {
+		for method, op := range map[string]*operation{http.MethodGet: item.Get, http.MethodPost: item.Post, http.MethodDelete: item.Delete} {
+			if op == nil {
+				continue
+			}
+			var params []*Parameter
+			// operation parameters override path ones
+			for _, p := range slices.Concat(op.Parameters, item.Parameters) {
+				p, err := doc.resolve(p)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+				if !slices.ContainsFunc(params, func(other *Parameter) bool { return other.Name == p.Name && other.In == p.In }) {
+					params = append(params, p)
+				}
+			}
+			v.operations[operationKey(method, path)] = params
+		}
+	}
+	return &v, nil
+}
+
+func (d *document) resolve(p *Parameter) (*Parameter, error) {
+	if p.Ref != "" {
+		resolved, ok := d.Components.Parameters[strings.TrimPrefix(p.Ref, parameterRefPrefix)]
+		if !strings.HasPrefix(p.Ref, parameterRefPrefix) || !ok {
+			return nil, fmt.Errorf("unknown parameter %s", p.Ref)
+		}
+		p = resolved
+	}
+	for s := &p.Schema; s != nil; s = s.Items {
+		if s.Pattern != "" && s.pattern == nil {
+			pattern, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of parameter %s: %w", p.Name, err)
+			}
+			s.pattern = pattern
+		}
+	}
+	return p, nil
+}
+
+func operationKey(method, path string) string {
+	return method + " " + path
+}
+
+// Validate checks the query and path parameters of a request to the given path of the document. Requests to paths
+// or methods that aren't documented aren't checked; neither are parameters that aren't documented.
+func (v *Validator) Validate(method, path string, query url.Values, vars map[string]string) *apierrors.ValidationError {
+	for _, p := range v.operations[operationKey(method, path)] {
+		var values []string
+		switch p.In {
+		case "query":
+			values = query[p.Name]
+		case "path":
+			if value, ok := vars[p.Name]; ok {
+				values = []string{value}
+			}
+		default:
+			continue
+		}
+		// empty parameters are ignored, as the handlers do
+		values = slices.DeleteFunc(slices.Clone(values), func(value string) bool { return value == "" })
+		if len(values) == 0 {
+			if p.Required {
+				return apierrors.NewValidationError(p.Name, fmt.Sprintf("%s parameter is required", p.Name))
+			}
+			continue
+		}
+		for _, value := range values {
+			if err := p.Schema.validate(value); err != nil {
+				return apierrors.NewValidationError(p.Name, fmt.Sprintf("invalid %s: %v", p.Name, err))
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validate(value string) error {
+	switch s.Type {
+	case "array":
+		if s.Items != nil {
+			for _, item := range strings.Split(value, ",") {
+				if err := s.Items.validate(item); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s is not an integer", value)
+		}
+		return s.validateMinimum(value, float64(n))
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s is not a number", value)
+		}
+		return s.validateMinimum(value, n)
+	case "boolean":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s is not a boolean", value)
+		}
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+		return fmt.Errorf("%s is not one of %s", value, strings.Join(s.Enum, ", "))
+	}
+	if s.MaxLength != nil && len(value) > *s.MaxLength {
+		return fmt.Errorf("%s is longer than %d", value, *s.MaxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		return fmt.Errorf("%s doesn't match %s", value, s.Pattern)
+	}
+	if s.Format == "duration" {
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%s is not a duration", value)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateMinimum(value string, n float64) error {
+	if s.Minimum != nil && n < *s.Minimum {
+		return fmt.Errorf("%s is lower than %v", value, *s.Minimum)
+	}
+	return nil
+}
+
+// Middleware validates requests of routes registered below the given prefix, such as /api, which is the server URL of
+// the document. Invalid requests get a 400 response with a validation error.
+func (v *Validator) Middleware(prefix string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					path := strings.TrimPrefix(tpl, prefix)
+					if verr := v.Validate(r.Method, path, r.URL.Query(), mux.Vars(r)); verr != nil {
+						hlog.Debugf("Invalid request %s %s: %v", r.Method, r.URL.Path, verr)
+						verr.Write(w, http.StatusBadRequest)
+						return
+					}
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Handler serves the OpenAPI document, as JSON
+func Handler() (func(http.ResponseWriter, *http.Request), error) {
+	var doc any
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert OpenAPI document to JSON: %w", err)
+	}
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(body); err != nil {
+			hlog.Errorf("Error while responding OpenAPI document: %v", err)
+		}
+	}, nil
+}
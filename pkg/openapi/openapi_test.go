@@ -0,0 +1,101 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+
+	validate := func(method, path, query string, vars map[string]string) string {
+		params, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		if verr := v.Validate(method, path, params, vars); verr != nil {
+			return verr.Parameter + ": " + verr.Message
+		}
+		return ""
+	}
+
+	// valid requests, with empty parameters ignored and unknown ones allowed
+	assert.Empty(t, validate(http.MethodGet, "/loki/flow/records", "startTime=1700000000&limit=50&recordType=endConnection&dataSource=&tenants=a,b&foo=bar", nil))
+	assert.Empty(t, validate(http.MethodGet, "/flow/metrics", "aggregateBy=namespace&function=p99&step=1m&snapshot=true", nil))
+	assert.Empty(t, validate(http.MethodGet, "/loki/export", "format=hubble&paged=true&maxRows=1000", nil))
+	// undocumented operations aren't checked
+	assert.Empty(t, validate(http.MethodPut, "/loki/flow/records", "limit=foo", nil))
+	assert.Empty(t, validate(http.MethodGet, "/unknown", "limit=foo", nil))
+
+	assert.Equal(t, "limit: invalid limit: foo is not an integer", validate(http.MethodGet, "/loki/flow/records", "limit=foo", nil))
+	assert.Equal(t, "recordType: invalid recordType: foo is not one of allConnections, newConnection, heartbeat, endConnection, flowLog",
+		validate(http.MethodGet, "/loki/flow/records", "recordType=foo", nil))
+	assert.Equal(t, "aggregateBy: aggregateBy parameter is required", validate(http.MethodGet, "/flow/metrics", "aggregateBy=", nil))
+	assert.Equal(t, "step: invalid step: 30 is not a duration", validate(http.MethodGet, "/flow/metrics", "aggregateBy=app&step=30", nil))
+	assert.Equal(t, "snapshot: invalid snapshot: 1 is not a boolean", validate(http.MethodGet, "/flow/metrics", "aggregateBy=app&snapshot=1", nil))
+	// parameters sharing a name across operations have their own schema
+	assert.Empty(t, validate(http.MethodGet, "/loki/export", "format=parquet", nil))
+	assert.Equal(t, "format: invalid format: parquet is not one of csv, openmetrics, graphml, dot",
+		validate(http.MethodGet, "/flow/metrics/export", "aggregateBy=app&format=parquet", nil))
+	assert.Equal(t, "maxRows: invalid maxRows: 0 is lower than 1", validate(http.MethodGet, "/loki/export", "format=csv&maxRows=0", nil))
+
+	assert.Equal(t, "namespace: invalid namespace: a&namespace=b doesn't match ^[a-z0-9]([-a-z0-9]*[a-z0-9])?$",
+		validate(http.MethodGet, "/resources/names", "namespace=a%26namespace%3Db", nil))
+	assert.Equal(t, "namespace: invalid namespace: "+strings.Repeat("a", 64)+" is longer than 63",
+		validate(http.MethodGet, "/resources/names", "namespace="+strings.Repeat("a", 64), nil))
+
+	// path parameters, declared on the path item
+	assert.Empty(t, validate(http.MethodDelete, "/loki/export/jobs/{id}", "", map[string]string{"id": "0123456789abcdef0123456789abcdef"}))
+	assert.Equal(t, "id: invalid id: foo doesn't match ^[a-f0-9]{32}$", validate(http.MethodGet, "/loki/export/jobs/{id}/download", "format=csv", map[string]string{"id": "foo"}))
+}
+
+func TestMiddleware(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(v.Middleware("/api"))
+	api.HandleFunc("/loki/export/jobs/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/loki/export/jobs/0123456789abcdef0123456789abcdef", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/loki/export/jobs/foo", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"validation":true,"parameter":"id","message":"invalid id: foo doesn't match ^[a-f0-9]{32}$"}`, rec.Body.String())
+}
+
+func TestHandler(t *testing.T) {
+	h, err := Handler()
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Contains(t, doc.Paths, "/flow/metrics")
+	assert.Contains(t, doc.Paths["/loki/export/jobs"], "post")
+	for _, schema := range []string{"AggregatedQueryResponse", "Status", "Frontend", "Error"} {
+		assert.Contains(t, doc.Components.Schemas, schema)
+	}
+}
@@ -0,0 +1,386 @@
+// Package parquet implements a minimal Parquet writer for exports: flat or list columns, all optional,
+// with uncompressed PLAIN-encoded pages and one page per column chunk.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+const magic = "PAR1"
+
+// Type is the physical type of a column
+type Type int32
+
+const (
+	Int64     Type = 2
+	Double    Type = 5
+	ByteArray Type = 6
+)
+
+// Logical describes how to interpret a physical type
+type Logical int
+
+const (
+	Plain Logical = iota
+	String
+	TimestampMillis
+)
+
+// Parquet enums
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+	repetitionRepeated = 2
+
+	convertedUTF8            = 0
+	convertedList            = 3
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeData = 0
+)
+
+// Column describes a column of the file. List columns use the standard 3-level list structure, with required elements.
+type Column struct {
+	Name    string
+	Type    Type
+	Logical Logical
+	List    bool
+}
+
+func (c *Column) maxDefinitionLevel() int {
+	if c.List {
+		return 2
+	}
+	return 1
+}
+
+func (c *Column) path() []string {
+	if c.List {
+		return []string{c.Name, "list", "element"}
+	}
+	return []string{c.Name}
+}
+
+type chunkMeta struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type rowGroup struct {
+	chunks  []chunkMeta
+	numRows int64
+	size    int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Writer writes rows to a Parquet file, buffering them until a row group is complete
+type Writer struct {
+	w            *countingWriter
+	columns      []Column
+	buffers      [][]any
+	rowGroupSize int
+	rowGroups    []rowGroup
+	numRows      int64
+}
+
+// NewWriter creates a writer for the given columns, flushing a row group every rowGroupSize rows
+func NewWriter(w io.Writer, columns []Column, rowGroupSize int) *Writer {
+	return &Writer{
+		w:            &countingWriter{w: w},
+		columns:      columns,
+		buffers:      make([][]any, len(columns)),
+		rowGroupSize: max(rowGroupSize, 1),
+	}
+}
+
+// Write adds a row, having a value for each column: nil, int64, float64 or string, or a slice of them for list columns
+func (wr *Writer) Write(row []any) error {
+	if len(row) != len(wr.columns) {
+		return fmt.Errorf("row has %d values, expected %d", len(row), len(wr.columns))
+	}
+	for i, v := range row {
+		wr.buffers[i] = append(wr.buffers[i], v)
+	}
+	if len(wr.buffers[0]) >= wr.rowGroupSize {
+		return wr.Flush()
+	}
+	return nil
+}
+
+// Flush writes buffered rows as a row group
+func (wr *Writer) Flush() error {
+	if len(wr.columns) == 0 || len(wr.buffers[0]) == 0 {
+		return nil
+	}
+	if wr.w.n == 0 {
+		if _, err := io.WriteString(wr.w, magic); err != nil {
+			return err
+		}
+	}
+	rg := rowGroup{numRows: int64(len(wr.buffers[0]))}
+	for i := range wr.columns {
+		chunk, err := wr.writeChunk(&wr.columns[i], wr.buffers[i])
+		if err != nil {
+			return err
+		}
+		rg.chunks = append(rg.chunks, chunk)
+		rg.size += chunk.size
+		wr.buffers[i] = wr.buffers[i][:0]
+	}
+	wr.rowGroups = append(wr.rowGroups, rg)
+	wr.numRows += rg.numRows
+	return nil
+}
+
+// Close flushes buffered rows and writes the file footer. It doesn't close the underlying writer.
+func (wr *Writer) Close() error {
+	if err := wr.Flush(); err != nil {
+		return err
+	}
+	if wr.w.n == 0 {
+		if _, err := io.WriteString(wr.w, magic); err != nil {
+			return err
+		}
+	}
+	footer := wr.footer()
+	if _, err := wr.w.Write(footer); err != nil {
+		return err
+	}
+	if err := binary.Write(wr.w, binary.LittleEndian, uint32(len(footer))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(wr.w, magic)
+	return err
+}
+
+func (wr *Writer) writeChunk(col *Column, values []any) (chunkMeta, error) {
+	var reps, defs []int
+	var data bytes.Buffer
+	for _, v := range values {
+		if !col.List {
+			if v == nil {
+				defs = append(defs, 0)
+				continue
+			}
+			defs = append(defs, 1)
+			if err := encodeValue(&data, col, v); err != nil {
+				return chunkMeta{}, err
+			}
+			continue
+		}
+		if v == nil {
+			reps, defs = append(reps, 0), append(defs, 0)
+			continue
+		}
+		items, ok := v.([]any)
+		if !ok {
+			return chunkMeta{}, fmt.Errorf("column %s: expected a list, got %T", col.Name, v)
+		}
+		rep := 0
+		for _, item := range items {
+			// elements are required: skip null ones
+			if item == nil {
+				continue
+			}
+			reps, defs = append(reps, rep), append(defs, 2)
+			rep = 1
+			if err := encodeValue(&data, col, item); err != nil {
+				return chunkMeta{}, err
+			}
+		}
+		if rep == 0 {
+			// empty list
+			reps, defs = append(reps, 0), append(defs, 1)
+		}
+	}
+
+	var page bytes.Buffer
+	if col.List {
+		writeLevels(&page, reps, 1)
+	}
+	writeLevels(&page, defs, col.maxDefinitionLevel())
+	page.Write(data.Bytes())
+
+	t := thriftWriter{}
+	t.beginStruct()
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(page.Len()))
+	t.i32(3, int32(page.Len()))
+	t.structField(5, func() {
+		t.i32(1, int32(len(defs)))
+		t.i32(2, encodingPlain)
+		t.i32(3, encodingRLE)
+		t.i32(4, encodingRLE)
+	})
+	t.endStruct()
+
+	offset := wr.w.n
+	if _, err := wr.w.Write(t.buf.Bytes()); err != nil {
+		return chunkMeta{}, err
+	}
+	if _, err := wr.w.Write(page.Bytes()); err != nil {
+		return chunkMeta{}, err
+	}
+	return chunkMeta{offset: offset, size: wr.w.n - offset, numValues: int64(len(defs))}, nil
+}
+
+func encodeValue(buf *bytes.Buffer, col *Column, v any) error {
+	switch col.Type {
+	case Int64:
+		var i int64
+		switch x := v.(type) {
+		case int64:
+			i = x
+		case float64:
+			// values are never truncated: fractional ones belong to Double columns
+			if x != math.Trunc(x) || math.IsInf(x, 0) {
+				return fmt.Errorf("column %s: %v is not an integer", col.Name, x)
+			}
+			i = int64(x)
+		default:
+			return fmt.Errorf("column %s: expected a number, got %T", col.Name, v)
+		}
+		return binary.Write(buf, binary.LittleEndian, i)
+	case Double:
+		var f float64
+		switch x := v.(type) {
+		case int64:
+			f = float64(x)
+		case float64:
+			f = x
+		default:
+			return fmt.Errorf("column %s: expected a number, got %T", col.Name, v)
+		}
+		return binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+	case ByteArray:
+		s, ok := v.(string)
+		if !ok {
+			s = fmt.Sprint(v)
+		}
+		if err := binary.Write(buf, binary.LittleEndian, uint32(len(s))); err != nil {
+			return err
+		}
+		buf.WriteString(s)
+		return nil
+	}
+	return fmt.Errorf("column %s: unsupported type %d", col.Name, col.Type)
+}
+
+// writeLevels writes levels with the RLE / bit-packing hybrid encoding, using RLE runs only, prefixed by their length
+func writeLevels(buf *bytes.Buffer, levels []int, maxLevel int) {
+	width := (bits.Len(uint(maxLevel)) + 7) / 8
+	var runs []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		runs = binary.AppendUvarint(runs, uint64(j-i)<<1)
+		for b := 0; b < width; b++ {
+			runs = append(runs, byte(levels[i]>>(8*b)))
+		}
+		i = j
+	}
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(runs)))
+	buf.Write(runs)
+}
+
+func (wr *Writer) footer() []byte {
+	t := thriftWriter{}
+	t.beginStruct()
+	t.i32(1, 1)
+
+	// schema: root, then each column, lists being groups of a repeated group of elements
+	schemaSize := 1
+	for i := range wr.columns {
+		if wr.columns[i].List {
+			schemaSize += 3
+		} else {
+			schemaSize++
+		}
+	}
+	t.listHeader(2, thriftStruct, schemaSize)
+	element := func(write func()) {
+		t.beginStruct()
+		write()
+		t.endStruct()
+	}
+	element(func() {
+		t.str(4, "schema")
+		t.i32(5, int32(len(wr.columns)))
+	})
+	for i := range wr.columns {
+		col := &wr.columns[i]
+		leaf := func(repetition int32, name string) {
+			t.i32(1, int32(col.Type))
+			t.i32(3, repetition)
+			t.str(4, name)
+			switch col.Logical {
+			case String:
+				t.i32(6, convertedUTF8)
+			case TimestampMillis:
+				t.i32(6, convertedTimestampMillis)
+			}
+		}
+		if !col.List {
+			element(func() { leaf(repetitionOptional, col.Name) })
+			continue
+		}
+		element(func() {
+			t.i32(3, repetitionOptional)
+			t.str(4, col.Name)
+			t.i32(5, 1)
+			t.i32(6, convertedList)
+		})
+		element(func() {
+			t.i32(3, repetitionRepeated)
+			t.str(4, "list")
+			t.i32(5, 1)
+		})
+		element(func() { leaf(repetitionRequired, "element") })
+	}
+
+	t.i64(3, wr.numRows)
+	t.structList(4, len(wr.rowGroups), func(i int) {
+		rg := &wr.rowGroups[i]
+		t.structList(1, len(rg.chunks), func(j int) {
+			chunk := &rg.chunks[j]
+			col := &wr.columns[j]
+			t.i64(2, chunk.offset)
+			t.structField(3, func() {
+				t.i32(1, int32(col.Type))
+				t.i32List(2, []int32{encodingPlain, encodingRLE})
+				t.strList(3, col.path())
+				t.i32(4, 0) // uncompressed
+				t.i64(5, chunk.numValues)
+				t.i64(6, chunk.size)
+				t.i64(7, chunk.size)
+				t.i64(9, chunk.offset)
+			})
+		})
+		t.i64(2, rg.size)
+		t.i64(3, rg.numRows)
+	})
+	t.str(6, "netobserv-console-plugin")
+	t.endStruct()
+	return t.buf.Bytes()
+}
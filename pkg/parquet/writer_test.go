@@ -0,0 +1,180 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// readPage returns the levels section and values of the single page of a column chunk
+func readPage(file []byte, offset int64) (map[int16]any, []byte) {
+	r := bytes.NewReader(file[offset:])
+	header := (&thriftReader{r: r}).readStruct()
+	start := offset + int64(len(file[offset:])-r.Len())
+	return header, file[start : start+header[3].(int64)]
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{
+		{Name: "Bytes", Type: Int64},
+		{Name: "Ratio", Type: Double},
+		{Name: "SrcAddr", Type: ByteArray, Logical: String},
+		{Name: "TimeFlowStartMs", Type: Int64, Logical: TimestampMillis},
+		{Name: "Interfaces", Type: ByteArray, Logical: String, List: true},
+	}, 2)
+	require.NoError(t, w.Write([]any{int64(100), 0.5, "10.0.0.1", int64(1000), []any{"eth0", "br-ex"}}))
+	require.NoError(t, w.Write([]any{nil, int64(1), nil, int64(2000), []any{}}))
+	require.NoError(t, w.Write([]any{int64(300), 2.25, "10.0.0.3", int64(3000), nil}))
+	assert.Error(t, w.Write([]any{int64(1)}))
+	require.NoError(t, w.Close())
+
+	file := buf.Bytes()
+	footer := readFooter(t, file)
+	assert.Equal(t, int64(1), footer[1])
+	assert.Equal(t, int64(3), footer[3])
+	assert.Equal(t, "netobserv-console-plugin", footer[6])
+
+	schema := footer[2].([]any)
+	require.Len(t, schema, 8)
+	assert.Equal(t, map[int16]any{4: "schema", 5: int64(5)}, schema[0])
+	assert.Equal(t, map[int16]any{1: int64(Int64), 3: int64(repetitionOptional), 4: "Bytes"}, schema[1])
+	assert.Equal(t, map[int16]any{1: int64(Double), 3: int64(repetitionOptional), 4: "Ratio"}, schema[2])
+	assert.Equal(t, map[int16]any{1: int64(ByteArray), 3: int64(repetitionOptional), 4: "SrcAddr", 6: int64(convertedUTF8)}, schema[3])
+	assert.Equal(t, map[int16]any{1: int64(Int64), 3: int64(repetitionOptional), 4: "TimeFlowStartMs", 6: int64(convertedTimestampMillis)}, schema[4])
+	assert.Equal(t, map[int16]any{3: int64(repetitionOptional), 4: "Interfaces", 5: int64(1), 6: int64(convertedList)}, schema[5])
+	assert.Equal(t, map[int16]any{3: int64(repetitionRepeated), 4: "list", 5: int64(1)}, schema[6])
+	assert.Equal(t, map[int16]any{1: int64(ByteArray), 3: int64(repetitionRequired), 4: "element", 6: int64(convertedUTF8)}, schema[7])
+
+	rowGroups := footer[4].([]any)
+	require.Len(t, rowGroups, 2)
+	rg := rowGroups[0].(map[int16]any)
+	assert.Equal(t, int64(2), rg[3])
+	chunks := rg[1].([]any)
+	require.Len(t, chunks, 5)
+
+	// Bytes: 100, null
+	meta := chunks[0].(map[int16]any)[3].(map[int16]any)
+	assert.Equal(t, []any{"Bytes"}, meta[3])
+	assert.Equal(t, int64(2), meta[5])
+	header, page := readPage(file, meta[9].(int64))
+	assert.Equal(t, int64(2), header[5].(map[int16]any)[1])
+	// levels: 4-byte length, then RLE runs of 1 x 1 and 1 x 0
+	assert.Equal(t, []byte{4, 0, 0, 0, 2, 1, 2, 0}, page[:8])
+	assert.Equal(t, int64(100), int64(binary.LittleEndian.Uint64(page[8:])))
+
+	// Ratio: 0.5, 1.0
+	meta = chunks[1].(map[int16]any)[3].(map[int16]any)
+	_, page = readPage(file, meta[9].(int64))
+	assert.Equal(t, 0.5, math.Float64frombits(binary.LittleEndian.Uint64(page[6:])))
+	assert.Equal(t, 1.0, math.Float64frombits(binary.LittleEndian.Uint64(page[14:])))
+
+	// Interfaces: [eth0, br-ex], []
+	meta = chunks[4].(map[int16]any)[3].(map[int16]any)
+	assert.Equal(t, []any{"Interfaces", "list", "element"}, meta[3])
+	assert.Equal(t, int64(3), meta[5])
+	_, page = readPage(file, meta[9].(int64))
+	// repetition levels: 0, 1, 0
+	assert.Equal(t, []byte{6, 0, 0, 0, 2, 0, 2, 1, 2, 0}, page[:10])
+	// definition levels: 2, 2, 1
+	assert.Equal(t, []byte{4, 0, 0, 0, 4, 2, 2, 1}, page[10:18])
+	assert.Equal(t, []byte{4, 0, 0, 0, 'e', 't', 'h', '0'}, page[18:26])
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "Bytes", Type: Double}}, 10)
+	require.NoError(t, w.Close())
+	footer := readFooter(t, buf.Bytes())
+	assert.Equal(t, int64(0), footer[3])
+	assert.Equal(t, []any{}, footer[4])
+}
+
+func TestWriter_NoTruncation(t *testing.T) {
+	// fractional values after integer row groups are kept
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "Bytes", Type: Double}}, 1)
+	require.NoError(t, w.Write([]any{int64(100)}))
+	require.NoError(t, w.Write([]any{2.5}))
+	require.NoError(t, w.Close())
+	file := buf.Bytes()
+	rowGroups := readFooter(t, file)[4].([]any)
+	require.Len(t, rowGroups, 2)
+	for i, expected := range []float64{100, 2.5} {
+		meta := rowGroups[i].(map[int16]any)[1].([]any)[0].(map[int16]any)[3].(map[int16]any)
+		assert.Equal(t, int64(Double), meta[1])
+		_, page := readPage(file, meta[9].(int64))
+		assert.Equal(t, expected, math.Float64frombits(binary.LittleEndian.Uint64(page[6:])))
+	}
+
+	// integer columns reject fractional values
+	w = NewWriter(&buf, []Column{{Name: "TimeFlowStartMs", Type: Int64, Logical: TimestampMillis}}, 1)
+	assert.ErrorContains(t, w.Write([]any{2.5}), "2.5 is not an integer")
+}
+
+// TestWriter_Golden writes the rows of testdata/flows.json and compares the output with testdata/flows.parquet.
+// The golden file is also read back by pyarrow in CI, with scripts/check-parquet.sh, which can be run locally after -update.
+func TestWriter_Golden(t *testing.T) {
+	columns := []Column{
+		{Name: "Bytes", Type: Double},
+		{Name: "Packets", Type: Int64},
+		{Name: "SrcAddr", Type: ByteArray, Logical: String},
+		{Name: "TimeFlowStartMs", Type: Int64, Logical: TimestampMillis},
+		{Name: "Interfaces", Type: ByteArray, Logical: String, List: true},
+	}
+	input, err := os.ReadFile("testdata/flows.json")
+	require.NoError(t, err)
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+	var records []map[string]any
+	require.NoError(t, decoder.Decode(&records))
+
+	var buf bytes.Buffer
+	// 2 rows per group: Bytes are integers in the first row group only
+	w := NewWriter(&buf, columns, 2)
+	for _, record := range records {
+		row := make([]any, len(columns))
+		for i := range columns {
+			row[i] = goldenValue(t, &columns[i], record[columns[i].Name])
+		}
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	if *update {
+		require.NoError(t, os.WriteFile("testdata/flows.parquet", buf.Bytes(), 0o644))
+	}
+	expected, err := os.ReadFile("testdata/flows.parquet")
+	require.NoError(t, err)
+	assert.Equal(t, expected, buf.Bytes())
+}
+
+func goldenValue(t *testing.T, col *Column, v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		if col.Type == Int64 {
+			i, err := x.Int64()
+			require.NoError(t, err)
+			return i
+		}
+		f, err := x.Float64()
+		require.NoError(t, err)
+		return f
+	case []any:
+		items := make([]any, len(x))
+		for i := range x {
+			items[i] = goldenValue(t, col, x[i])
+		}
+		return items
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/netobserv/network-observability-console-plugin/pkg/config"
+	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+const (
+	silencesPath = "/api/v2/silences"
+	silencePath  = "/api/v2/silence/:id"
+)
+
+// NewAlertManagerClient creates a client for the Alertmanager v2 API, using the same credentials as Prometheus
+func NewAlertManagerClient(cfg *config.Prometheus, requestHeader http.Header) (api.Client, error) {
+	return newClient(cfg.Timeout.Duration, cfg.SkipTLS, cfg.CAPath, cfg.ForwardUserToken, cfg.TokenPath, cfg.AlertManager.URL, requestHeader)
+}
+
+func doAlertManager(ctx context.Context, cl api.Client, method string, u *url.URL, payload interface{}, result interface{}) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, respBody, err := cl.Do(ctx, req)
+	if err != nil {
+		return http.StatusServiceUnavailable, fmt.Errorf("error from Alertmanager: %w", err)
+	}
+	log.Tracef("Alertmanager response:\n%s", string(respBody))
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, fmt.Errorf("error from Alertmanager [code=%d]: %s", resp.StatusCode, string(respBody))
+	}
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("cannot parse Alertmanager response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// ListSilences fetches silences, optionally filtered with Alertmanager label matchers such as `netobserv="true"`
+func ListSilences(ctx context.Context, cl api.Client, filters []string) ([]model.Silence, int, error) {
+	u := cl.URL(silencesPath, nil)
+	if len(filters) > 0 {
+		q := u.Query()
+		for _, f := range filters {
+			q.Add("filter", f)
+		}
+		u.RawQuery = q.Encode()
+	}
+	var silences []model.Silence
+	code, err := doAlertManager(ctx, cl, http.MethodGet, u, nil, &silences)
+	return silences, code, err
+}
+
+// GetSilence fetches a single silence by ID
+func GetSilence(ctx context.Context, cl api.Client, id string) (model.Silence, int, error) {
+	var silence model.Silence
+	code, err := doAlertManager(ctx, cl, http.MethodGet, cl.URL(silencePath, map[string]string{"id": url.PathEscape(id)}), nil, &silence)
+	return silence, code, err
+}
+
+// CreateSilence posts a new silence and returns its ID
+func CreateSilence(ctx context.Context, cl api.Client, silence *model.Silence) (string, int, error) {
+	var res struct {
+		SilenceID string `json:"silenceID"`
+	}
+	code, err := doAlertManager(ctx, cl, http.MethodPost, cl.URL(silencesPath, nil), silence, &res)
+	return res.SilenceID, code, err
+}
+
+// ExpireSilence expires a silence by ID
+func ExpireSilence(ctx context.Context, cl api.Client, id string) (int, error) {
+	return doAlertManager(ctx, cl, http.MethodDelete, cl.URL(silencePath, map[string]string{"id": url.PathEscape(id)}), nil, nil)
+}
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	neturl "net/url"
 	"strings"
 	"time"
 
@@ -14,6 +14,7 @@ import (
 	"github.com/netobserv/network-observability-console-plugin/pkg/kubernetes/auth"
 	"github.com/netobserv/network-observability-console-plugin/pkg/metrics"
 	"github.com/netobserv/network-observability-console-plugin/pkg/model"
+	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
@@ -30,7 +31,7 @@ func NewDevClient(cfg *config.Prometheus, requestHeader http.Header, namespace s
 	if cfg.DevURL == "" {
 		url = cfg.URL
 	} else {
-		url = fmt.Sprintf("%s?namespace=%s", cfg.DevURL, namespace)
+		url = cfg.DevURL + "?" + neturl.Values{"namespace": {namespace}}.Encode()
 	}
 	return newClient(cfg.Timeout.Duration, cfg.SkipTLS, cfg.CAPath, cfg.ForwardUserToken, cfg.TokenPath, url, requestHeader)
 }
@@ -44,7 +45,7 @@ func newClient(timeout time.Duration, skipTLS bool, caPath string, forwardUserTo
 }
 
 func CreateRoundTripper(timeout time.Duration, skipTLS bool, caPath string, forwardUserToken bool, tokenPath string, requestHeader http.Header) (http.RoundTripper, error) {
-	maybeTLS := httpclient.NewTransport(timeout, skipTLS, caPath, "", "")
+	maybeTLS := httpclient.SharedTransport(httpclient.TransportKey{Timeout: timeout, SkipTLS: skipTLS, CAPath: caPath})
 
 	var roundTripper http.RoundTripper
 	if forwardUserToken && requestHeader != nil {
@@ -56,12 +57,12 @@ func CreateRoundTripper(timeout time.Duration, skipTLS bool, caPath string, forw
 			log.Debug("Missing Authorization token in user request")
 		}
 	} else if tokenPath != "" {
-		bytes, err := os.ReadFile(tokenPath)
+		token, err := httpclient.ReadTokenFile(tokenPath)
 		if err != nil {
 			log.WithError(err).Warnf("Failed to read authorization token from path '%s'. Continuing without token authentication. This may cause authentication failures if the Prometheus server requires authentication.", tokenPath)
 			roundTripper = maybeTLS
 		} else {
-			roundTripper = pconf.NewAuthorizationCredentialsRoundTripper("Bearer", pconf.NewInlineSecret(string(bytes)), maybeTLS)
+			roundTripper = pconf.NewAuthorizationCredentialsRoundTripper("Bearer", pconf.NewInlineSecret(token), maybeTLS)
 		}
 	} else {
 		roundTripper = maybeTLS
@@ -120,6 +121,102 @@ func QueryMatrix(ctx context.Context, cl api.Client, q *Query) (model.QueryRespo
 	return qr, code, nil
 }
 
+func executeQuery(ctx context.Context, cl api.Client, q *Query) (pmod.Value, int, error) {
+	var code int
+	startTime := time.Now()
+	defer func() {
+		metrics.ObservePromCall(code, startTime)
+	}()
+
+	log.Debugf("executeQuery: %v; promQL=%s", q.Range.End, q.PromQL)
+	v1api := v1.NewAPI(cl)
+	result, warnings, err := v1api.Query(ctx, q.PromQL, q.Range.End)
+	log.Tracef("Result:\n%v", result)
+	if len(warnings) > 0 {
+		log.Infof("executeQuery warnings: %v", warnings)
+	}
+	if err != nil {
+		log.Tracef("Error:\n%v", err)
+		code = translateErrorCode(err)
+		return nil, code, fmt.Errorf("error from Prometheus query: %w", err)
+	}
+
+	code = http.StatusOK
+	return result, code, nil
+}
+
+// QueryVector runs an instant query, evaluated at the end of the query range
+func QueryVector(ctx context.Context, cl api.Client, q *Query) (model.QueryResponse, int, error) {
+	resp, code, err := executeQuery(ctx, cl, q)
+	if err != nil {
+		log.WithError(err).Error("Error in QueryVector")
+		return model.QueryResponse{}, code, err
+	}
+	// Transform response
+	v, ok := resp.(pmod.Vector)
+	if !ok {
+		err := fmt.Errorf("QueryVector: wrong return type: %T", resp)
+		log.Error(err.Error())
+		return model.QueryResponse{}, http.StatusInternalServerError, err
+	}
+	convVector := model.Vector{}
+	for i := range v {
+		convVector = append(convVector, *v[i])
+	}
+	qr := model.QueryResponse{
+		Data: model.QueryResponseData{
+			ResultType: model.ResultTypeVector,
+			Result:     convVector,
+		},
+	}
+	return qr, code, nil
+}
+
+// RunQuery runs an instant query for snapshots, or a range query otherwise
+// Prometheus engine stats are not exposed by the client library, so only the execution time is measured
+func RunQuery(ctx context.Context, cl api.Client, q *Query) (model.QueryResponse, int, error) {
+	startTime := time.Now()
+	var qr model.QueryResponse
+	var code int
+	var err error
+	if q.Snapshot {
+		qr, code, err = QueryVector(ctx, cl, q)
+	} else {
+		qr, code, err = QueryMatrix(ctx, cl, q)
+	}
+	if err != nil {
+		return qr, code, err
+	}
+	qr.Data.Stats = &model.QueryStats{
+		DataSource: constants.DataSourceProm,
+		QueryHash:  model.HashQuery(q.PromQL),
+		QueryCosts: model.QueryCosts{ExecTime: time.Since(startTime).Seconds()},
+	}
+	log.Debugf("Prometheus query %s stats: %+v", qr.Data.Stats.QueryHash, qr.Data.Stats.QueryCosts)
+	metrics.ObserveQueryStats(qr.Data.Stats)
+	return qr, code, nil
+}
+
+// GetRules fetches alerting and recording rules, including active alerts
+func GetRules(ctx context.Context, cl api.Client) (v1.RulesResult, int, error) {
+	var code int
+	startTime := time.Now()
+	defer func() {
+		metrics.ObservePromCall(code, startTime)
+	}()
+
+	log.Debug("GetRules")
+	v1api := v1.NewAPI(cl)
+	result, err := v1api.Rules(ctx)
+	if err != nil {
+		code = translateErrorCode(err)
+		return result, code, fmt.Errorf("could not get rules: %w", err)
+	}
+	log.Tracef("Result:\n%v", result)
+	code = http.StatusOK
+	return result, code, nil
+}
+
 func GetLabelValues(ctx context.Context, cl api.Client, label string, match []string) ([]string, int, error) {
 	log.Debugf("GetLabelValues: %s", label)
 	v1api := v1.NewAPI(cl)
@@ -139,6 +236,26 @@ func GetLabelValues(ctx context.Context, cl api.Client, label string, match []st
 	return asStrings, http.StatusOK, nil
 }
 
+// GetTSDBStatus fetches cardinality statistics about the TSDB head block
+func GetTSDBStatus(ctx context.Context, cl api.Client, limit uint64) (v1.TSDBResult, int, error) {
+	var code int
+	startTime := time.Now()
+	defer func() {
+		metrics.ObservePromCall(code, startTime)
+	}()
+
+	log.Debug("GetTSDBStatus")
+	v1api := v1.NewAPI(cl)
+	result, err := v1api.TSDB(ctx, v1.WithLimit(limit))
+	if err != nil {
+		code = translateErrorCode(err)
+		return result, code, fmt.Errorf("could not get TSDB status: %w", err)
+	}
+	log.Tracef("Result:\n%v", result)
+	code = http.StatusOK
+	return result, code, nil
+}
+
 func translateErrorCode(err error) int {
 	var promError *v1.Error
 	if errors.As(err, &promError) {
@@ -7,6 +7,7 @@ import (
 	"github.com/netobserv/network-observability-console-plugin/pkg/model/filters"
 	"github.com/netobserv/network-observability-console-plugin/pkg/utils/constants"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	pmod "github.com/prometheus/common/model"
 )
 
 type QueryBuilder struct {
@@ -20,6 +21,8 @@ type QueryBuilder struct {
 type Query struct {
 	Range  v1.Range
 	PromQL string
+	// Snapshot queries are instant queries evaluated at the end of the range, returning a Vector
+	Snapshot bool
 }
 
 func NewQuery(kl map[string][]string, in *loki.TopologyInput, qr *v1.Range, filters filters.SingleQuery, orMetrics []string) *QueryBuilder {
@@ -54,6 +57,38 @@ func (q *QueryBuilder) Build() Query {
 		}
 	}
 
+	rateFunction, rateInterval := "rate", q.in.RateInterval
+	var overTime, subqueryRange string
+	if q.in.Snapshot {
+		// In snapshot mode, the whole range is covered by an instant query
+		window := pmod.Duration(q.qRange.End.Sub(q.qRange.Start)).String()
+		switch q.in.MetricFunction {
+		case constants.MetricFunctionSum, constants.MetricFunctionCount, constants.MetricFunctionRate:
+			// rates are totals as well, as in Loki snapshots
+			if isHisto {
+				rateInterval = window
+			} else {
+				rateFunction, rateInterval = "increase", window
+			}
+		case constants.MetricFunctionMin, constants.MetricFunctionMax:
+			overTime = string(q.in.MetricFunction) + "_over_time"
+		default:
+			if isHisto {
+				// quantiles and averages are computed from the buckets / sum / count over the whole range
+				rateInterval = window
+			} else {
+				overTime = "avg_over_time"
+			}
+		}
+		if overTime != "" {
+			subqueryRange = "[" + window + ":"
+			if q.qRange.Step > 0 {
+				subqueryRange += pmod.Duration(q.qRange.Step).String()
+			}
+			subqueryRange += "]"
+		}
+	}
+
 	// Build metrics query like:
 	//		topk | bottomk(
 	// 			<k>,
@@ -64,6 +99,8 @@ func (q *QueryBuilder) Build() Query {
 	//			)
 	//		)
 	//		&<query params>&step=<step>
+	// In snapshot mode, the function is either applied over the whole range, or the sum is wrapped in a
+	// <min|max|avg>_over_time subquery
 	sb := strings.Builder{}
 
 	if q.in.Top != "" {
@@ -81,6 +118,10 @@ func (q *QueryBuilder) Build() Query {
 		if orIdx > 0 {
 			sb.WriteString(" or ")
 		}
+		if overTime != "" {
+			sb.WriteString(overTime)
+			sb.WriteString("((")
+		}
 
 		if isHisto && quantile != "" {
 			// use histogram_quantile
@@ -105,14 +146,14 @@ func (q *QueryBuilder) Build() Query {
 		if isHisto {
 			if quantile == "" {
 				// histogram average: sum / count
-				appendRate(&sb, metric+"_sum", q.filters, q.in.RateInterval)
+				appendRate(&sb, rateFunction, metric+"_sum", q.filters, rateInterval)
 				sb.WriteRune('/')
-				appendRate(&sb, metric+"_count", q.filters, q.in.RateInterval)
+				appendRate(&sb, rateFunction, metric+"_count", q.filters, rateInterval)
 			} else {
-				appendRate(&sb, metric+"_bucket", q.filters, q.in.RateInterval)
+				appendRate(&sb, rateFunction, metric+"_bucket", q.filters, rateInterval)
 			}
 		} else {
-			appendRate(&sb, metric, q.filters, q.in.RateInterval)
+			appendRate(&sb, rateFunction, metric, q.filters, rateInterval)
 		}
 		sb.WriteRune(')') // closes sum(...
 		if isHisto && quantile != "" {
@@ -122,6 +163,11 @@ func (q *QueryBuilder) Build() Query {
 		if len(factor) > 0 {
 			sb.WriteString(factor)
 		}
+		if overTime != "" {
+			sb.WriteRune(')')
+			sb.WriteString(subqueryRange)
+			sb.WriteRune(')') // closes <function>_over_time(...
+		}
 	}
 
 	if q.in.Top != "" {
@@ -129,13 +175,15 @@ func (q *QueryBuilder) Build() Query {
 	}
 
 	return Query{
-		PromQL: sb.String(),
-		Range:  q.qRange,
+		PromQL:   sb.String(),
+		Range:    q.qRange,
+		Snapshot: q.in.Snapshot,
 	}
 }
 
-func appendRate(sb *strings.Builder, metric string, filters filters.SingleQuery, interval string) {
-	sb.WriteString("rate(")
+func appendRate(sb *strings.Builder, function, metric string, filters filters.SingleQuery, interval string) {
+	sb.WriteString(function)
+	sb.WriteRune('(')
 	appendFilteredMetric(sb, metric, filters)
 	sb.WriteRune('[')
 	sb.WriteString(interval)
@@ -213,3 +213,64 @@ func TestBuildQuery_PromQLORMetrics(t *testing.T) {
 		result.PromQL,
 	)
 }
+
+func TestBuildQuery_PromQLSnapshot(t *testing.T) {
+	snapshotRange := v1.Range{Start: qr.End.Add(-15 * time.Minute), End: qr.End, Step: 30 * time.Second}
+	in := loki.TopologyInput{
+		Top:            "50",
+		RateInterval:   "2m",
+		DataField:      "Bytes",
+		MetricFunction: constants.MetricFunctionSum,
+		RecordType:     constants.RecordTypeLog,
+		DataSource:     constants.DataSourceAuto,
+		Aggregate:      "namespace",
+		Snapshot:       true,
+	}
+	f := filters.SingleQuery{}
+
+	// Total over the range
+	result := NewQuery(kl, &in, &snapshotRange, f, []string{"my_metric"}).Build()
+	assert.True(t, result.Snapshot)
+	assert.Equal(
+		t,
+		"topk(50,sum by(SrcK8S_Namespace,DstK8S_Namespace)(increase(my_metric{}[15m])))",
+		result.PromQL,
+	)
+
+	// Rates are totals too
+	in.MetricFunction = constants.MetricFunctionRate
+	result = NewQuery(kl, &in, &snapshotRange, f, []string{"my_metric"}).Build()
+	assert.Equal(
+		t,
+		"topk(50,sum by(SrcK8S_Namespace,DstK8S_Namespace)(increase(my_metric{}[15m])))",
+		result.PromQL,
+	)
+
+	// Average rate over the range
+	in.MetricFunction = constants.MetricFunctionAvg
+	result = NewQuery(kl, &in, &snapshotRange, f, []string{"my_metric"}).Build()
+	assert.Equal(
+		t,
+		"topk(50,avg_over_time((sum by(SrcK8S_Namespace,DstK8S_Namespace)(rate(my_metric{}[2m])))[15m:30s]))",
+		result.PromQL,
+	)
+
+	// Min rate over the range
+	in.MetricFunction = constants.MetricFunctionMin
+	result = NewQuery(kl, &in, &snapshotRange, f, []string{"my_metric"}).Build()
+	assert.Equal(
+		t,
+		"bottomk(50,min_over_time((sum by(SrcK8S_Namespace,DstK8S_Namespace)(rate(my_metric{}[2m])))[15m:30s]))",
+		result.PromQL,
+	)
+
+	// Histogram quantile over the range
+	in.DataField = "TimeFlowRttNs"
+	in.MetricFunction = constants.MetricFunctionP99
+	result = NewQuery(kl, &in, &snapshotRange, f, []string{"my_metric"}).Build()
+	assert.Equal(
+		t,
+		"topk(50,histogram_quantile(0.99,sum by(SrcK8S_Namespace,DstK8S_Namespace,le)(rate(my_metric_bucket{}[15m])))*1000)",
+		result.PromQL,
+	)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/netobserv/network-observability-console-plugin/pkg/handler"
 	"github.com/netobserv/network-observability-console-plugin/pkg/handler/alertingmock"
 	"github.com/netobserv/network-observability-console-plugin/pkg/kubernetes/auth"
+	"github.com/netobserv/network-observability-console-plugin/pkg/openapi"
 	"github.com/netobserv/network-observability-console-plugin/pkg/prometheus"
 )
 
@@ -21,45 +22,90 @@ func setupRoutes(ctx context.Context, cfg *config.Config, authChecker auth.Check
 	}
 
 	r := mux.NewRouter()
-	h := handler.Handlers{Cfg: cfg, PromInventory: promInventory}
+	h := handler.Handlers{Cfg: cfg, PromInventory: promInventory, AuthChecker: authChecker}
+	if !cfg.Static && cfg.Export.Jobs.Dir != "" {
+		jobs, err := handler.NewExportJobs(ctx, &cfg.Export.Jobs)
+		if err != nil {
+			logrus.Errorf("Export jobs are disabled: %v", err)
+		} else {
+			h.ExportJobs = jobs
+		}
+	}
 
 	// Role
 	r.PathPrefix("/").Subrouter().HandleFunc("/role", getRole(authChecker))
 
-	// API role checks
+	// Request validation against the OpenAPI document
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		logrus.Errorf("Request validation is disabled: %v", err)
+	}
+
+	// Versioned API, with responses in an envelope
+	v2 := r.PathPrefix("/api/v2").Subrouter()
+	v2.Use(handler.Envelope("/api/v2"), checkAuth(ctx, authChecker))
+	if validator != nil {
+		v2.Use(validator.Middleware("/api/v2"))
+	}
+	setupAPIRoutes(ctx, cfg, &h, authChecker, v2, false)
+
+	// API of the console
 	api := r.PathPrefix("/api").Subrouter()
-	api.Use(func(orig http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if err := authChecker.CheckAuth(ctx, r.Header); err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, err2 := w.Write([]byte(err.Error()))
-				if err2 != nil {
-					logrus.Errorf("Error while responding an error: %v (initial was: %v)", err2, err)
-				}
-				return
-			}
-			orig.ServeHTTP(w, r)
+	api.Use(checkAuth(ctx, authChecker))
+	if validator != nil {
+		api.Use(validator.Middleware("/api"))
+	}
+	setupAPIRoutes(ctx, cfg, &h, authChecker, api, true)
+
+	if cfg.Static {
+		// Expose static files only
+		r.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/dist/static")))
+	} else {
+		// Path prefixed with "console-" are routed via react-router (standalone console)
+		r.PathPrefix("/console-").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, "./web/dist/index.html")
 		})
-	})
 
+		// Frontend files
+		r.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/dist/")))
+	}
+
+	return r
+}
+
+// setupAPIRoutes registers the API routes on a router, either the one of the console, which also proxies alerting
+// APIs, or the one of the versioned API
+func setupAPIRoutes(ctx context.Context, cfg *config.Config, h *handler.Handlers, authChecker auth.Checker, api *mux.Router, console bool) {
 	// Server status and config
 	api.HandleFunc("/status", h.Status(ctx))
 	api.HandleFunc("/frontend-config", h.GetFrontendConfig())
-
-	if cfg.Static {
-		// Expose static files only
-		r.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/dist/static")))
+	if spec, err := openapi.Handler(); err != nil {
+		logrus.Errorf("OpenAPI document is not served: %v", err)
 	} else {
+		api.HandleFunc("/openapi.json", spec)
+	}
+
+	if !cfg.Static {
 		// Loki endpoints
 		api.HandleFunc("/loki/ready", h.LokiReady())
 		api.HandleFunc("/loki/metrics", forceCheckAdmin(authChecker, h.LokiMetrics()))
 		api.HandleFunc("/loki/buildinfo", forceCheckAdmin(authChecker, h.LokiBuildInfos()))
 		api.HandleFunc("/loki/config/limits", forceCheckAdmin(authChecker, h.LokiLimits()))
+		api.HandleFunc("/admin/cardinality", forceCheckAdmin(authChecker, h.GetCardinality(ctx)))
 		api.HandleFunc("/loki/flow/records", h.GetFlows(ctx))
 		api.HandleFunc("/loki/export", h.ExportFlows(ctx))
+		if h.ExportJobs != nil {
+			api.HandleFunc("/loki/export/jobs", h.SubmitExportJob(ctx)).Methods(http.MethodPost)
+			api.HandleFunc("/loki/export/jobs", h.GetExportJobs(ctx)).Methods(http.MethodGet)
+			api.HandleFunc("/loki/export/jobs/{id}", h.GetExportJob(ctx)).Methods(http.MethodGet)
+			api.HandleFunc("/loki/export/jobs/{id}", h.DeleteExportJob(ctx)).Methods(http.MethodDelete)
+			api.HandleFunc("/loki/export/jobs/{id}/download", h.DownloadExportJob(ctx)).Methods(http.MethodGet)
+		}
 
 		// Common endpoints
 		api.HandleFunc("/flow/metrics", h.GetTopology(ctx))
+		api.HandleFunc("/flow/metrics/export", h.ExportTopology(ctx))
+		api.HandleFunc("/alerts/topology", h.GetTopologyAlerts(ctx))
 		api.HandleFunc("/resources/clusters", h.GetClusters(ctx))
 		api.HandleFunc("/resources/udns", h.GetUDNs(ctx))
 		api.HandleFunc("/resources/zones", h.GetZones(ctx))
@@ -68,16 +114,17 @@ func setupRoutes(ctx context.Context, cfg *config.Config, authChecker auth.Check
 
 		// K8S endpoints
 		api.HandleFunc("/k8s/resources/udnIds", h.GetUDNIdss(ctx))
-
-		// Path prefixed with "console-" are routed via react-router (standalone console)
-		r.PathPrefix("/console-").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			http.ServeFile(w, r, "./web/dist/index.html")
-		})
-
-		// Frontend files
-		r.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/dist/")))
 	}
 
+	if cfg.Prometheus.AlertManager.URL != "" || cfg.Loki.UseMocks {
+		// Silences scoped to netobserv alerts
+		api.HandleFunc("/alerts/silences", h.GetSilences(ctx)).Methods(http.MethodGet)
+		api.HandleFunc("/alerts/silences", h.CreateSilence(ctx)).Methods(http.MethodPost)
+		api.HandleFunc("/alerts/silences/{id}", h.ExpireSilence(ctx)).Methods(http.MethodDelete)
+	}
+	if !console {
+		return
+	}
 	if cfg.Prometheus.AlertManager.URL != "" {
 		// When AlertManager URL is configured, we don't use the Console proxy; doing our own proxy instead (likely, we're in standalone mode)
 		api.HandleFunc("/prometheus/api/v1/rules", h.PromProxyRules())
@@ -89,8 +136,23 @@ func setupRoutes(ctx context.Context, cfg *config.Config, authChecker auth.Check
 		api.HandleFunc("/prometheus/api/v1/query", alertingmock.GetQuery())
 		api.HandleFunc("/alertmanager/api/v2/silences", alertingmock.GetSilences())
 	}
+}
 
-	return r
+// checkAuth is the middleware checking that API requests are authorized
+func checkAuth(ctx context.Context, authChecker auth.Checker) mux.MiddlewareFunc {
+	return func(orig http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := authChecker.CheckAuth(ctx, r.Header); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, err2 := w.Write([]byte(err.Error()))
+				if err2 != nil {
+					logrus.Errorf("Error while responding an error: %v (initial was: %v)", err2, err)
+				}
+				return
+			}
+			orig.ServeHTTP(w, r)
+		})
+	}
 }
 
 func getRole(authChecker auth.Checker) func(http.ResponseWriter, *http.Request) {
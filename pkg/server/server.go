@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -18,15 +17,10 @@ func Start(ctx context.Context, cfg *config.Config, authChecker auth.Checker) {
 	router := setupRoutes(ctx, cfg, authChecker)
 	router.Use(corsHeader(cfg))
 
-	writeTimeout := 30 * time.Second
-	if cfg.Loki.Timeout.Seconds() > writeTimeout.Seconds() {
-		writeTimeout = cfg.Loki.Timeout.Duration
-	}
-
 	httpServer := defaultServer(&http.Server{
 		Handler:      router,
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		WriteTimeout: writeTimeout,
+		WriteTimeout: cfg.WriteTimeout(),
 	})
 
 	if cfg.Server.CertPath != "" && cfg.Server.KeyPath != "" {
@@ -9,6 +9,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -22,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -520,9 +522,21 @@ func (a *authMock) CheckAdmin(ctx context.Context, header http.Header) error {
 	return args.Error(0)
 }
 
+func (a *authMock) CheckNamespace(ctx context.Context, header http.Header, namespace string) error {
+	args := a.Called(ctx, header, namespace)
+	return args.Error(0)
+}
+
+func (a *authMock) GetUsername(ctx context.Context, header http.Header) (string, error) {
+	args := a.Called(ctx, header)
+	return args.String(0), args.Error(1)
+}
+
 func (a *authMock) MockGranted() {
 	a.On("CheckAuth", mock.Anything, mock.Anything).Return(nil)
 	a.On("CheckAdmin", mock.Anything, mock.Anything).Return(nil)
+	a.On("CheckNamespace", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	a.On("GetUsername", mock.Anything, mock.Anything).Return("admin", nil)
 }
 
 func prepareServerAssets(t *testing.T) string {
@@ -694,3 +708,111 @@ func (conf *httpClientConfig) buildHTTPClient() (*http.Client, error) {
 
 	return &httpClient, nil
 }
+
+func TestOpenAPI(t *testing.T) {
+	authM := &authMock{}
+	authM.MockGranted()
+	backendRoutes := setupRoutes(t.Context(), &config.Config{
+		Loki:   config.Loki{UseMocks: true},
+		Export: config.Export{Jobs: config.ExportJobs{Dir: t.TempDir()}},
+	}, authM)
+	backendSvc := httptest.NewServer(backendRoutes)
+	defer backendSvc.Close()
+
+	// WHEN the OpenAPI document is queried
+	resp, err := backendSvc.Client().Get(backendSvc.URL + "/api/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var doc struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+
+	// THEN it documents every API route
+	err = backendRoutes.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(tpl, "/api/") || route.GetHandler() == nil {
+			return nil
+		}
+		// the versioned API has the same paths
+		path := strings.TrimPrefix(strings.TrimPrefix(tpl, "/api"), "/v2")
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+		for _, method := range methods {
+			assert.Contains(t, doc.Paths[path], strings.ToLower(method), "%s %s is not documented", method, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// AND requests are validated against it
+	resp, err = backendSvc.Client().Get(backendSvc.URL + "/api/loki/flow/records?recordType=foo")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"validation":true,"parameter":"recordType","message":"invalid recordType: foo is not one of allConnections, newConnection, heartbeat, endConnection, flowLog"}`, string(body))
+}
+
+func TestAPIv2(t *testing.T) {
+	lokiMock := httpMock{}
+	lokiMock.On("ServeHTTP", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		_, _ = args.Get(0).(http.ResponseWriter).Write([]byte(`{"status":"","data":{"resultType":"streams","result":[]}}`))
+	})
+	lokiSvc := httptest.NewServer(&lokiMock)
+	defer lokiSvc.Close()
+	cfg := &config.Config{Loki: config.Loki{URL: lokiSvc.URL, Timeout: config.Duration{Duration: time.Second}}}
+
+	get := func(routes http.Handler, path string) (int, string) {
+		backendSvc := httptest.NewServer(routes)
+		defer backendSvc.Close()
+		resp, err := backendSvc.Client().Get(backendSvc.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	// GIVEN an unauthorized user
+	denied := &authMock{}
+	denied.On("CheckAuth", mock.Anything, mock.Anything).Return(errors.New("missing Authorization header"))
+	// THEN errors are in an envelope
+	code, body := get(setupRoutes(t.Context(), cfg, denied), "/api/v2/loki/flow/records")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.JSONEq(t, `{"errors":[{"code":"Unauthorized","message":"missing Authorization header"}]}`, body)
+
+	// GIVEN an authorized user
+	authM := &authMock{}
+	authM.MockGranted()
+	routes := setupRoutes(t.Context(), cfg, authM)
+
+	// THEN flows are in an envelope, with their stats
+	code, body = get(routes, "/api/v2/loki/flow/records?limit=10")
+	assert.Equal(t, http.StatusOK, code)
+	var env struct {
+		Data struct {
+			ResultType string `json:"resultType"`
+		} `json:"data"`
+		Stats model.AggregatedStats `json:"stats"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &env))
+	assert.Equal(t, model.ResultTypeStream, env.Data.ResultType)
+	assert.Equal(t, 1, env.Stats.NumQueries)
+
+	// AND so are validation errors
+	code, body = get(routes, "/api/v2/flow/metrics?aggregateBy=app&limit=foo")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.JSONEq(t, `{"errors":[{"code":"Validation","message":"invalid limit: foo is not an integer",
+		"details":{"validation":true,"parameter":"limit","message":"invalid limit: foo is not an integer"}}]}`, body)
+
+	// WHILE the console API is unchanged
+	code, body = get(routes, "/api/flow/metrics?aggregateBy=app&limit=foo")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.JSONEq(t, `{"validation":true,"parameter":"limit","message":"invalid limit: foo is not an integer"}`, body)
+}
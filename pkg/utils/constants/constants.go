@@ -11,6 +11,8 @@ const (
 	AppLabel        = "app"
 	AppLabelValue   = "netobserv-flowcollector"
 	RecordTypeLabel = "_RecordType"
+	// TenantLabel is set by Loki on results of multi-tenant queries
+	TenantLabel = "__tenant_id__"
 
 	MetricTypeFlows          = "Flows"
 	MetricTypeBytes          = "Bytes"
@@ -0,0 +1,23 @@
+#!/usr/bin/env bash
+# Reads the Parquet golden file with pyarrow, as a reference reader, and compares its rows with the writer input
+set -e
+
+echo "Checking pkg/parquet/testdata/flows.parquet with pyarrow..."
+python3 - <<'PYTHON'
+import json
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+table = pq.read_table("pkg/parquet/testdata/flows.parquet")
+# compare timestamps as milliseconds, like in the JSON input
+table = table.cast(pa.schema([
+    pa.field(f.name, pa.int64()) if pa.types.is_timestamp(f.type) else f
+    for f in table.schema
+]))
+with open("pkg/parquet/testdata/flows.json") as f:
+    expected = json.load(f)
+actual = table.to_pylist()
+if actual != expected:
+    raise SystemExit(f"rows differ:\n  expected {expected}\n  actual   {actual}")
+print(f"{len(actual)} rows match")
+PYTHON
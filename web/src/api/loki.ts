@@ -16,7 +16,38 @@ export interface Stats {
   numQueries: number;
   limitReached: boolean;
   dataSources: string[];
-  // Here, more (raw) stats available in queriesStats array
+  queriesStats?: QueryStats[];
+  totals?: QueryCosts;
+  estimate?: boolean;
+  degradation?: Degradation;
+}
+
+// How queries were made cheaper after Loki rejected them for its limits
+export interface Degradation {
+  reason: 'seriesLimit' | 'timeout';
+  applied: ('coarserStep' | 'narrowerTopk' | 'timeSharding' | 'shorterRange')[];
+  step?: string;
+  top?: string;
+  shards?: number;
+  start?: string;
+}
+
+// Times are in seconds
+export interface QueryCosts {
+  bytesProcessed: number;
+  linesProcessed: number;
+  execTime: number;
+  queueTime: number;
+  cacheHits: number;
+  cacheRequests: number;
+  subqueries: number;
+  splits: number;
+  shards: number;
+}
+
+export interface QueryStats extends QueryCosts {
+  dataSource: string;
+  queryHash: string;
 }
 
 export interface StreamResult {
@@ -1,6 +1,6 @@
 import axios from 'axios';
 import { Config, defaultConfig } from '../model/config';
-import { buildExportQuery } from '../model/export-query';
+import { buildExportQuery, buildTopologyExportQuery, ExportFormat, TopologyExportFormat } from '../model/export-query';
 import { FlowQuery, FlowScope, isTimeMetric } from '../model/flow-query';
 import { ContextSingleton } from '../utils/context';
 import { TimeRange } from '../utils/datetime';
@@ -73,11 +73,21 @@ export const queryPrometheusMetric = (query: string): Promise<unknown> => {
   });
 };
 
-export const getExportFlowsURL = (params: FlowQuery, columns?: string[]): string => {
-  const exportQuery = buildExportQuery(params, columns);
+export const getExportFlowsURL = (
+  params: FlowQuery,
+  columns?: string[],
+  format?: ExportFormat,
+  pretty?: boolean
+): string => {
+  const exportQuery = buildExportQuery(params, columns, format, pretty);
   return `${ContextSingleton.getHost()}/api/loki/export?${exportQuery}`;
 };
 
+export const getExportTopologyURL = (params: FlowQuery, format: TopologyExportFormat, totals?: boolean): string => {
+  const exportQuery = buildTopologyExportQuery(params, format, totals);
+  return `${ContextSingleton.getHost()}/api/flow/metrics/export?${exportQuery}`;
+};
+
 export const getRole = (): Promise<string> => {
   return axios.get(ContextSingleton.getHost() + '/role').then(r => {
     return r.data;
@@ -226,6 +236,7 @@ export const getConfig = (): Promise<Config> => {
       dataSources: r.data.dataSources || defaultConfig.dataSources,
       promLabels: r.data.promLabels || defaultConfig.promLabels,
       lokiLabels: r.data.lokiLabels || defaultConfig.lokiLabels,
+      lokiTenants: r.data.lokiTenants,
       maxChunkAgeMs: r.data.maxChunkAgeMs,
       recordingAnnotations: r.data.recordingAnnotations || defaultConfig.recordingAnnotations
     };
@@ -1,4 +1,4 @@
-import { buildExportQuery } from '../export-query';
+import { buildExportQuery, buildTopologyExportQuery } from '../export-query';
 
 describe('buildExportQuery', () => {
   it('should build without columns', () => {
@@ -32,4 +32,45 @@ describe('buildExportQuery', () => {
       'filters=&recordType=flowLog&dataSource=auto&packetLoss=all&limit=500&format=csv&columns=foo%2Cbar'
     );
   });
+
+  it('should build pretty csv', () => {
+    const query = buildExportQuery(
+      {
+        filters: '',
+        recordType: 'flowLog',
+        dataSource: 'auto',
+        packetLoss: 'all',
+        limit: 500
+      },
+      ['foo'],
+      'csv',
+      true
+    );
+    expect(query).toEqual(
+      'filters=&recordType=flowLog&dataSource=auto&packetLoss=all&limit=500&format=csv&columns=foo&pretty=true'
+    );
+  });
+});
+
+describe('buildTopologyExportQuery', () => {
+  it('should build totals csv', () => {
+    const query = buildTopologyExportQuery(
+      {
+        filters: '',
+        recordType: 'flowLog',
+        dataSource: 'auto',
+        packetLoss: 'all',
+        limit: 50,
+        aggregateBy: 'namespace',
+        type: 'Bytes',
+        function: 'rate'
+      },
+      'csv',
+      true
+    );
+    expect(query).toEqual(
+      // eslint-disable-next-line max-len
+      'filters=&recordType=flowLog&dataSource=auto&packetLoss=all&limit=50&aggregateBy=namespace&type=Bytes&function=rate&format=csv&totals=true'
+    );
+  });
 });
@@ -38,6 +38,7 @@ export type Config = {
   dataSources: string[];
   lokiMocks: boolean;
   lokiLabels: string[];
+  lokiTenants?: string[];
   promLabels: string[];
   maxChunkAgeMs?: number;
   recordingAnnotations?: RecordingAnnotations;
@@ -1,10 +1,18 @@
 import * as _ from 'lodash';
 import { FlowQuery } from './flow-query';
 
-export const buildExportQuery = (flowQuery: FlowQuery, columns?: string[]) => {
+export type ExportFormat = 'csv' | 'json' | 'ndjson' | 'parquet' | 'zeek' | 'hubble';
+export type TopologyExportFormat = 'csv' | 'openmetrics' | 'graphml' | 'dot';
+
+export const buildExportQuery = (
+  flowQuery: FlowQuery,
+  columns?: string[],
+  format: ExportFormat = 'csv',
+  pretty?: boolean
+) => {
   const query = {
     ...flowQuery,
-    format: 'csv'
+    format
     // no-explicit-any disabled: URLSearchParams actually accepts any object
     // even though its typescript def doesn't say so
     // eslint-disable-next-line @typescript-eslint/no-explicit-any
@@ -12,6 +20,19 @@ export const buildExportQuery = (flowQuery: FlowQuery, columns?: string[]) => {
   if (columns) {
     query.columns = String(columns);
   }
+  if (pretty) {
+    query.pretty = 'true';
+  }
+  const omitEmpty = _.omitBy(query, a => a === undefined);
+  return new URLSearchParams(omitEmpty).toString();
+};
+
+export const buildTopologyExportQuery = (flowQuery: FlowQuery, format: TopologyExportFormat, totals?: boolean) => {
+  // eslint-disable-next-line @typescript-eslint/no-explicit-any
+  const query = { ...flowQuery, format } as any;
+  if (totals) {
+    query.totals = 'true';
+  }
   const omitEmpty = _.omitBy(query, a => a === undefined);
   return new URLSearchParams(omitEmpty).toString();
 };